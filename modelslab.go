@@ -53,6 +53,17 @@ func NewEnterpriseWithConfig(config *client.Config) *ModelsLab {
 	return NewWithClient(c, true)
 }
 
+// NewSDK creates a new ModelsLab SDK instance from client options. Enterprise
+// mode is selected with client.WithEnterprise. Unlike NewWithConfig, an
+// invalid configuration is returned as an error instead of a panic.
+func NewSDK(opts ...client.Option) (*ModelsLab, error) {
+	c, err := client.NewClient(opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithClient(c, c.IsEnterprise()), nil
+}
+
 // NewWithClient creates a new ModelsLab SDK instance with a custom client
 func NewWithClient(c *client.Client, enterprise bool) *ModelsLab {
 	return &ModelsLab{
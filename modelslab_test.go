@@ -0,0 +1,49 @@
+package modelslab
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/client"
+)
+
+func TestNewSDK(t *testing.T) {
+	t.Setenv("MODELSLAB_API_KEY", "")
+
+	tests := []struct {
+		name    string
+		opts    []client.Option
+		wantErr string
+	}{
+		{name: "valid", opts: []client.Option{client.WithAPIKey("key"), client.WithEnterprise(true)}},
+		{name: "empty API key", wantErr: "APIKey"},
+		{name: "negative timeout", opts: []client.Option{client.WithAPIKey("key"), client.WithHTTPTimeout(-time.Second)}, wantErr: "HTTPTimeout"},
+		{
+			name: "conflicting limits",
+			opts: []client.Option{
+				client.WithAPIKey("key"),
+				client.WithRateLimit(client.Limits{MaxInFlight: 1}),
+				client.WithModuleLimits("3d", client.Limits{MaxInFlight: 3}),
+			},
+			wantErr: "3d module allows 3 requests in flight",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sdk, err := NewSDK(tt.opts...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("NewSDK() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !sdk.IsEnterprise() || sdk.Audio() == nil || sdk.Video() == nil {
+				t.Errorf("NewSDK() = %+v, want an enterprise SDK with every module", sdk)
+			}
+		})
+	}
+}
@@ -23,7 +23,7 @@ func New(c *client.Client, enterprise bool) *API {
 }
 
 // TextToAudio performs text-to-audio conversion
-func (a *API) TextToAudio(ctx context.Context, req *audio.Text2AudioRequest) (*client.APIResponse, error) {
+func (a *API) TextToAudio(ctx context.Context, req *audio.Text2AudioRequest) (*audio.AudioResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -34,11 +34,31 @@ func (a *API) TextToAudio(ctx context.Context, req *audio.Text2AudioRequest) (*c
 		return nil, fmt.Errorf("text-to-audio request failed: %w", err)
 	}
 
-	return resp, nil
+	var out audio.AudioResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("text-to-audio request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // TextToSpeech performs text-to-speech conversion
-func (a *API) TextToSpeech(ctx context.Context, req *audio.Text2SpeechRequest) (*client.APIResponse, error) {
+func (a *API) TextToSpeech(ctx context.Context, req *audio.Text2SpeechRequest) (*audio.AudioResponse, error) {
+	resp, err := a.textToSpeech(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var out audio.AudioResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("text-to-speech request failed: %w", err)
+	}
+
+	return &out, nil
+}
+
+// textToSpeech sends a text-to-speech request and returns the raw response
+func (a *API) textToSpeech(ctx context.Context, req *audio.Text2SpeechRequest) (*client.APIResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -48,12 +68,11 @@ func (a *API) TextToSpeech(ctx context.Context, req *audio.Text2SpeechRequest) (
 	if err != nil {
 		return nil, fmt.Errorf("text-to-speech request failed: %w", err)
 	}
-
 	return resp, nil
 }
 
 // Voice2Voice performs voice-to-voice conversion
-func (a *API) Voice2Voice(ctx context.Context, req *audio.Voice2VoiceRequest) (*client.APIResponse, error) {
+func (a *API) Voice2Voice(ctx context.Context, req *audio.Voice2VoiceRequest) (*audio.AudioResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -64,11 +83,16 @@ func (a *API) Voice2Voice(ctx context.Context, req *audio.Voice2VoiceRequest) (*
 		return nil, fmt.Errorf("voice-to-voice request failed: %w", err)
 	}
 
-	return resp, nil
+	var out audio.AudioResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("voice-to-voice request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // VoiceCover performs voice cover generation
-func (a *API) VoiceCover(ctx context.Context, req *audio.VoiceCoverRequest) (*client.APIResponse, error) {
+func (a *API) VoiceCover(ctx context.Context, req *audio.VoiceCoverRequest) (*audio.AudioResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -79,11 +103,16 @@ func (a *API) VoiceCover(ctx context.Context, req *audio.VoiceCoverRequest) (*cl
 		return nil, fmt.Errorf("voice cover request failed: %w", err)
 	}
 
-	return resp, nil
+	var out audio.AudioResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("voice cover request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // MusicGen performs music generation
-func (a *API) MusicGen(ctx context.Context, req *audio.MusicGenRequest) (*client.APIResponse, error) {
+func (a *API) MusicGen(ctx context.Context, req *audio.MusicGenRequest) (*base.Job[audio.AudioResponse], error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -94,11 +123,31 @@ func (a *API) MusicGen(ctx context.Context, req *audio.MusicGenRequest) (*client
 		return nil, fmt.Errorf("music generation request failed: %w", err)
 	}
 
-	return resp, nil
+	job, err := base.NewJob[audio.AudioResponse](a.BaseAPI, resp)
+	if err != nil {
+		return nil, fmt.Errorf("music generation request failed: %w", err)
+	}
+
+	return job, nil
 }
 
 // LyricsGen performs lyrics generation
-func (a *API) LyricsGen(ctx context.Context, req *audio.LyricsGeneratorRequest) (*client.APIResponse, error) {
+func (a *API) LyricsGen(ctx context.Context, req *audio.LyricsGeneratorRequest) (*audio.LyricsResponse, error) {
+	resp, err := a.lyricsGen(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	var out audio.LyricsResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("lyrics generation request failed: %w", err)
+	}
+
+	return &out, nil
+}
+
+// lyricsGen sends a lyrics generation request and returns the raw response
+func (a *API) lyricsGen(ctx context.Context, req *audio.LyricsGeneratorRequest) (*client.APIResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -108,12 +157,11 @@ func (a *API) LyricsGen(ctx context.Context, req *audio.LyricsGeneratorRequest)
 	if err != nil {
 		return nil, fmt.Errorf("lyrics generation request failed: %w", err)
 	}
-
 	return resp, nil
 }
 
 // SongGenerator performs song generation
-func (a *API) SongGenerator(ctx context.Context, req *audio.SongGeneratorRequest) (*client.APIResponse, error) {
+func (a *API) SongGenerator(ctx context.Context, req *audio.SongGeneratorRequest) (*base.Job[audio.AudioResponse], error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -124,26 +172,45 @@ func (a *API) SongGenerator(ctx context.Context, req *audio.SongGeneratorRequest
 		return nil, fmt.Errorf("song generation request failed: %w", err)
 	}
 
-	return resp, nil
+	job, err := base.NewJob[audio.AudioResponse](a.BaseAPI, resp)
+	if err != nil {
+		return nil, fmt.Errorf("song generation request failed: %w", err)
+	}
+
+	return job, nil
 }
 
-// SpeechToText performs speech-to-text conversion
-func (a *API) SpeechToText(ctx context.Context, req *audio.Speech2TextRequest) (*client.APIResponse, error) {
+// SpeechToText performs speech-to-text conversion. A local Audio file is
+// checked for a supported format and MaxSpeechToTextDuration before it is
+// sent. When the API queues a long recording, SpeechToText polls for the
+// transcript until it is ready.
+func (a *API) SpeechToText(ctx context.Context, req *audio.Speech2TextRequest) (*audio.TranscriptionResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
 
+	body, cleanup, err := speechToTextBody(req)
+	if err != nil {
+		return nil, fmt.Errorf("speech-to-text request failed: %w", err)
+	}
+	defer cleanup()
+
 	endpoint := a.GetBaseURL() + "speech_to_text"
-	resp, err := a.GetClient().Post(ctx, endpoint, req)
+	resp, err := a.GetClient().Post(ctx, endpoint, body)
 	if err != nil {
 		return nil, fmt.Errorf("speech-to-text request failed: %w", err)
 	}
 
-	return resp, nil
+	out, err := base.Await[audio.TranscriptionResponse](ctx, a.BaseAPI, resp)
+	if err != nil {
+		return nil, fmt.Errorf("speech-to-text request failed: %w", err)
+	}
+
+	return out, nil
 }
 
 // SFXGen performs sound effects generation
-func (a *API) SFXGen(ctx context.Context, req *audio.SFXRequest) (*client.APIResponse, error) {
+func (a *API) SFXGen(ctx context.Context, req *audio.SFXRequest) (*audio.AudioResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -154,5 +221,10 @@ func (a *API) SFXGen(ctx context.Context, req *audio.SFXRequest) (*client.APIRes
 		return nil, fmt.Errorf("SFX generation request failed: %w", err)
 	}
 
-	return resp, nil
+	var out audio.AudioResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("SFX generation request failed: %w", err)
+	}
+
+	return &out, nil
 }
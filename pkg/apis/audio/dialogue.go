@@ -0,0 +1,132 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/modelslab/modelslab-go/pkg/schemas/audio"
+	"github.com/modelslab/modelslab-go/pkg/utils"
+)
+
+// DialogueOptions configures Dialogue
+type DialogueOptions struct {
+	// Concurrency is how many lines are synthesized at once
+	Concurrency int
+	// Gap is the silence between consecutive lines of the same speaker
+	Gap time.Duration
+	// TurnGap is the silence where the speaker changes
+	TurnGap time.Duration
+	// Processing, when set, is applied to the track. Silence is trimmed
+	// from each line before stitching, so the gaps are exactly as
+	// configured.
+	Processing *utils.AudioProcessing
+	// Lexicon, when set, replaces words with the spelling the voices should
+	// read before the lines are synthesized
+	Lexicon *Lexicon
+}
+
+// DefaultDialogueOptions returns the options used when none are given
+func DefaultDialogueOptions() *DialogueOptions {
+	return &DialogueOptions{
+		Concurrency: 4,
+		Gap:         250 * time.Millisecond,
+		TurnGap:     500 * time.Millisecond,
+	}
+}
+
+// DialogueResult is a rendered dialogue
+type DialogueResult struct {
+	// Audio is the track as a WAV file
+	Audio []byte
+	// PCM is the decoded track
+	PCM      *utils.PCM
+	Duration time.Duration
+	// Manifest lists when each line is spoken; it also renders captions
+	Manifest *audio.DialogueManifest
+}
+
+// Dialogue renders a multi-speaker script into one track. Each line is
+// synthesized with the voice settings of its speaker, up to Concurrency lines
+// at once, and the lines are joined with the configured gaps. When opts is
+// nil, DefaultDialogueOptions is used.
+func (a *API) Dialogue(ctx context.Context, script *audio.DialogueScript, opts *DialogueOptions) (*DialogueResult, error) {
+	if script == nil {
+		return nil, fmt.Errorf("script cannot be nil")
+	}
+	if opts == nil {
+		opts = DefaultDialogueOptions()
+	}
+	if err := validator.New().Struct(script); err != nil {
+		return nil, fmt.Errorf("invalid dialogue script: %w", err)
+	}
+
+	reqs := make([]*audio.Text2SpeechRequest, len(script.Lines))
+	pauses := make([]time.Duration, len(script.Lines))
+	for i, line := range script.Lines {
+		speaker, ok := script.Speakers[line.Speaker]
+		if !ok {
+			return nil, fmt.Errorf("invalid dialogue script: line %d has unknown speaker %q", i+1, line.Speaker)
+		}
+
+		voiceID := speaker.VoiceID
+		reqs[i] = &audio.Text2SpeechRequest{
+			Prompt:   opts.Lexicon.Apply(line.Text),
+			VoiceID:  &voiceID,
+			Language: speaker.Language,
+			Speed:    speaker.Speed,
+			Emotion:  speaker.Emotion,
+		}
+
+		switch {
+		case line.PauseBefore != nil:
+			pauses[i] = time.Duration(*line.PauseBefore * float64(time.Second))
+		case i > 0 && script.Lines[i-1].Speaker != line.Speaker:
+			pauses[i] = opts.TurnGap
+		default:
+			pauses[i] = opts.Gap
+		}
+	}
+
+	clips, err := a.synthesizeAll(ctx, reqs, opts.Concurrency, "line")
+	if err != nil {
+		return nil, fmt.Errorf("dialogue failed: %w", err)
+	}
+
+	pcm, spans, err := stitch(clips, pauses, opts.Processing)
+	if err != nil {
+		return nil, fmt.Errorf("dialogue failed: %w", err)
+	}
+	data, err := encodeWAV(pcm)
+	if err != nil {
+		return nil, fmt.Errorf("dialogue failed: %w", err)
+	}
+
+	manifest := &audio.DialogueManifest{
+		Duration: seconds(pcm.Duration()),
+		Lines:    make([]audio.DialogueTiming, len(script.Lines)),
+	}
+	for i, line := range script.Lines {
+		manifest.Lines[i] = audio.DialogueTiming{
+			Index:   i,
+			Speaker: line.Speaker,
+			VoiceID: script.Speakers[line.Speaker].VoiceID,
+			Text:    line.Text,
+			Start:   seconds(spans[i].Start),
+			End:     seconds(spans[i].End),
+		}
+	}
+
+	return &DialogueResult{
+		Audio:    data,
+		PCM:      pcm,
+		Duration: pcm.Duration(),
+		Manifest: manifest,
+	}, nil
+}
+
+// seconds converts a duration to seconds, rounded to the millisecond
+func seconds(d time.Duration) float64 {
+	return d.Round(time.Millisecond).Seconds()
+}
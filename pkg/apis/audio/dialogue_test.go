@@ -0,0 +1,134 @@
+package audio
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/schemas/audio"
+	"github.com/modelslab/modelslab-go/pkg/schemas/base"
+)
+
+func TestDialogue(t *testing.T) {
+	script := &audio.DialogueScript{
+		Speakers: map[string]audio.Speaker{
+			"host":  {VoiceID: "madison", Emotion: base.StringPtr("happy")},
+			"guest": {VoiceID: "jessica", Speed: base.Float64Ptr(1.25)},
+		},
+		Lines: []audio.DialogueLine{
+			{Speaker: "host", Text: "Welcome back."},
+			{Speaker: "host", Text: "Today we have a guest."},
+			{Speaker: "guest", Text: "Thanks for having me."},
+			{Speaker: "host", Text: "Queued question?"},
+			{Speaker: "guest", Text: "Sure.", PauseBefore: base.Float64Ptr(1)},
+		},
+	}
+	// Same speaker, turn, turn, and the line's own pause
+	wantPauses := []time.Duration{0, 50 * time.Millisecond, 200 * time.Millisecond, 200 * time.Millisecond, time.Second}
+
+	fake := speechServer(t)
+	opts := &DialogueOptions{Concurrency: 2, Gap: 50 * time.Millisecond, TurnGap: 200 * time.Millisecond}
+	result, err := fake.api.Dialogue(context.Background(), script, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reqs := fake.requests()
+	if len(reqs) != len(script.Lines) {
+		t.Fatalf("sent %d requests, want %d", len(reqs), len(script.Lines))
+	}
+	for _, req := range reqs {
+		var line audio.DialogueLine
+		for _, l := range script.Lines {
+			if l.Text == req.Prompt {
+				line = l
+			}
+		}
+		speaker := script.Speakers[line.Speaker]
+		if req.VoiceID != speaker.VoiceID {
+			t.Errorf("line %q voiced by %q, want %q", req.Prompt, req.VoiceID, speaker.VoiceID)
+		}
+		if speaker.Emotion != nil && req.Emotion != *speaker.Emotion {
+			t.Errorf("line %q has emotion %q, want %q", req.Prompt, req.Emotion, *speaker.Emotion)
+		}
+		if speaker.Speed != nil && req.Speed != *speaker.Speed {
+			t.Errorf("line %q has speed %v, want %v", req.Prompt, req.Speed, *speaker.Speed)
+		}
+	}
+	if n := fake.concurrency(); n > opts.Concurrency {
+		t.Errorf("%d lines were synthesized at once, want at most %d", n, opts.Concurrency)
+	}
+
+	m := result.Manifest
+	if len(m.Lines) != len(script.Lines) {
+		t.Fatalf("manifest has %d lines, want %d", len(m.Lines), len(script.Lines))
+	}
+	var end time.Duration
+	for i, timing := range m.Lines {
+		line := script.Lines[i]
+		if timing.Index != i || timing.Speaker != line.Speaker || timing.Text != line.Text || timing.VoiceID != script.Speakers[line.Speaker].VoiceID {
+			t.Errorf("manifest line %d = %+v, want line %+v", i, timing, line)
+		}
+		wantStart := end + wantPauses[i]
+		wantEnd := wantStart + time.Duration(len(line.Text))*clipPerRune
+		if timing.Start != wantStart.Seconds() || timing.End != wantEnd.Seconds() {
+			t.Errorf("line %d spans %v-%v, want %v-%v", i, timing.Start, timing.End, wantStart.Seconds(), wantEnd.Seconds())
+		}
+		end = wantEnd
+	}
+	if result.Duration != end || m.Duration != end.Seconds() {
+		t.Errorf("duration = %v (manifest %v), want %v", result.Duration, m.Duration, end)
+	}
+
+	// The captions follow the manifest
+	srt := m.SRT(nil)
+	if !strings.Contains(srt, "3\n00:00:00,600 --> 00:00:00,810\nguest: Thanks for having me.\n") {
+		t.Errorf("SRT() =\n%s\nwant the third line labelled and timed", srt)
+	}
+}
+
+func TestDialogueInvalidScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  *audio.DialogueScript
+		wantErr string
+	}{
+		{name: "nil", wantErr: "script cannot be nil"},
+		{
+			name: "unknown speaker",
+			script: &audio.DialogueScript{
+				Speakers: map[string]audio.Speaker{"host": {VoiceID: "madison"}},
+				Lines:    []audio.DialogueLine{{Speaker: "host", Text: "Hi."}, {Speaker: "guest", Text: "Hello."}},
+			},
+			wantErr: `line 2 has unknown speaker "guest"`,
+		},
+		{
+			name: "no lines",
+			script: &audio.DialogueScript{
+				Speakers: map[string]audio.Speaker{"host": {VoiceID: "madison"}},
+			},
+			wantErr: "invalid dialogue script",
+		},
+		{
+			name: "no voice",
+			script: &audio.DialogueScript{
+				Speakers: map[string]audio.Speaker{"host": {}},
+				Lines:    []audio.DialogueLine{{Speaker: "host", Text: "Hi."}},
+			},
+			wantErr: "invalid dialogue script",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := speechServer(t)
+			_, err := fake.api.Dialogue(context.Background(), tt.script, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Dialogue() error = %v, want %q", err, tt.wantErr)
+			}
+			if n := len(fake.requests()); n != 0 {
+				t.Errorf("sent %d requests for an invalid script", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/modelslab/modelslab-go/pkg/schemas/audio"
+	"github.com/modelslab/modelslab-go/pkg/utils"
+)
+
+// DownloadAudio fetches and decodes the audio of a finished response, such as
+// the result of TextToSpeech, SFXGen or a MusicGen job. When processing is
+// not nil, it is applied to the decoded audio.
+func (a *API) DownloadAudio(ctx context.Context, out *audio.AudioResponse, processing *utils.AudioProcessing) (*utils.PCM, error) {
+	if out == nil {
+		return nil, fmt.Errorf("response cannot be nil")
+	}
+
+	data, err := a.audioData(ctx, out)
+	if err != nil {
+		return nil, err
+	}
+
+	pcm, err := utils.DecodeAudio(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio: %w", err)
+	}
+
+	if processing != nil {
+		if pcm, err = pcm.Process(processing); err != nil {
+			return nil, err
+		}
+	}
+	return pcm, nil
+}
+
+// audioData returns the audio file of a finished response, downloading it
+// when the response links to it
+func (a *API) audioData(ctx context.Context, out *audio.AudioResponse) ([]byte, error) {
+	if url := audioURL(out); url != "" {
+		return a.GetClient().Download(ctx, url)
+	}
+
+	if out.AudioData == "" {
+		return nil, fmt.Errorf("response has no audio")
+	}
+	encoded := out.AudioData
+	if strings.HasPrefix(encoded, "data:") {
+		if i := strings.Index(encoded, ","); i >= 0 {
+			encoded = encoded[i+1:]
+		}
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio data: %w", err)
+	}
+	return data, nil
+}
+
+// audioURL returns the link to the audio of a finished response, or "" when
+// the audio is inline
+func audioURL(out *audio.AudioResponse) string {
+	if len(out.Output) > 0 {
+		return out.Output[0]
+	}
+	return out.AudioURL
+}
@@ -0,0 +1,297 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/schemas/audio"
+	"github.com/modelslab/modelslab-go/pkg/utils"
+)
+
+// Translator translates transcript segments for Dub
+type Translator interface {
+	// Translate returns the translation of each text, in order. source is
+	// empty when the spoken language is unknown; target is the language
+	// the dub is voiced in.
+	Translate(ctx context.Context, texts []string, source, target string) ([]string, error)
+}
+
+// IdentityTranslator returns texts unchanged. It is useful in tests and to
+// re-voice a recording in its own language.
+type IdentityTranslator struct{}
+
+// Translate returns texts unchanged
+func (IdentityTranslator) Translate(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	return texts, nil
+}
+
+// DubOptions configures Dub. Zero fields take their value from
+// DefaultDubOptions.
+type DubOptions struct {
+	// Translator translates the transcript; IdentityTranslator is used when
+	// it is nil
+	Translator Translator
+	// Concurrency is how many segments are synthesized at once
+	Concurrency int
+	// MaxSegmentDuration splits sentences that are spoken for longer
+	MaxSegmentDuration time.Duration
+	// MaxGap ends a segment at a pause longer than this
+	MaxGap time.Duration
+	// MaxSpeedup is the most a segment is sped up to fit the time of the
+	// original; 1 never speeds segments up. Segments that still run long
+	// push the following ones back.
+	MaxSpeedup float64
+	// Processing, when set, is applied to the track. Silence is trimmed
+	// from each segment before it is fitted to the original timing.
+	Processing *utils.AudioProcessing
+	// Lexicon, when set, is applied to the translations before synthesis
+	Lexicon *Lexicon
+}
+
+// DefaultDubOptions returns the options used when none are given
+func DefaultDubOptions() *DubOptions {
+	return &DubOptions{
+		Concurrency:        4,
+		MaxSegmentDuration: 15 * time.Second,
+		MaxGap:             time.Second,
+		MaxSpeedup:         1.5,
+	}
+}
+
+// DubSegment is a segment of the original speech and its dubbed version
+type DubSegment struct {
+	// Start and End are the times of the original speech
+	Start time.Duration
+	End   time.Duration
+	Text  string
+	// Translation is the text that was synthesized
+	Translation string
+	// DubStart and DubEnd are the times of the dubbed speech in the track
+	DubStart time.Duration
+	DubEnd   time.Duration
+	// Speedup is the rate the synthesized speech was sped up by to fit
+	Speedup float64
+}
+
+// DubResult is a dubbed recording
+type DubResult struct {
+	// Audio is the dubbed track as a WAV file
+	Audio []byte
+	// PCM is the decoded track
+	PCM      *utils.PCM
+	Duration time.Duration
+	// Transcript is the speech-to-text result of the original recording
+	Transcript *audio.TranscriptionResponse
+	Segments   []DubSegment
+	// Subtitles holds the translations at their dubbed times; export them
+	// with its SRT or WebVTT methods
+	Subtitles *audio.TranscriptionResponse
+}
+
+// Dub re-voices a recording in another language. The recording given by
+// source is transcribed with word timestamps and split into sentences, which
+// are translated into voice.Language and synthesized with the settings of
+// voice; voice.Prompt is ignored. Each segment starts at the time of the
+// original sentence, sped up to fit before the next one or padded with
+// silence when it is shorter. When opts is nil, DefaultDubOptions is used.
+func (a *API) Dub(ctx context.Context, source *audio.Speech2TextRequest, voice *audio.Text2SpeechRequest, opts *DubOptions) (*DubResult, error) {
+	if source == nil || voice == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	opts = dubDefaults(opts)
+	translator := opts.Translator
+	if translator == nil {
+		translator = IdentityTranslator{}
+	}
+
+	sttReq := *source
+	word := "word"
+	sttReq.TimestampLevel = &word
+	transcript, err := a.SpeechToText(ctx, &sttReq)
+	if err != nil {
+		return nil, fmt.Errorf("dubbing failed: %w", err)
+	}
+
+	cues := transcript.Cues(&audio.CaptionOptions{
+		MaxDuration: opts.MaxSegmentDuration,
+		MergeWords:  true,
+		MaxGap:      opts.MaxGap,
+	})
+	if len(cues) == 0 {
+		return nil, fmt.Errorf("dubbing failed: recording has no speech")
+	}
+
+	texts := make([]string, len(cues))
+	for i, cue := range cues {
+		texts[i] = cue.Text
+	}
+
+	var sourceLang, targetLang string
+	if source.InputLanguage != nil {
+		sourceLang = *source.InputLanguage
+	}
+	if voice.Language != nil {
+		targetLang = *voice.Language
+	}
+	translations, err := translator.Translate(ctx, texts, sourceLang, targetLang)
+	if err != nil {
+		return nil, fmt.Errorf("dubbing failed: translation failed: %w", err)
+	}
+	if len(translations) != len(texts) {
+		return nil, fmt.Errorf("dubbing failed: translator returned %d texts for %d segments", len(translations), len(texts))
+	}
+
+	// Segments that translate to nothing stay silent
+	var reqs []*audio.Text2SpeechRequest
+	var spoken []int
+	for i, translation := range translations {
+		translations[i] = strings.TrimSpace(translation)
+		if translations[i] == "" {
+			continue
+		}
+		segmentReq := *voice
+		segmentReq.Prompt = opts.Lexicon.Apply(translations[i])
+		reqs = append(reqs, &segmentReq)
+		spoken = append(spoken, i)
+	}
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("dubbing failed: translation is empty")
+	}
+
+	clips, err := a.synthesizeAll(ctx, reqs, opts.Concurrency, "segment")
+	if err != nil {
+		return nil, fmt.Errorf("dubbing failed: %w", err)
+	}
+
+	segments := make([]DubSegment, len(cues))
+	for i, cue := range cues {
+		segments[i] = DubSegment{Start: cue.Start, End: cue.End, Text: texts[i], Translation: translations[i]}
+	}
+
+	pcm, err := fitSegments(segments, spoken, clips, opts)
+	if err != nil {
+		return nil, fmt.Errorf("dubbing failed: %w", err)
+	}
+	data, err := encodeWAV(pcm)
+	if err != nil {
+		return nil, fmt.Errorf("dubbing failed: %w", err)
+	}
+
+	subtitles := &audio.TranscriptionResponse{}
+	for _, segment := range segments {
+		if segment.Translation == "" {
+			continue
+		}
+		subtitles.Timestamps = append(subtitles.Timestamps, audio.TranscriptionSegment{
+			Text:      segment.Translation,
+			StartTime: seconds(segment.DubStart),
+			EndTime:   seconds(segment.DubEnd),
+		})
+	}
+	subtitles.Transcription = subtitles.Text()
+
+	return &DubResult{
+		Audio:      data,
+		PCM:        pcm,
+		Duration:   pcm.Duration(),
+		Transcript: transcript,
+		Segments:   segments,
+		Subtitles:  subtitles,
+	}, nil
+}
+
+// dubDefaults returns a copy of opts with zero fields set to their defaults
+func dubDefaults(opts *DubOptions) *DubOptions {
+	defaults := DefaultDubOptions()
+	if opts == nil {
+		return defaults
+	}
+	o := *opts
+	if o.Concurrency <= 0 {
+		o.Concurrency = defaults.Concurrency
+	}
+	if o.MaxSegmentDuration <= 0 {
+		o.MaxSegmentDuration = defaults.MaxSegmentDuration
+	}
+	if o.MaxGap <= 0 {
+		o.MaxGap = defaults.MaxGap
+	}
+	if o.MaxSpeedup <= 0 {
+		o.MaxSpeedup = defaults.MaxSpeedup
+	}
+	return &o
+}
+
+// fitSegments places each synthesized clip at the start of its original
+// segment, speeding it up to end before the next segment starts, and mixes
+// them into one track. It fills in the dubbed times of segments.
+func fitSegments(segments []DubSegment, spoken []int, clips []*utils.PCM, opts *DubOptions) (*utils.PCM, error) {
+	format := clips[0]
+	var placed []*utils.PCM
+	var cursor time.Duration
+	for n, i := range spoken {
+		clip := clips[n]
+		if opts.Processing != nil && opts.Processing.TrimSilence {
+			clip = clip.TrimSilence(opts.Processing.SilenceThreshold, opts.Processing.SilencePadding)
+		}
+		clip, err := clip.Convert(format.SampleRate, format.Channels)
+		if err != nil {
+			return nil, err
+		}
+
+		start := segments[i].Start
+		if start < cursor {
+			start = cursor
+		}
+		// The segment may run on into the pause before the next one
+		slot := segments[i].End - start
+		if i+1 < len(segments) {
+			slot = segments[i+1].Start - start
+		}
+
+		speedup := 1.0
+		if d := clip.Duration(); slot > 0 && d > slot {
+			speedup = float64(d) / float64(slot)
+			speedup = math.Min(speedup, opts.MaxSpeedup)
+			if speedup > 1 {
+				if clip, err = clip.TimeStretch(speedup); err != nil {
+					return nil, err
+				}
+			} else {
+				speedup = 1
+			}
+		}
+
+		segments[i].DubStart = start
+		segments[i].DubEnd = start + clip.Duration()
+		segments[i].Speedup = speedup
+		cursor = segments[i].DubEnd
+
+		gap := start
+		if len(placed) > 0 {
+			gap = start - segments[spoken[n-1]].DubEnd
+		}
+		placed = append(placed, utils.Silence(format.SampleRate, format.Channels, gap), clip)
+	}
+
+	// The track lasts at least as long as the original speech
+	if end := segments[len(segments)-1].End; end > cursor {
+		placed = append(placed, utils.Silence(format.SampleRate, format.Channels, end-cursor))
+	}
+
+	pcm, err := utils.Concat(placed...)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Processing != nil {
+		whole := *opts.Processing
+		whole.TrimSilence = false
+		if pcm, err = pcm.Process(&whole); err != nil {
+			return nil, err
+		}
+	}
+	return pcm, nil
+}
@@ -0,0 +1,181 @@
+package audio
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/schemas/audio"
+	"github.com/modelslab/modelslab-go/pkg/schemas/base"
+)
+
+// dubTranscript has four sentences: the first runs a little long, the second
+// far too long and the last follows a pause without punctuation
+func dubTranscript() *audio.TranscriptionResponse {
+	return &audio.TranscriptionResponse{
+		Response: base.Response{Status: "success"},
+		Timestamps: []audio.TranscriptionSegment{
+			{Text: "Hello", StartTime: 0, EndTime: 0.04},
+			{Text: "there.", StartTime: 0.04, EndTime: 0.08},
+			{Text: "This", StartTime: 0.1, EndTime: 0.12},
+			{Text: "is", StartTime: 0.12, EndTime: 0.13},
+			{Text: "fine.", StartTime: 0.13, EndTime: 0.14},
+			{Text: "Ok", StartTime: 0.15, EndTime: 0.2},
+			{Text: "bye", StartTime: 1.5, EndTime: 1.6},
+		},
+	}
+}
+
+// durationNear reports whether got is within a millisecond of want
+func durationNear(got, want time.Duration) bool {
+	return got-want < time.Millisecond && want-got < time.Millisecond
+}
+
+// stretched returns how long a clip of d lasts played speedup times as fast
+// at 16 kHz
+func stretched(d time.Duration, speedup float64) time.Duration {
+	frames := math.Round(d.Seconds() * 16000 / speedup)
+	return time.Duration(frames / 16000 * float64(time.Second))
+}
+
+func TestDub(t *testing.T) {
+	fake := speechServer(t)
+	fake.transcript = dubTranscript()
+
+	// The zero options take their defaults, so the second sentence is sped
+	// up by at most 1.5 and the pause before "bye" splits a segment
+	opts := &DubOptions{
+		Translator: IdentityTranslator{},
+		Lexicon:    NewLexicon(map[string]string{"bye": "goodbye"}),
+	}
+	voice := &audio.Text2SpeechRequest{VoiceID: base.StringPtr("madison"), Prompt: "ignored"}
+	result, err := fake.api.Dub(context.Background(), &audio.Speech2TextRequest{AudioURL: "https://example.com/talk.wav"}, voice, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secondEnd := 100*time.Millisecond + stretched(130*time.Millisecond, 1.5)
+	want := []DubSegment{
+		{Start: 0, End: 80 * time.Millisecond, Text: "Hello there.", DubStart: 0, DubEnd: 100 * time.Millisecond, Speedup: 1.2},
+		{Start: 100 * time.Millisecond, End: 140 * time.Millisecond, Text: "This is fine.", DubStart: 100 * time.Millisecond, DubEnd: secondEnd, Speedup: 1.5},
+		// Pushed back by the sentence before
+		{Start: 150 * time.Millisecond, End: 200 * time.Millisecond, Text: "Ok", DubStart: secondEnd, DubEnd: secondEnd + 20*time.Millisecond, Speedup: 1},
+		{Start: 1500 * time.Millisecond, End: 1600 * time.Millisecond, Text: "bye", DubStart: 1500 * time.Millisecond, DubEnd: 1570 * time.Millisecond, Speedup: 1},
+	}
+	if len(result.Segments) != len(want) {
+		t.Fatalf("got %d segments %+v, want %d", len(result.Segments), result.Segments, len(want))
+	}
+	for i, got := range result.Segments {
+		w := want[i]
+		if got.Text != w.Text || got.Translation != w.Text {
+			t.Errorf("segment %d text = %q translated to %q, want %q", i, got.Text, got.Translation, w.Text)
+		}
+		if !durationNear(got.Start, w.Start) || !durationNear(got.End, w.End) {
+			t.Errorf("segment %d spans %v-%v, want %v-%v", i, got.Start, got.End, w.Start, w.End)
+		}
+		if !durationNear(got.DubStart, w.DubStart) || !durationNear(got.DubEnd, w.DubEnd) {
+			t.Errorf("segment %d dubbed at %v-%v, want %v-%v", i, got.DubStart, got.DubEnd, w.DubStart, w.DubEnd)
+		}
+		if math.Abs(got.Speedup-w.Speedup) > 1e-6 {
+			t.Errorf("segment %d sped up by %v, want %v", i, got.Speedup, w.Speedup)
+		}
+	}
+
+	// The lexicon changes what is spoken but not the subtitles
+	reqs := fake.requests()
+	var prompts []string
+	for _, req := range reqs {
+		if req.VoiceID != "madison" {
+			t.Errorf("segment %q voiced by %q, want madison", req.Prompt, req.VoiceID)
+		}
+		prompts = append(prompts, req.Prompt)
+	}
+	if got := strings.Join(prompts, "|"); len(reqs) != 4 || !strings.Contains(got, "goodbye") || strings.Contains(got, "ignored") {
+		t.Errorf("synthesized %q, want the four translations with the lexicon applied", prompts)
+	}
+	if got, want := result.Subtitles.Transcription, "Hello there. This is fine. Ok bye"; got != want {
+		t.Errorf("subtitles = %q, want %q", got, want)
+	}
+	if sub := result.Subtitles.Timestamps[2]; math.Abs(sub.StartTime-secondEnd.Seconds()) > 0.001 {
+		t.Errorf("third subtitle starts at %v, want %v", sub.StartTime, secondEnd)
+	}
+
+	// The track lasts as long as the original speech
+	if !durationNear(result.Duration, 1600*time.Millisecond) || result.PCM.SampleRate != 16000 {
+		t.Errorf("track = %v at %d Hz, want 1.6s at 16000 Hz", result.Duration, result.PCM.SampleRate)
+	}
+	if result.Transcript.Transcription != "" || len(result.Transcript.Timestamps) != 7 {
+		t.Errorf("Transcript = %+v, want the speech-to-text result", result.Transcript)
+	}
+}
+
+func TestDubMaxSpeedup(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxSpeedup float64
+		want       float64
+	}{
+		{name: "default", want: 1.2},
+		{name: "capped", maxSpeedup: 1.1, want: 1.1},
+		{name: "never", maxSpeedup: 1, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := speechServer(t)
+			fake.transcript = dubTranscript()
+			opts := &DubOptions{MaxSpeedup: tt.maxSpeedup}
+			result, err := fake.api.Dub(context.Background(), &audio.Speech2TextRequest{AudioURL: "https://example.com/talk.wav"}, &audio.Text2SpeechRequest{}, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			first := result.Segments[0]
+			if math.Abs(first.Speedup-tt.want) > 1e-6 || !durationNear(first.DubEnd, stretched(120*time.Millisecond, tt.want)) {
+				t.Errorf("first segment sped up by %v to end at %v, want %v", first.Speedup, first.DubEnd, tt.want)
+			}
+		})
+	}
+}
+
+// failingTranslator fails every translation
+type failingTranslator struct{}
+
+func (failingTranslator) Translate(ctx context.Context, texts []string, source, target string) ([]string, error) {
+	return nil, errors.New("quota exceeded")
+}
+
+func TestDubErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		transcript *audio.TranscriptionResponse
+		translator Translator
+		wantErr    string
+	}{
+		{
+			name:       "no speech",
+			transcript: &audio.TranscriptionResponse{Response: base.Response{Status: "success"}},
+			wantErr:    "recording has no speech",
+		},
+		{
+			name:       "translation fails",
+			transcript: dubTranscript(),
+			translator: failingTranslator{},
+			wantErr:    "translation failed: quota exceeded",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := speechServer(t)
+			fake.transcript = tt.transcript
+			_, err := fake.api.Dub(context.Background(), &audio.Speech2TextRequest{AudioURL: "https://example.com/talk.wav"}, &audio.Text2SpeechRequest{}, &DubOptions{Translator: tt.translator})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Dub() error = %v, want %q", err, tt.wantErr)
+			}
+			if n := len(fake.requests()); n != 0 {
+				t.Errorf("synthesized %d segments after a failure", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,223 @@
+package audio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Lexicon replaces words and phrases with the spelling a voice should read,
+// such as "SQL" with "sequel". Matching ignores case, using Unicode case
+// folding, and only applies to whole words.
+type Lexicon struct {
+	// entries holds the entries by the folded first rune of their word,
+	// longest first so phrases win over the words they contain
+	entries map[rune][]lexiconEntry
+}
+
+// lexiconEntry is a word or phrase and its replacement
+type lexiconEntry struct {
+	word  string
+	alias string
+}
+
+// NewLexicon creates a lexicon from a map of words or phrases to their
+// replacements. Words that differ only in case are the same entry; the
+// replacement of the first in sort order is kept.
+func NewLexicon(entries map[string]string) *Lexicon {
+	words := make([]string, 0, len(entries))
+	for word := range entries {
+		words = append(words, word)
+	}
+	sort.Strings(words)
+
+	l := &Lexicon{entries: make(map[rune][]lexiconEntry)}
+	seen := make(map[string]bool, len(words))
+	for _, word := range words {
+		alias := entries[word]
+		word = strings.TrimSpace(word)
+		if word == "" || seen[foldString(word)] {
+			continue
+		}
+		seen[foldString(word)] = true
+
+		first, _ := utf8.DecodeRuneInString(word)
+		l.entries[foldRune(first)] = append(l.entries[foldRune(first)], lexiconEntry{word: word, alias: alias})
+	}
+	for _, list := range l.entries {
+		sort.SliceStable(list, func(i, j int) bool {
+			return utf8.RuneCountInString(list[i].word) > utf8.RuneCountInString(list[j].word)
+		})
+	}
+	return l
+}
+
+// LoadLexicon reads a lexicon file. Three formats are accepted: a JSON
+// object of words to replacements, a W3C Pronunciation Lexicon (PLS) using
+// <alias> entries, or lines of "word = replacement" with # comments.
+func LoadLexicon(path string) (*Lexicon, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lexicon: %w", err)
+	}
+
+	var entries map[string]string
+	switch trimmed := bytes.TrimSpace(data); {
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		err = json.Unmarshal(trimmed, &entries)
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		entries, err = parsePLS(trimmed)
+	default:
+		entries, err = parseLexiconLines(trimmed)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse lexicon %s: %w", path, err)
+	}
+
+	return NewLexicon(entries), nil
+}
+
+// parsePLS reads the graphemes and aliases of a Pronunciation Lexicon
+// document. Lexemes with phonemes only are skipped, since voices take text.
+func parsePLS(data []byte) (map[string]string, error) {
+	var doc struct {
+		Lexemes []struct {
+			Graphemes []string `xml:"grapheme"`
+			Alias     string   `xml:"alias"`
+		} `xml:"lexeme"`
+	}
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]string)
+	for _, lexeme := range doc.Lexemes {
+		if lexeme.Alias == "" {
+			continue
+		}
+		for _, grapheme := range lexeme.Graphemes {
+			entries[grapheme] = strings.TrimSpace(lexeme.Alias)
+		}
+	}
+	return entries, nil
+}
+
+// parseLexiconLines reads "word = replacement" lines
+func parseLexiconLines(data []byte) (map[string]string, error) {
+	entries := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		word, alias, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected \"word = replacement\"", n)
+		}
+		entries[strings.TrimSpace(word)] = strings.TrimSpace(alias)
+	}
+	return entries, scanner.Err()
+}
+
+// Apply returns text with the lexicon entries replaced. A nil lexicon
+// returns text unchanged.
+func (l *Lexicon) Apply(text string) string {
+	if l == nil || len(l.entries) == 0 {
+		return text
+	}
+
+	var b strings.Builder
+	last := 0
+	for i := 0; i < len(text); {
+		alias, n, ok := l.match(text, i)
+		if !ok {
+			_, size := utf8.DecodeRuneInString(text[i:])
+			i += size
+			continue
+		}
+		b.WriteString(text[last:i])
+		b.WriteString(alias)
+		i += n
+		last = i
+	}
+	if last == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// match returns the replacement of the longest entry that matches text at
+// start as a whole word, and the length of the match in bytes
+func (l *Lexicon) match(text string, start int) (string, int, bool) {
+	r, _ := utf8.DecodeRuneInString(text[start:])
+	for _, entry := range l.entries[foldRune(r)] {
+		n, ok := hasPrefixFold(text[start:], entry.word)
+		if ok && wordBoundary(text, start, start+n) {
+			return entry.alias, n, true
+		}
+	}
+	return "", 0, false
+}
+
+// hasPrefixFold reports whether s starts with prefix under Unicode case
+// folding, and returns the length in bytes of the matching start of s
+func hasPrefixFold(s, prefix string) (int, bool) {
+	n := 0
+	for _, want := range prefix {
+		if n >= len(s) {
+			return 0, false
+		}
+		got, size := utf8.DecodeRuneInString(s[n:])
+		if foldRune(got) != foldRune(want) {
+			return 0, false
+		}
+		n += size
+	}
+	return n, true
+}
+
+// foldRune returns the smallest rune that r is equivalent to under simple
+// case folding, so that "K", "k" and the Kelvin sign fold alike
+func foldRune(r rune) rune {
+	folded := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < folded {
+			folded = f
+		}
+	}
+	return folded
+}
+
+// foldString folds every rune of s
+func foldString(s string) string {
+	return strings.Map(foldRune, s)
+}
+
+// wordBoundary reports whether text[start:end] is not part of a longer word
+func wordBoundary(text string, start, end int) bool {
+	if r, _ := utf8.DecodeLastRuneInString(text[:start]); start > 0 && isWordRune(r) {
+		if first, _ := utf8.DecodeRuneInString(text[start:end]); isWordRune(first) {
+			return false
+		}
+	}
+	if r, _ := utf8.DecodeRuneInString(text[end:]); end < len(text) && isWordRune(r) {
+		if final, _ := utf8.DecodeLastRuneInString(text[start:end]); isWordRune(final) {
+			return false
+		}
+	}
+	return true
+}
+
+// isWordRune reports whether r can be part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
@@ -0,0 +1,107 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLexiconApply(t *testing.T) {
+	lexicon := NewLexicon(map[string]string{
+		"SQL":          "sequel",
+		"SQLite":       "sequel light",
+		"New York":     "the big apple",
+		"York":         "yorrk",
+		"kg":           "kilograms",
+		"mass":         "weight",
+		"C++":          "C plus plus",
+		"straße":       "street",
+		"  ":           "ignored",
+		"HTTP/2":       "H T T P two",
+		"naïve":        "nigh eve",
+		"Ωmega":        "omega",
+		"unused entry": "never",
+	})
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "no match", text: "Nothing to replace here.", want: "Nothing to replace here."},
+		{name: "empty", text: "", want: ""},
+		{name: "word", text: "I like SQL.", want: "I like sequel."},
+		{name: "any case", text: "sql and Sqlite", want: "sequel and sequel light"},
+		{name: "phrase beats word", text: "New York and York", want: "the big apple and yorrk"},
+		{name: "inside a word", text: "MySQL and SQLs", want: "MySQL and SQLs"},
+		// The longer entry would end mid-word, so the shorter one is used
+		{name: "shorter entry", text: "SQL is not SQLit", want: "sequel is not SQLit"},
+		{name: "punctuation", text: "(C++), HTTP/2!", want: "(C plus plus), H T T P two!"},
+		// The Kelvin sign and the long s fold to k and s
+		{name: "kelvin sign", text: "5 Kg", want: "5 kilograms"},
+		{name: "long s", text: "maſſ", want: "weight"},
+		{name: "non-ascii", text: "NAÏVE STRASSE Straße ωMEGA", want: "nigh eve STRASSE street omega"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lexicon.Apply(tt.text); got != tt.want {
+				t.Errorf("Apply(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+
+	var none *Lexicon
+	if got := none.Apply("SQL"); got != "SQL" {
+		t.Errorf("nil Apply() = %q, want the text unchanged", got)
+	}
+}
+
+func TestLexiconDuplicateCase(t *testing.T) {
+	entries := map[string]string{"sql": "lower", "SQL": "upper", "Sql": "title"}
+	// Map order varies, so the kept entry must not
+	for i := 0; i < 20; i++ {
+		if got := NewLexicon(entries).Apply("sql"); got != "upper" {
+			t.Fatalf("Apply() = %q, want the first entry in sort order", got)
+		}
+	}
+}
+
+func TestLoadLexicon(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+	}{
+		{name: "json", contents: `{"SQL": "sequel", "k8s": "kubernetes"}`},
+		{name: "lines", contents: "# pronunciations\nSQL = sequel\n\nk8s = kubernetes\n"},
+		{
+			name: "pls",
+			contents: `<?xml version="1.0" encoding="UTF-8"?>
+<lexicon version="1.0" xmlns="http://www.w3.org/2005/01/pronunciation-lexicon" alphabet="ipa" xml:lang="en-US">
+  <lexeme><grapheme>SQL</grapheme><alias>sequel</alias></lexeme>
+  <lexeme><grapheme>k8s</grapheme><grapheme>K8S</grapheme><alias> kubernetes </alias></lexeme>
+  <lexeme><grapheme>tomato</grapheme><phoneme>təˈmeɪtoʊ</phoneme></lexeme>
+</lexicon>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "lexicon")
+			if err := os.WriteFile(path, []byte(tt.contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			lexicon, err := LoadLexicon(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := lexicon.Apply("SQL on k8s, tomato"), "sequel on kubernetes, tomato"; got != want {
+				t.Errorf("Apply() = %q, want %q", got, want)
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "lexicon")
+	os.WriteFile(path, []byte("SQL sequel\n"), 0o600)
+	if _, err := LoadLexicon(path); err == nil {
+		t.Error("LoadLexicon() of a line without = succeeded, want an error")
+	}
+}
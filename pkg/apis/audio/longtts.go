@@ -0,0 +1,290 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/modelslab/modelslab-go/pkg/schemas/audio"
+	"github.com/modelslab/modelslab-go/pkg/utils"
+)
+
+// LongSpeechOptions configures LongTextToSpeech
+type LongSpeechOptions struct {
+	// MaxChunkLength is the most characters sent in one text-to-speech
+	// request
+	MaxChunkLength int
+	// Concurrency is how many chunks are synthesized at once
+	Concurrency int
+	// SentencePause is the silence inserted between chunks
+	SentencePause time.Duration
+	// ParagraphPause is the silence inserted between paragraphs
+	ParagraphPause time.Duration
+	// Processing, when set, is applied to the stitched audio. Silence is
+	// trimmed from each chunk before stitching, so the pauses between chunks
+	// are exactly as configured.
+	Processing *utils.AudioProcessing
+	// Lexicon, when set, replaces words with the spelling the voice should
+	// read before the text is synthesized
+	Lexicon *Lexicon
+}
+
+// DefaultLongSpeechOptions returns the options used when none are given
+func DefaultLongSpeechOptions() *LongSpeechOptions {
+	return &LongSpeechOptions{
+		MaxChunkLength: 500,
+		Concurrency:    4,
+		SentencePause:  300 * time.Millisecond,
+		ParagraphPause: 800 * time.Millisecond,
+	}
+}
+
+// SpeechChunk is a piece of text and its position in the stitched audio
+type SpeechChunk struct {
+	Text  string
+	Start time.Duration
+	End   time.Duration
+}
+
+// LongSpeechResult is the stitched output of LongTextToSpeech
+type LongSpeechResult struct {
+	// Audio is the stitched audio as a WAV file
+	Audio []byte
+	// PCM is the decoded stitched audio
+	PCM      *utils.PCM
+	Duration time.Duration
+	Chunks   []SpeechChunk
+}
+
+// textChunk is a chunk of text to synthesize
+type textChunk struct {
+	text string
+	// paragraphEnd is set on the last chunk of a paragraph
+	paragraphEnd bool
+}
+
+// LongTextToSpeech synthesizes text of any length. The prompt is split at
+// paragraph and sentence boundaries into chunks of at most MaxChunkLength
+// characters, which are synthesized concurrently with the settings of req
+// and stitched into one WAV file. When opts is nil, DefaultLongSpeechOptions
+// is used.
+func (a *API) LongTextToSpeech(ctx context.Context, req *audio.Text2SpeechRequest, opts *LongSpeechOptions) (*LongSpeechResult, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if opts == nil {
+		opts = DefaultLongSpeechOptions()
+	}
+	if opts.MaxChunkLength <= 0 {
+		return nil, fmt.Errorf("max chunk length must be positive")
+	}
+
+	chunks := splitText(opts.Lexicon.Apply(req.Prompt), opts.MaxChunkLength)
+	if len(chunks) == 0 {
+		return nil, fmt.Errorf("prompt cannot be empty")
+	}
+
+	reqs := make([]*audio.Text2SpeechRequest, len(chunks))
+	pauses := make([]time.Duration, len(chunks))
+	for i, chunk := range chunks {
+		chunkReq := *req
+		chunkReq.Prompt = chunk.text
+		reqs[i] = &chunkReq
+		if i > 0 {
+			pauses[i] = opts.SentencePause
+			if chunks[i-1].paragraphEnd {
+				pauses[i] = opts.ParagraphPause
+			}
+		}
+	}
+
+	clips, err := a.synthesizeAll(ctx, reqs, opts.Concurrency, "chunk")
+	if err != nil {
+		return nil, fmt.Errorf("long text-to-speech failed: %w", err)
+	}
+
+	pcm, spans, err := stitch(clips, pauses, opts.Processing)
+	if err != nil {
+		return nil, fmt.Errorf("long text-to-speech failed: %w", err)
+	}
+	data, err := encodeWAV(pcm)
+	if err != nil {
+		return nil, fmt.Errorf("long text-to-speech failed: %w", err)
+	}
+
+	result := &LongSpeechResult{
+		Audio:    data,
+		PCM:      pcm,
+		Duration: pcm.Duration(),
+		Chunks:   make([]SpeechChunk, len(chunks)),
+	}
+	for i, chunk := range chunks {
+		result.Chunks[i] = SpeechChunk{Text: chunk.text, Start: spans[i].Start, End: spans[i].End}
+	}
+
+	return result, nil
+}
+
+// splitText splits text into chunks of at most limit characters. Paragraphs,
+// separated by blank lines, always start a new chunk; sentences are packed
+// into chunks whole unless a single sentence exceeds the limit.
+func splitText(text string, limit int) []textChunk {
+	var chunks []textChunk
+	for _, paragraph := range paragraphs(text) {
+		var current strings.Builder
+		currentLen := 0
+		flush := func() {
+			if currentLen > 0 {
+				chunks = append(chunks, textChunk{text: current.String()})
+				current.Reset()
+				currentLen = 0
+			}
+		}
+
+		for _, sentence := range sentences(paragraph) {
+			for _, piece := range splitLong(sentence, limit) {
+				n := utf8.RuneCountInString(piece)
+				if currentLen > 0 && currentLen+1+n > limit {
+					flush()
+				}
+				if currentLen > 0 {
+					current.WriteByte(' ')
+					currentLen++
+				}
+				current.WriteString(piece)
+				currentLen += n
+			}
+		}
+		flush()
+
+		if len(chunks) > 0 {
+			chunks[len(chunks)-1].paragraphEnd = true
+		}
+	}
+	return chunks
+}
+
+// paragraphs splits text at blank lines and collapses the whitespace inside
+// each paragraph
+func paragraphs(text string) []string {
+	var out []string
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(lines) > 0 {
+				out = append(out, strings.Join(strings.Fields(strings.Join(lines, " ")), " "))
+				lines = nil
+			}
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) > 0 {
+		out = append(out, strings.Join(strings.Fields(strings.Join(lines, " ")), " "))
+	}
+	return out
+}
+
+// abbreviations are words ending in a period that do not end a sentence,
+// compared in lower case
+var abbreviations = map[string]bool{
+	"e.g.": true, "i.e.": true, "cf.": true, "vs.": true, "approx.": true,
+	"mr.": true, "mrs.": true, "ms.": true, "dr.": true, "prof.": true, "st.": true,
+}
+
+// sentences splits a paragraph after sentence punctuation, keeping closing
+// quotes and brackets with their sentence
+func sentences(paragraph string) []string {
+	var out []string
+	runes := []rune(paragraph)
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		if !strings.ContainsRune(".!?…。！？", runes[i]) {
+			continue
+		}
+		end := i + 1
+		for end < len(runes) && strings.ContainsRune(".!?…\"')]}»”’", runes[end]) {
+			end++
+		}
+		// Western punctuation only ends a sentence before a space, so
+		// decimals stay intact, and not after abbreviations such as "e.g."
+		if end < len(runes) && !unicode.IsSpace(runes[end]) && !strings.ContainsRune("。！？", runes[i]) {
+			i = end - 1
+			continue
+		}
+		if runes[i] == '.' && end == i+1 && abbreviations[strings.ToLower(lastWord(runes[start:end]))] {
+			continue
+		}
+		if s := strings.TrimSpace(string(runes[start:end])); s != "" {
+			out = append(out, s)
+		}
+		start = end
+		i = end - 1
+	}
+	if s := strings.TrimSpace(string(runes[start:])); s != "" {
+		out = append(out, s)
+	}
+	return out
+}
+
+// lastWord returns the word at the end of text without leading quotes and
+// brackets
+func lastWord(text []rune) string {
+	begin := len(text)
+	for begin > 0 && !unicode.IsSpace(text[begin-1]) {
+		begin--
+	}
+	return strings.TrimLeft(string(text[begin:]), "\"'([{«“‘")
+}
+
+// splitLong splits a sentence longer than limit at clause punctuation, then
+// between words, and as a last resort inside words
+func splitLong(sentence string, limit int) []string {
+	if utf8.RuneCountInString(sentence) <= limit {
+		return []string{sentence}
+	}
+
+	var out []string
+	var current []rune
+	lastBreak, lastSpace := -1, -1
+	for _, r := range sentence {
+		current = append(current, r)
+		switch {
+		case strings.ContainsRune(",;:—", r):
+			lastBreak = len(current)
+		case unicode.IsSpace(r):
+			lastSpace = len(current)
+		}
+		if len(current) <= limit {
+			continue
+		}
+
+		cut := lastBreak
+		if cut <= 0 {
+			cut = lastSpace
+		}
+		if cut <= 0 || cut >= len(current) {
+			cut = limit
+		}
+		if s := strings.TrimSpace(string(current[:cut])); s != "" {
+			out = append(out, s)
+		}
+		current = append([]rune(nil), current[cut:]...)
+		lastBreak, lastSpace = -1, -1
+		for i, r := range current {
+			switch {
+			case strings.ContainsRune(",;:—", r):
+				lastBreak = i + 1
+			case unicode.IsSpace(r):
+				lastSpace = i + 1
+			}
+		}
+	}
+	if s := strings.TrimSpace(string(current)); s != "" {
+		out = append(out, s)
+	}
+	return out
+}
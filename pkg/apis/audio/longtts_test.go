@@ -0,0 +1,302 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelslab/modelslab-go/pkg/client"
+	"github.com/modelslab/modelslab-go/pkg/internal/testutil"
+	"github.com/modelslab/modelslab-go/pkg/schemas/audio"
+	"github.com/modelslab/modelslab-go/pkg/schemas/base"
+	"github.com/modelslab/modelslab-go/pkg/utils"
+)
+
+func TestSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "punctuation",
+			text: "It rained. Did it? Yes!",
+			want: []string{"It rained.", "Did it?", "Yes!"},
+		},
+		{
+			name: "closing quotes",
+			text: `She said "stop." Then she left…`,
+			want: []string{`She said "stop."`, "Then she left…"},
+		},
+		{
+			name: "decimals",
+			text: "It costs 3.50 dollars. That is cheap.",
+			want: []string{"It costs 3.50 dollars.", "That is cheap."},
+		},
+		{
+			name: "abbreviations",
+			text: "Bring fruit, e.g. apples. Ask Dr. Smith (i.e. the vet) first.",
+			want: []string{"Bring fruit, e.g. apples.", "Ask Dr. Smith (i.e. the vet) first."},
+		},
+		{
+			name: "full-width punctuation",
+			text: "今日は晴れ。明日は雨！",
+			want: []string{"今日は晴れ。", "明日は雨！"},
+		},
+		{
+			name: "no punctuation",
+			text: "  just words  ",
+			want: []string{"just words"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sentences(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sentences(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitLong(t *testing.T) {
+	tests := []struct {
+		name     string
+		sentence string
+		limit    int
+		want     []string
+	}{
+		{name: "short", sentence: "one two", limit: 10, want: []string{"one two"}},
+		{name: "clauses", sentence: "first part, second part", limit: 15, want: []string{"first part,", "second part"}},
+		{name: "words", sentence: "alpha beta gamma", limit: 12, want: []string{"alpha beta", "gamma"}},
+		{name: "inside words", sentence: "abcdefghij", limit: 4, want: []string{"abcd", "efgh", "ij"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitLong(tt.sentence, tt.limit); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitLong(%q, %d) = %q, want %q", tt.sentence, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
+
+// clipPerRune is how much audio the fake speech server renders per character
+const clipPerRune = 10 * time.Millisecond
+
+// speechRequest is a text-to-speech request seen by the fake speech server
+type speechRequest struct {
+	Prompt       string  `json:"prompt"`
+	OutputFormat string  `json:"output_format"`
+	VoiceID      string  `json:"voice_id"`
+	Emotion      string  `json:"emotion"`
+	Speed        float64 `json:"speed"`
+}
+
+// speechFake is a fake text-to-speech API. Each prompt is rendered as a
+// 16 kHz mono tone lasting clipPerRune per character. Prompts starting with
+// "Queued" are answered with a job that finishes on the first poll.
+// Speech-to-text requests are answered with transcript.
+type speechFake struct {
+	api        *API
+	transcript *audio.TranscriptionResponse
+
+	mu          sync.Mutex
+	reqs        []speechRequest
+	clips       map[string][]byte
+	inFlight    int
+	maxInFlight int
+}
+
+// speechServer starts a speechFake for the test
+func speechServer(t *testing.T) *speechFake {
+	t.Helper()
+
+	f := &speechFake{clips: make(map[string][]byte)}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/clip/"):
+			f.mu.Lock()
+			clip := f.clips[r.URL.Path]
+			f.mu.Unlock()
+			w.Write(clip)
+		case strings.HasPrefix(r.URL.Path, "/fetch/"):
+			fmt.Fprintf(w, `{"status": "success", "output": [%q]}`, srv.URL+"/clip/"+strings.TrimPrefix(r.URL.Path, "/fetch/"))
+		case strings.HasSuffix(r.URL.Path, "text_to_speech"):
+			var req speechRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("decoding body: %v", err)
+			}
+			id := f.start(req)
+			// Hold the request briefly so concurrent ones overlap
+			time.Sleep(5 * time.Millisecond)
+			f.finish()
+
+			if strings.HasPrefix(req.Prompt, "Queued") {
+				fmt.Fprintf(w, `{"status": "processing", "id": %d, "fetch_result": "%s/fetch/%d"}`, id, srv.URL, id)
+				return
+			}
+			fmt.Fprintf(w, `{"status": "success", "output": ["%s/clip/%d"]}`, srv.URL, id)
+		case strings.HasSuffix(r.URL.Path, "speech_to_text"):
+			var req map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("decoding body: %v", err)
+			}
+			if req["timestamp_level"] != "word" {
+				t.Errorf("timestamp_level = %v, want word", req["timestamp_level"])
+			}
+			json.NewEncoder(w).Encode(f.transcript)
+		default:
+			t.Errorf("unexpected request for %s", r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := client.NewClient(client.WithAPIKey("test-key"), client.WithBaseURL(srv.URL+"/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.api = New(c, false)
+	return f
+}
+
+// start records a request and renders its clip, returning the clip's id
+func (f *speechFake) start(req speechRequest) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.reqs = append(f.reqs, req)
+	id := len(f.reqs)
+	d := time.Duration(utf8.RuneCountInString(req.Prompt)) * clipPerRune
+	f.clips[fmt.Sprintf("/clip/%d", id)] = testutil.WAV(16000, 1, testutil.Sine(16000, 1, 440, -12, d))
+
+	f.inFlight++
+	f.maxInFlight = max(f.maxInFlight, f.inFlight)
+	return id
+}
+
+// finish marks a request as answered
+func (f *speechFake) finish() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.inFlight--
+}
+
+// concurrency returns the most requests that were in flight at once
+func (f *speechFake) concurrency() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.maxInFlight
+}
+
+// requests returns the requests received so far
+func (f *speechFake) requests() []speechRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]speechRequest(nil), f.reqs...)
+}
+
+func TestLongTextToSpeech(t *testing.T) {
+	text := "Queued first sentence. A second one follows here.\n\nA new paragraph starts. It ends now."
+	wantChunks := []string{
+		"Queued first sentence.",
+		"A second one follows here.",
+		"A new paragraph starts.",
+		"It ends now.",
+	}
+	// The second paragraph gets the longer pause
+	wantPauses := []time.Duration{0, 50 * time.Millisecond, 200 * time.Millisecond, 50 * time.Millisecond}
+
+	tests := []struct {
+		name       string
+		format     *string
+		wantFormat string
+	}{
+		{name: "default format", wantFormat: "wav"},
+		{name: "mp3 requested", format: base.StringPtr("mp3"), wantFormat: "mp3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := speechServer(t)
+			opts := &LongSpeechOptions{
+				MaxChunkLength: 30,
+				Concurrency:    2,
+				SentencePause:  50 * time.Millisecond,
+				ParagraphPause: 200 * time.Millisecond,
+			}
+			req := &audio.Text2SpeechRequest{Prompt: text, OutputFormat: tt.format}
+
+			result, err := fake.api.LongTextToSpeech(context.Background(), req, opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if req.Prompt != text || req.OutputFormat != tt.format {
+				t.Errorf("LongTextToSpeech() changed the request: %+v", req)
+			}
+
+			reqs := fake.requests()
+			if len(reqs) != len(wantChunks) {
+				t.Fatalf("sent %d requests, want %d", len(reqs), len(wantChunks))
+			}
+			for _, r := range reqs {
+				if r.OutputFormat != tt.wantFormat {
+					t.Errorf("chunk %q requested %q, want %q", r.Prompt, r.OutputFormat, tt.wantFormat)
+				}
+			}
+
+			if len(result.Chunks) != len(wantChunks) {
+				t.Fatalf("got %d chunks, want %d", len(result.Chunks), len(wantChunks))
+			}
+			var end time.Duration
+			for i, chunk := range result.Chunks {
+				if chunk.Text != wantChunks[i] {
+					t.Errorf("chunk %d = %q, want %q", i, chunk.Text, wantChunks[i])
+				}
+				wantStart := end + wantPauses[i]
+				wantEnd := wantStart + time.Duration(len(wantChunks[i]))*clipPerRune
+				if chunk.Start != wantStart || chunk.End != wantEnd {
+					t.Errorf("chunk %d spans %v-%v, want %v-%v", i, chunk.Start, chunk.End, wantStart, wantEnd)
+				}
+				end = chunk.End
+			}
+			if result.Duration != end || result.PCM.Duration() != end {
+				t.Errorf("duration = %v, want %v", result.Duration, end)
+			}
+
+			decoded, err := utils.DecodeWAV(bytes.NewReader(result.Audio))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded.Duration() != end || decoded.SampleRate != 16000 {
+				t.Errorf("Audio holds %v at %d Hz, want %v at 16000 Hz", decoded.Duration(), decoded.SampleRate, end)
+			}
+			// The pause before the second paragraph is silent
+			pause := decoded.Slice(result.Chunks[1].End, result.Chunks[2].Start)
+			if peak := pause.Peak(); !math.IsInf(peak, -1) {
+				t.Errorf("paragraph pause peaks at %v dBFS, want silence", peak)
+			}
+			speech := decoded.Slice(result.Chunks[2].Start, result.Chunks[2].End)
+			if peak := speech.Peak(); peak < -13 {
+				t.Errorf("chunk 3 peaks at %v dBFS, want its tone", peak)
+			}
+		})
+	}
+}
+
+func TestLongTextToSpeechFailure(t *testing.T) {
+	fake := speechServer(t)
+	_, err := fake.api.LongTextToSpeech(context.Background(), &audio.Text2SpeechRequest{Prompt: "   \n\n  "}, nil)
+	if err == nil || !strings.Contains(err.Error(), "prompt cannot be empty") {
+		t.Errorf("LongTextToSpeech() of a blank prompt = %v, want an empty prompt error", err)
+	}
+}
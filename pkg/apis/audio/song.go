@@ -0,0 +1,168 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/apis/base"
+	"github.com/modelslab/modelslab-go/pkg/schemas/audio"
+	schemas "github.com/modelslab/modelslab-go/pkg/schemas/base"
+	"github.com/modelslab/modelslab-go/pkg/utils"
+)
+
+// SongOptions configures ComposeSong
+type SongOptions struct {
+	// LyricsPrompt is what lyrics are written about when the request has
+	// none; the song prompt is used when it is empty
+	LyricsPrompt string
+	// SyncLyrics transcribes the song and times the lyrics by it
+	SyncLyrics bool
+	// Language is the language the lyrics are sung in, passed to
+	// speech-to-text
+	Language *string
+}
+
+// DefaultSongOptions returns the options used when none are given
+func DefaultSongOptions() *SongOptions {
+	return &SongOptions{SyncLyrics: true}
+}
+
+// SongResult is a composed song
+type SongResult struct {
+	// Lyrics are the lyrics the song was generated from
+	Lyrics string
+	// Response is the finished song generation response
+	Response *audio.AudioResponse
+	// Audio is the song file as generated
+	Audio []byte
+	// PCM is the decoded song
+	PCM      *utils.PCM
+	Duration time.Duration
+	// Transcript and Synced are set when SyncLyrics is
+	Transcript *audio.TranscriptionResponse
+	// Synced holds the lyrics timed to the song; export them with its LRC
+	// or EnhancedLRC methods
+	Synced *audio.SyncedLyrics
+}
+
+// ComposeSong generates a song and its lyrics. When req has no lyrics, they
+// are generated with LyricsGen first. The song is generated with the lyrics,
+// waited for and downloaded. With SyncLyrics, the song is then transcribed
+// with word timestamps and the lyrics are aligned to it, ready to export as
+// an LRC file. When opts is nil, DefaultSongOptions is used.
+func (a *API) ComposeSong(ctx context.Context, req *audio.SongGeneratorRequest, opts *SongOptions) (*SongResult, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if opts == nil {
+		opts = DefaultSongOptions()
+	}
+
+	songReq := *req
+	var lyrics string
+	if req.Lyrics != nil {
+		lyrics = strings.TrimSpace(*req.Lyrics)
+	}
+	if lyrics == "" {
+		prompt := opts.LyricsPrompt
+		if prompt == "" {
+			prompt = req.Prompt
+		}
+		generated, err := a.GenerateLyrics(ctx, &audio.LyricsGeneratorRequest{Prompt: prompt})
+		if err != nil {
+			return nil, fmt.Errorf("song composition failed: %w", err)
+		}
+		lyrics = generated
+	}
+	// The song is sung from these lyrics rather than ones the API writes
+	generate := false
+	songReq.Lyrics = &lyrics
+	songReq.LyricsGeneration = &generate
+
+	job, err := a.SongGenerator(ctx, &songReq)
+	if err != nil {
+		return nil, fmt.Errorf("song composition failed: %w", err)
+	}
+	out, err := job.Wait(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("song composition failed: %w", err)
+	}
+
+	data, err := a.audioData(ctx, out)
+	if err != nil {
+		return nil, fmt.Errorf("song composition failed: %w", err)
+	}
+	pcm, err := utils.DecodeAudio(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("song composition failed: failed to decode audio: %w", err)
+	}
+
+	result := &SongResult{
+		Lyrics:   lyrics,
+		Response: out,
+		Audio:    data,
+		PCM:      pcm,
+		Duration: pcm.Duration(),
+	}
+	if !opts.SyncLyrics {
+		return result, nil
+	}
+
+	level := "word"
+	sttReq := &audio.Speech2TextRequest{InputLanguage: opts.Language, TimestampLevel: &level}
+	if url := audioURL(out); url != "" {
+		sttReq.AudioURL = url
+	} else {
+		sttReq.Audio = schemas.FileFromBytes(data)
+	}
+	transcript, err := a.SpeechToText(ctx, sttReq)
+	if err != nil {
+		return nil, fmt.Errorf("song composition failed: %w", err)
+	}
+
+	result.Transcript = transcript
+	result.Synced = audio.AlignLyrics(lyrics, transcript, pcm.Duration().Seconds())
+	return result, nil
+}
+
+// GenerateLyrics writes lyrics with LyricsGen and returns them as text,
+// waiting for them when the API queues the request
+func (a *API) GenerateLyrics(ctx context.Context, req *audio.LyricsGeneratorRequest) (string, error) {
+	resp, err := a.lyricsGen(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	out, err := base.Await[audio.LyricsResponse](ctx, a.BaseAPI, resp)
+	if err != nil {
+		return "", fmt.Errorf("lyrics generation request failed: %w", err)
+	}
+
+	return a.lyricsText(ctx, out)
+}
+
+// lyricsText returns the lyrics of a finished response, which come in the
+// lyrics field, as the data, or as the first output, inline or linked
+func (a *API) lyricsText(ctx context.Context, out *audio.LyricsResponse) (string, error) {
+	if text := strings.TrimSpace(out.Lyrics); text != "" {
+		return text, nil
+	}
+	if text, ok := out.Data.(string); ok && strings.TrimSpace(text) != "" {
+		return strings.TrimSpace(text), nil
+	}
+	if len(out.Output) == 0 || strings.TrimSpace(out.Output[0]) == "" {
+		return "", fmt.Errorf("response has no lyrics")
+	}
+
+	text := strings.TrimSpace(out.Output[0])
+	if strings.HasPrefix(text, "http://") || strings.HasPrefix(text, "https://") {
+		data, err := a.GetClient().Download(ctx, text)
+		if err != nil {
+			return "", fmt.Errorf("failed to download lyrics: %w", err)
+		}
+		text = strings.TrimSpace(string(data))
+	}
+	return text, nil
+}
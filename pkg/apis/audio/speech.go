@@ -0,0 +1,115 @@
+package audio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/schemas/audio"
+	"github.com/modelslab/modelslab-go/pkg/schemas/base"
+	"github.com/modelslab/modelslab-go/pkg/utils"
+)
+
+// MaxSpeechToTextDuration is the longest local recording SpeechToText sends
+var MaxSpeechToTextDuration = 2 * time.Hour
+
+// ErrAudioTooLong is returned when a local recording exceeds
+// MaxSpeechToTextDuration
+var ErrAudioTooLong = errors.New("audio is too long")
+
+// speechToTextRequest sends a local audio file in place of audio_url. It
+// holds the fields of audio.Speech2TextRequest other than the audio, so the
+// caller's input, which has already been read, is not uploaded again.
+type speechToTextRequest struct {
+	base.BaseRequest
+	Audio          *base.FileInput `json:"audio_url" validate:"required"`
+	InputLanguage  *string         `json:"input_language,omitempty"`
+	TimestampLevel *string         `json:"timestamp_level,omitempty" validate:"omitempty,oneof=word sentence"`
+}
+
+// speechToTextBody returns the request to post for req. Local audio is
+// probed and wrapped so that it is uploaded or streamed as audio_url. The
+// returned function releases temporary files once the request is sent.
+func speechToTextBody(req *audio.Speech2TextRequest) (interface{}, func(), error) {
+	// Requests with a hosted URL, or with both sources set, which fails
+	// validation, are sent as they are
+	if req.Audio == nil || req.AudioURL != "" {
+		return req, func() {}, nil
+	}
+
+	if req.Audio.URL != nil {
+		body := *req
+		body.AudioURL = *req.Audio.URL
+		body.Audio = nil
+		return &body, func() {}, nil
+	}
+
+	input, cleanup, err := checkAudio(req.Audio)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &speechToTextRequest{
+		BaseRequest:    req.BaseRequest,
+		Audio:          input,
+		InputLanguage:  req.InputLanguage,
+		TimestampLevel: req.TimestampLevel,
+	}, cleanup, nil
+}
+
+// checkAudio validates the format and duration of a local audio input and
+// returns a copy of it to send. Readers that cannot seek are buffered in a
+// temporary file, released by the returned function.
+func checkAudio(in *base.FileInput) (*base.FileInput, func(), error) {
+	r, err := in.Open()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	src, cleanup, err := utils.Seekable(r)
+	if err != nil {
+		r.Close()
+		return nil, nil, err
+	}
+	release := func() {
+		cleanup()
+		r.Close()
+	}
+
+	start, err := src.Seek(0, io.SeekCurrent)
+	if err != nil {
+		release()
+		return nil, nil, fmt.Errorf("failed to read audio: %w", err)
+	}
+	info, err := utils.ProbeAudio(src)
+	if err != nil {
+		release()
+		return nil, nil, fmt.Errorf("invalid audio: %w", err)
+	}
+	if info.Duration <= 0 {
+		release()
+		return nil, nil, fmt.Errorf("invalid audio: %s file is empty", info.Format)
+	}
+	if info.Duration > MaxSpeechToTextDuration {
+		release()
+		return nil, nil, fmt.Errorf("%w: %s exceeds %s", ErrAudioTooLong, info.Duration.Round(time.Second), MaxSpeechToTextDuration)
+	}
+	if _, err := src.Seek(start, io.SeekStart); err != nil {
+		release()
+		return nil, nil, fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	out := *in
+	if in.Reader != nil || in.Base64 != nil {
+		// Send the rewound reader, or the buffered or decoded copy, so that
+		// an Uploader can host inline audio as well
+		out = base.FileInput{Reader: src, MIMEType: in.MIMEType}
+	} else {
+		r.Close()
+		release = cleanup
+	}
+	if out.MIMEType == "" {
+		out.MIMEType = info.MIMEType()
+	}
+	return &out, release, nil
+}
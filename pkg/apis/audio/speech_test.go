@@ -0,0 +1,162 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/client"
+	"github.com/modelslab/modelslab-go/pkg/internal/testutil"
+	"github.com/modelslab/modelslab-go/pkg/schemas/audio"
+	"github.com/modelslab/modelslab-go/pkg/schemas/base"
+)
+
+// countingUploader counts uploads and returns fake URLs for them
+type countingUploader struct {
+	mu    sync.Mutex
+	names []string
+}
+
+func (u *countingUploader) Upload(ctx context.Context, name, contentType string, size int64, r io.Reader) (string, error) {
+	if _, err := io.Copy(io.Discard, r); err != nil {
+		return "", err
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.names = append(u.names, name)
+	return "https://cdn.example.com/" + name, nil
+}
+
+// toneWAV returns a WAV file holding a second of a quiet tone
+func toneWAV() []byte {
+	return testutil.WAV(16000, 1, testutil.Sine(16000, 1, 440, -20, time.Second))
+}
+
+func TestSpeechToTextUploadsLocalAudio(t *testing.T) {
+	var sent []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+		url, _ := body["audio_url"].(string)
+		sent = append(sent, url)
+		io.WriteString(w, `{"status": "success", "transcription": "hello"}`)
+	}))
+	defer srv.Close()
+
+	wav := toneWAV()
+	tests := []struct {
+		name  string
+		input *base.FileInput
+	}{
+		// A reader that cannot seek is read once, while it is checked
+		{"reader", &base.FileInput{Reader: struct{ io.Reader }{bytes.NewReader(wav)}}},
+		{"seekable reader", &base.FileInput{Reader: bytes.NewReader(wav)}},
+		{"bytes", base.FileFromBytes(wav)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Each case gets a client of its own, as uploads of the same
+			// contents are cached
+			uploader := &countingUploader{}
+			c, err := client.NewClient(client.WithAPIKey("test-key"), client.WithBaseURL(srv.URL), client.WithUploader(uploader))
+			if err != nil {
+				t.Fatal(err)
+			}
+			api := New(c, false)
+
+			sent = nil
+			req := &audio.Speech2TextRequest{Audio: tt.input}
+			before := *tt.input
+
+			out, err := api.SpeechToText(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Transcription != "hello" {
+				t.Errorf("transcription = %q", out.Transcription)
+			}
+			if len(uploader.names) != 1 || !strings.HasSuffix(uploader.names[0], ".wav") {
+				t.Errorf("uploads = %v, want one WAV file", uploader.names)
+			}
+			if len(sent) != 1 || sent[0] != "https://cdn.example.com/"+uploader.names[0] {
+				t.Errorf("sent audio_url %v", sent)
+			}
+			if req.Audio != tt.input || tt.input.URL != before.URL || req.AudioURL != "" {
+				t.Errorf("request was changed: %+v", req)
+			}
+		})
+	}
+}
+
+func TestSpeechToTextInlineAudio(t *testing.T) {
+	var sent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+		sent, _ = body["audio_url"].(string)
+		io.WriteString(w, `{"status": "success", "transcription": "hello"}`)
+	}))
+	defer srv.Close()
+
+	c, err := client.NewClient(client.WithAPIKey("test-key"), client.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	level := "word"
+	req := &audio.Speech2TextRequest{
+		Audio:          &base.FileInput{Reader: struct{ io.Reader }{bytes.NewReader(toneWAV())}},
+		TimestampLevel: &level,
+	}
+	if _, err := New(c, false).SpeechToText(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(sent, "data:audio/wav;base64,") {
+		t.Errorf("sent audio_url = %.40q, want a WAV data URI", sent)
+	}
+}
+
+func TestSpeechToTextWaitsForQueuedTranscript(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		if strings.HasSuffix(r.URL.Path, "/fetch/7") {
+			io.WriteString(w, `{"status": "success", "transcription": "queued words"}`)
+			return
+		}
+		io.WriteString(w, `{"status": "processing", "id": 7, "eta": 1, "fetch_result": "`+srv.URL+`/fetch/7"}`)
+	}))
+	defer srv.Close()
+
+	c, err := client.NewClient(client.WithAPIKey("test-key"), client.WithBaseURL(srv.URL+"/"), client.WithFetchTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	url := "https://example.com/long.wav"
+	out, err := New(c, false).SpeechToText(context.Background(), &audio.Speech2TextRequest{AudioURL: url})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Transcription != "queued words" {
+		t.Errorf("transcription = %q, want the fetched transcript", out.Transcription)
+	}
+	if len(paths) != 2 || !strings.HasSuffix(paths[0], "speech_to_text") || paths[1] != "/fetch/7" {
+		t.Errorf("requested %v, want speech_to_text then the fetch URL", paths)
+	}
+}
@@ -0,0 +1,450 @@
+package audio
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/modelslab/modelslab-go/pkg/schemas/audio"
+)
+
+// Pauses for the SSML break strengths
+var breakStrengths = map[string]time.Duration{
+	"none":     0,
+	"x-weak":   100 * time.Millisecond,
+	"weak":     250 * time.Millisecond,
+	"medium":   400 * time.Millisecond,
+	"strong":   700 * time.Millisecond,
+	"x-strong": time.Second,
+}
+
+// Speed multipliers for the SSML prosody rate keywords
+var prosodyRates = map[string]float64{
+	"x-slow":  0.5,
+	"slow":    0.75,
+	"medium":  1,
+	"default": 1,
+	"fast":    1.25,
+	"x-fast":  1.5,
+}
+
+// SpeechSegment is a part of an SSML document: text spoken at one rate with
+// one emotion, or a pause
+type SpeechSegment struct {
+	Text string
+	// Rate multiplies the speed of the request
+	Rate float64
+	// Emotion overrides the emotion of the request when set
+	Emotion string
+	// Pause is the length of a break; it is only set on segments without
+	// text
+	Pause time.Duration
+}
+
+// ssmlState holds the prosody in effect inside an element
+type ssmlState struct {
+	rate    float64
+	emotion string
+}
+
+// ssmlParser collects the segments of an SSML document
+type ssmlParser struct {
+	decoder  *xml.Decoder
+	lexicon  *Lexicon
+	segments []SpeechSegment
+}
+
+// ParseSSML splits SSML markup into segments. The supported subset is
+// <speak>, <p>, <s>, <break time|strength>, <prosody rate>, <say-as
+// interpret-as [format]>, <sub alias> and <emotion name>, which sets the
+// Emotion field. Other prosody attributes are ignored. Markup without a
+// <speak> root, after any <?xml?> declaration, is treated as its content.
+// The lexicon, when not nil, is applied to the text but not to <sub>
+// aliases or <say-as> output.
+func ParseSSML(markup string, lexicon *Lexicon) ([]SpeechSegment, error) {
+	markup = strings.TrimSpace(strings.TrimPrefix(markup, "\uFEFF"))
+	if strings.HasPrefix(markup, "<?xml") {
+		_, rest, ok := strings.Cut(markup, "?>")
+		if !ok {
+			return nil, fmt.Errorf("invalid SSML: unterminated XML declaration")
+		}
+		markup = strings.TrimSpace(rest)
+	}
+	if !strings.HasPrefix(markup, "<speak") {
+		markup = "<speak>" + markup + "</speak>"
+	}
+
+	p := &ssmlParser{
+		decoder: xml.NewDecoder(strings.NewReader(markup)),
+		lexicon: lexicon,
+	}
+	if err := p.parse(ssmlState{rate: 1}); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid SSML: %w", err)
+	}
+	return p.segments, nil
+}
+
+// parse consumes tokens until the end of the current element
+func (p *ssmlParser) parse(state ssmlState) error {
+	for {
+		token, err := p.decoder.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := token.(type) {
+		case xml.CharData:
+			p.text(p.lexicon.Apply(string(t)), state)
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			if err := p.element(t, state); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// element handles a start element and its content
+func (p *ssmlParser) element(el xml.StartElement, state ssmlState) error {
+	switch el.Name.Local {
+	case "speak", "s":
+		return p.parse(state)
+	case "p":
+		p.boundary(breakStrengths["strong"])
+		if err := p.parse(state); err != nil {
+			return err
+		}
+		p.boundary(breakStrengths["strong"])
+		return nil
+	case "break":
+		pause, err := breakDuration(el)
+		if err != nil {
+			return err
+		}
+		p.pause(pause)
+		return p.decoder.Skip()
+	case "prosody":
+		if rate := attr(el, "rate"); rate != "" {
+			multiplier, err := prosodyRate(rate)
+			if err != nil {
+				return err
+			}
+			state.rate *= multiplier
+		}
+		return p.parse(state)
+	case "emotion":
+		name := attr(el, "name")
+		if name == "" {
+			return fmt.Errorf("<emotion> requires a name")
+		}
+		state.emotion = name
+		return p.parse(state)
+	case "sub":
+		alias := attr(el, "alias")
+		if err := p.decoder.Skip(); err != nil {
+			return err
+		}
+		p.text(alias, state)
+		return nil
+	case "say-as":
+		var content struct {
+			Text string `xml:",chardata"`
+		}
+		if err := p.decoder.DecodeElement(&content, &el); err != nil {
+			return err
+		}
+		p.text(sayAs(strings.TrimSpace(content.Text), attr(el, "interpret-as"), attr(el, "format")), state)
+		return nil
+	}
+	return fmt.Errorf("unsupported element <%s>", el.Name.Local)
+}
+
+// text appends text, joining it to the previous segment when it has the
+// same prosody
+func (p *ssmlParser) text(text string, state ssmlState) {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return
+	}
+
+	if n := len(p.segments); n > 0 {
+		last := &p.segments[n-1]
+		if last.Text != "" && last.Rate == state.rate && last.Emotion == state.emotion {
+			if r, _ := utf8.DecodeRuneInString(text); !strings.ContainsRune(",.!?;:)]}", r) {
+				last.Text += " "
+			}
+			last.Text += text
+			return
+		}
+	}
+	p.segments = append(p.segments, SpeechSegment{Text: text, Rate: state.rate, Emotion: state.emotion})
+}
+
+// pause appends a break, merging it with a preceding one
+func (p *ssmlParser) pause(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	if n := len(p.segments); n > 0 && p.segments[n-1].Text == "" {
+		p.segments[n-1].Pause += d
+		return
+	}
+	p.segments = append(p.segments, SpeechSegment{Pause: d})
+}
+
+// boundary makes sure there is a pause of at least d, so consecutive
+// paragraphs are separated by one strong break rather than two
+func (p *ssmlParser) boundary(d time.Duration) {
+	if n := len(p.segments); n > 0 && p.segments[n-1].Text == "" {
+		if p.segments[n-1].Pause < d {
+			p.segments[n-1].Pause = d
+		}
+		return
+	}
+	p.pause(d)
+}
+
+// attr returns the value of an attribute, or "" when it is missing
+func attr(el xml.StartElement, name string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// breakDuration returns the pause of a <break> element, which defaults to a
+// medium break
+func breakDuration(el xml.StartElement) (time.Duration, error) {
+	if value := attr(el, "time"); value != "" {
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("invalid break time %q", value)
+		}
+		return d, nil
+	}
+
+	strength := attr(el, "strength")
+	if strength == "" {
+		strength = "medium"
+	}
+	d, ok := breakStrengths[strength]
+	if !ok {
+		return 0, fmt.Errorf("invalid break strength %q", strength)
+	}
+	return d, nil
+}
+
+// prosodyRate parses a rate keyword, a percentage such as "120%" or a plain
+// multiplier
+func prosodyRate(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if rate, ok := prosodyRates[value]; ok {
+		return rate, nil
+	}
+
+	percent := strings.HasSuffix(value, "%")
+	rate, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+	if err != nil || rate <= 0 {
+		return 0, fmt.Errorf("invalid prosody rate %q", value)
+	}
+	if percent {
+		rate /= 100
+	}
+	return rate, nil
+}
+
+// sayAs rewrites text so it is read as interpret-as describes. Unknown
+// interpretations leave the text unchanged.
+func sayAs(text, interpretAs, format string) string {
+	switch interpretAs {
+	case "characters", "spell-out", "verbatim":
+		return spaced(text, func(r rune) bool { return !unicode.IsSpace(r) })
+	case "digits":
+		return spaced(text, unicode.IsDigit)
+	case "telephone":
+		groups := strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsDigit(r) })
+		for i, group := range groups {
+			groups[i] = spaced(group, unicode.IsDigit)
+		}
+		return strings.Join(groups, ", ")
+	case "cardinal", "number":
+		return strings.ReplaceAll(text, ",", "")
+	case "ordinal":
+		return ordinal(strings.ReplaceAll(text, ",", ""))
+	case "date":
+		return sayDate(text, format)
+	}
+	return text
+}
+
+// spaced returns the runes of text that keep reports true, separated by
+// spaces
+func spaced(text string, keep func(rune) bool) string {
+	var runes []string
+	for _, r := range text {
+		if keep(r) {
+			runes = append(runes, string(r))
+		}
+	}
+	return strings.Join(runes, " ")
+}
+
+// ordinal adds the English ordinal suffix to a number, e.g. "21" becomes
+// "21st"
+func ordinal(number string) string {
+	n, err := strconv.Atoi(number)
+	if err != nil {
+		return number
+	}
+
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return number + suffix
+}
+
+// sayDate spells out a numeric date whose field order is given by format,
+// one of "ymd" (the default), "mdy" or "dmy", e.g. "2024-03-05" becomes
+// "March 5, 2024"
+func sayDate(text, format string) string {
+	parts := strings.FieldsFunc(text, func(r rune) bool { return r == '-' || r == '/' || r == '.' })
+	if format == "" {
+		format = "ymd"
+	}
+	if len(parts) != 3 || len(format) != 3 {
+		return text
+	}
+
+	var year, month, day int
+	for i, field := range format {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return text
+		}
+		switch field {
+		case 'y':
+			year = n
+		case 'm':
+			month = n
+		case 'd':
+			day = n
+		default:
+			return text
+		}
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return text
+	}
+	return fmt.Sprintf("%s %d, %d", time.Month(month), day, year)
+}
+
+// SSMLToSpeech synthesizes a prompt written in the SSML subset accepted by
+// ParseSSML. Each segment is rendered with the settings of req, its rate
+// multiplying Speed and its emotion replacing Emotion, and the segments are
+// stitched with the requested breaks; breaks before the first or after the
+// last words are dropped. Long segments are split like LongTextToSpeech
+// splits its prompt. When opts is nil, DefaultLongSpeechOptions is used.
+func (a *API) SSMLToSpeech(ctx context.Context, req *audio.Text2SpeechRequest, opts *LongSpeechOptions) (*LongSpeechResult, error) {
+	if req == nil {
+		return nil, fmt.Errorf("request cannot be nil")
+	}
+	if opts == nil {
+		opts = DefaultLongSpeechOptions()
+	}
+	if opts.MaxChunkLength <= 0 {
+		return nil, fmt.Errorf("max chunk length must be positive")
+	}
+
+	segments, err := ParseSSML(req.Prompt, opts.Lexicon)
+	if err != nil {
+		return nil, fmt.Errorf("SSML text-to-speech failed: %w", err)
+	}
+
+	speed := 1.0
+	if req.Speed != nil {
+		speed = *req.Speed
+	}
+
+	var reqs []*audio.Text2SpeechRequest
+	var pauses []time.Duration
+	var texts []string
+	var pending time.Duration
+	for _, segment := range segments {
+		if segment.Text == "" {
+			pending += segment.Pause
+			continue
+		}
+
+		chunks := splitText(segment.Text, opts.MaxChunkLength)
+		for i, chunk := range chunks {
+			chunkReq := *req
+			chunkReq.Prompt = chunk.text
+			if segment.Rate != 1 {
+				rate := math.Min(10, math.Max(0.1, speed*segment.Rate))
+				chunkReq.Speed = &rate
+			}
+			if segment.Emotion != "" {
+				emotion := segment.Emotion
+				chunkReq.Emotion = &emotion
+			}
+
+			pause := pending
+			if i > 0 {
+				pause = opts.SentencePause
+			}
+			pending = 0
+
+			reqs = append(reqs, &chunkReq)
+			pauses = append(pauses, pause)
+			texts = append(texts, chunk.text)
+		}
+	}
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("prompt has no text to speak")
+	}
+
+	clips, err := a.synthesizeAll(ctx, reqs, opts.Concurrency, "segment")
+	if err != nil {
+		return nil, fmt.Errorf("SSML text-to-speech failed: %w", err)
+	}
+
+	pcm, spans, err := stitch(clips, pauses, opts.Processing)
+	if err != nil {
+		return nil, fmt.Errorf("SSML text-to-speech failed: %w", err)
+	}
+	data, err := encodeWAV(pcm)
+	if err != nil {
+		return nil, fmt.Errorf("SSML text-to-speech failed: %w", err)
+	}
+
+	result := &LongSpeechResult{
+		Audio:    data,
+		PCM:      pcm,
+		Duration: pcm.Duration(),
+		Chunks:   make([]SpeechChunk, len(texts)),
+	}
+	for i, text := range texts {
+		result.Chunks[i] = SpeechChunk{Text: text, Start: spans[i].Start, End: spans[i].End}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,140 @@
+package audio
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSSML(t *testing.T) {
+	tests := []struct {
+		name   string
+		markup string
+		want   []SpeechSegment
+	}{
+		{
+			name:   "plain text",
+			markup: "Hello   there, world.",
+			want:   []SpeechSegment{{Text: "Hello there, world.", Rate: 1}},
+		},
+		{
+			name:   "breaks",
+			markup: `<speak>One.<break time="500ms"/><break strength="weak"/>Two.<break/>Three.</speak>`,
+			want: []SpeechSegment{
+				{Text: "One.", Rate: 1},
+				{Pause: 750 * time.Millisecond},
+				{Text: "Two.", Rate: 1},
+				{Pause: 400 * time.Millisecond},
+				{Text: "Three.", Rate: 1},
+			},
+		},
+		{
+			name:   "paragraphs",
+			markup: `<p>First.</p><p><s>Second.</s></p>`,
+			want: []SpeechSegment{
+				{Pause: 700 * time.Millisecond},
+				{Text: "First.", Rate: 1},
+				{Pause: 700 * time.Millisecond},
+				{Text: "Second.", Rate: 1},
+				{Pause: 700 * time.Millisecond},
+			},
+		},
+		{
+			name:   "nested prosody and emotion",
+			markup: `Normal <prosody rate="slow">slow <prosody rate="200%">back</prosody></prosody> <emotion name="happy">yay</emotion>!`,
+			want: []SpeechSegment{
+				{Text: "Normal", Rate: 1},
+				{Text: "slow", Rate: 0.75},
+				{Text: "back", Rate: 1.5},
+				{Text: "yay", Rate: 1, Emotion: "happy"},
+				{Text: "!", Rate: 1},
+			},
+		},
+		{
+			name:   "xml declaration",
+			markup: "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<speak version=\"1.1\">Hello.<break time=\"1s\"/>Bye.</speak>",
+			want: []SpeechSegment{
+				{Text: "Hello.", Rate: 1},
+				{Pause: time.Second},
+				{Text: "Bye.", Rate: 1},
+			},
+		},
+		{
+			name:   "xml declaration without a speak root",
+			markup: `<?xml version="1.0"?> Just <prosody rate="fast">text</prosody>`,
+			want:   []SpeechSegment{{Text: "Just", Rate: 1}, {Text: "text", Rate: 1.25}},
+		},
+		{
+			name: "say-as and sub",
+			markup: `Call <say-as interpret-as="telephone">555-0100</say-as> by the ` +
+				`<say-as interpret-as="ordinal">22</say-as> or <say-as interpret-as="date" format="dmy">05/03/2024</say-as>, ` +
+				`code <say-as interpret-as="characters">AB1</say-as>, <sub alias="World Wide Web">WWW</sub>.`,
+			want: []SpeechSegment{{
+				Text: "Call 5 5 5, 0 1 0 0 by the 22nd or March 5, 2024, code A B 1, World Wide Web.",
+				Rate: 1,
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSSML(tt.markup, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseSSML(%q) =\n%+v\nwant\n%+v", tt.markup, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSSMLLexicon(t *testing.T) {
+	lexicon := NewLexicon(map[string]string{"SQL": "sequel"})
+	got, err := ParseSSML(`SQL <sub alias="SQL">x</sub> <say-as interpret-as="verbatim">SQL</say-as>`, lexicon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SpeechSegment{{Text: "sequel SQL S Q L", Rate: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseSSML() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseSSMLErrors(t *testing.T) {
+	tests := []struct {
+		markup  string
+		wantErr string
+	}{
+		{markup: `<audio src="x.mp3"/>`, wantErr: "unsupported element <audio>"},
+		{markup: `<break time="soon"/>`, wantErr: "invalid break time"},
+		{markup: `<break strength="huge"/>`, wantErr: "invalid break strength"},
+		{markup: `<prosody rate="-10%">x</prosody>`, wantErr: "invalid prosody rate"},
+		{markup: `<emotion>x</emotion>`, wantErr: "<emotion> requires a name"},
+		{markup: `<speak>unclosed`, wantErr: "invalid SSML"},
+		{markup: `<?xml version="1.0"<speak>x</speak>`, wantErr: "unterminated XML declaration"},
+	}
+	for _, tt := range tests {
+		_, err := ParseSSML(tt.markup, nil)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("ParseSSML(%q) error = %v, want %q", tt.markup, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSayDate(t *testing.T) {
+	tests := []struct {
+		text, format, want string
+	}{
+		{text: "2024-03-05", want: "March 5, 2024"},
+		{text: "03/05/2024", format: "mdy", want: "March 5, 2024"},
+		{text: "5.3.2024", format: "dmy", want: "March 5, 2024"},
+		{text: "2024-13-05", want: "2024-13-05"},
+		{text: "yesterday", want: "yesterday"},
+	}
+	for _, tt := range tests {
+		if got := sayDate(tt.text, tt.format); got != tt.want {
+			t.Errorf("sayDate(%q, %q) = %q, want %q", tt.text, tt.format, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package audio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/apis/base"
+	"github.com/modelslab/modelslab-go/pkg/schemas/audio"
+	"github.com/modelslab/modelslab-go/pkg/utils"
+)
+
+// synthesizeAll renders each request, running up to concurrency requests at
+// once. The first failure cancels the rest and is reported with the label
+// and number of the failed request.
+func (a *API) synthesizeAll(ctx context.Context, reqs []*audio.Text2SpeechRequest, concurrency int, label string) ([]*utils.PCM, error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	clips := make([]*utils.PCM, len(reqs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for i, req := range reqs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, req *audio.Text2SpeechRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			clip, err := a.synthesize(ctx, req)
+			if err != nil {
+				// Only the first failure is kept; the others are usually
+				// the cancellation it caused
+				once.Do(func() {
+					firstErr = fmt.Errorf("%s %d: %w", label, i+1, err)
+					cancel()
+				})
+				return
+			}
+			clips[i] = clip
+		}(i, req)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return clips, nil
+}
+
+// synthesize renders one request, waiting for it when the API queues it,
+// and decodes the result. The audio is requested as WAV unless the request
+// asks for MP3, the other format the API returns and DecodeAudio reads.
+func (a *API) synthesize(ctx context.Context, req *audio.Text2SpeechRequest) (*utils.PCM, error) {
+	if req.OutputFormat == nil {
+		wav := "wav"
+		withFormat := *req
+		withFormat.OutputFormat = &wav
+		req = &withFormat
+	}
+
+	resp, err := a.textToSpeech(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	out, err := base.Await[audio.AudioResponse](ctx, a.BaseAPI, resp)
+	if err != nil {
+		return nil, fmt.Errorf("text-to-speech request failed: %w", err)
+	}
+
+	return a.DownloadAudio(ctx, out, nil)
+}
+
+// stitch joins clips into one track, inserting pauses[i] of silence before
+// clip i; pauses[0] is ignored. When processing trims silence, each clip is
+// trimmed before stitching so the pauses are exact; the other steps apply to
+// the whole track. It returns the track and the span of each clip in it.
+func stitch(clips []*utils.PCM, pauses []time.Duration, processing *utils.AudioProcessing) (*utils.PCM, []utils.Interval, error) {
+	parts := make([]*utils.PCM, 0, 2*len(clips))
+	spans := make([]utils.Interval, len(clips))
+	var offset time.Duration
+	for i, clip := range clips {
+		if processing != nil && processing.TrimSilence {
+			clip = clip.TrimSilence(processing.SilenceThreshold, processing.SilencePadding)
+		}
+		if i > 0 && pauses[i] > 0 {
+			silence := utils.Silence(clip.SampleRate, clip.Channels, pauses[i])
+			parts = append(parts, silence)
+			offset += silence.Duration()
+		}
+		parts = append(parts, clip)
+		spans[i] = utils.Interval{Start: offset, End: offset + clip.Duration()}
+		offset += clip.Duration()
+	}
+
+	pcm, err := utils.Concat(parts...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if processing != nil {
+		whole := *processing
+		whole.TrimSilence = false
+		if pcm, err = pcm.Process(&whole); err != nil {
+			return nil, nil, err
+		}
+	}
+	return pcm, spans, nil
+}
+
+// encodeWAV returns audio as a WAV file
+func encodeWAV(pcm *utils.PCM) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := utils.EncodeWAV(&buf, pcm); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
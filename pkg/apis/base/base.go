@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/modelslab/modelslab-go/pkg/client"
+	schemas "github.com/modelslab/modelslab-go/pkg/schemas/base"
 )
 
 // BaseAPI provides common functionality for all API modules
@@ -17,22 +18,15 @@ type BaseAPI struct {
 
 // NewBaseAPI creates a new base API instance
 func NewBaseAPI(c *client.Client, enterprise bool, apiPath string) *BaseAPI {
-	var baseURL string
-	if enterprise {
-		baseURL = c.GetBaseURL() + "v1/enterprise/" + apiPath + "/"
-	} else {
-		baseURL = c.GetBaseURL() + "v6/" + apiPath + "/"
-	}
-
 	return &BaseAPI{
 		client:     c,
 		enterprise: enterprise,
-		baseURL:    baseURL,
+		baseURL:    c.ModuleURL(apiPath, enterprise),
 	}
 }
 
 // Fetch performs a fetch operation with the provided ID
-func (b *BaseAPI) Fetch(ctx context.Context, id string) (*client.APIResponse, error) {
+func (b *BaseAPI) Fetch(ctx context.Context, id string) (*schemas.Response, error) {
 	if id == "" {
 		return nil, fmt.Errorf("id is required for fetch operation")
 	}
@@ -42,11 +36,16 @@ func (b *BaseAPI) Fetch(ctx context.Context, id string) (*client.APIResponse, er
 		return nil, fmt.Errorf("fetch operation failed: %w", err)
 	}
 
-	return resp, nil
+	var out schemas.Response
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("fetch operation failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // SystemDetails returns system details (enterprise only)
-func (b *BaseAPI) SystemDetails(ctx context.Context) (*client.APIResponse, error) {
+func (b *BaseAPI) SystemDetails(ctx context.Context) (*schemas.SystemDetailsResponse, error) {
 	if !b.enterprise {
 		return nil, fmt.Errorf("system details are only available for enterprise users")
 	}
@@ -57,11 +56,16 @@ func (b *BaseAPI) SystemDetails(ctx context.Context) (*client.APIResponse, error
 		return nil, fmt.Errorf("system details request failed: %w", err)
 	}
 
-	return resp, nil
+	var out schemas.SystemDetailsResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("system details request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // RestartServer restarts the server (enterprise only)
-func (b *BaseAPI) RestartServer(ctx context.Context) (*client.APIResponse, error) {
+func (b *BaseAPI) RestartServer(ctx context.Context) (*schemas.Response, error) {
 	if !b.enterprise {
 		return nil, fmt.Errorf("restart server is only available for enterprise users")
 	}
@@ -72,11 +76,16 @@ func (b *BaseAPI) RestartServer(ctx context.Context) (*client.APIResponse, error
 		return nil, fmt.Errorf("restart server request failed: %w", err)
 	}
 
-	return resp, nil
+	var out schemas.Response
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("restart server request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // Update updates the system (enterprise only)
-func (b *BaseAPI) Update(ctx context.Context) (*client.APIResponse, error) {
+func (b *BaseAPI) Update(ctx context.Context) (*schemas.Response, error) {
 	if !b.enterprise {
 		return nil, fmt.Errorf("update is only available for enterprise users")
 	}
@@ -87,11 +96,16 @@ func (b *BaseAPI) Update(ctx context.Context) (*client.APIResponse, error) {
 		return nil, fmt.Errorf("update request failed: %w", err)
 	}
 
-	return resp, nil
+	var out schemas.Response
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("update request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // ClearCache clears the cache (enterprise only)
-func (b *BaseAPI) ClearCache(ctx context.Context) (*client.APIResponse, error) {
+func (b *BaseAPI) ClearCache(ctx context.Context) (*schemas.Response, error) {
 	if !b.enterprise {
 		return nil, fmt.Errorf("clear cache is only available for enterprise users")
 	}
@@ -102,11 +116,16 @@ func (b *BaseAPI) ClearCache(ctx context.Context) (*client.APIResponse, error) {
 		return nil, fmt.Errorf("clear cache request failed: %w", err)
 	}
 
-	return resp, nil
+	var out schemas.Response
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("clear cache request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // ClearQueue clears the queue (enterprise only)
-func (b *BaseAPI) ClearQueue(ctx context.Context) (*client.APIResponse, error) {
+func (b *BaseAPI) ClearQueue(ctx context.Context) (*schemas.Response, error) {
 	if !b.enterprise {
 		return nil, fmt.Errorf("clear queue is only available for enterprise users")
 	}
@@ -117,7 +136,12 @@ func (b *BaseAPI) ClearQueue(ctx context.Context) (*client.APIResponse, error) {
 		return nil, fmt.Errorf("clear queue request failed: %w", err)
 	}
 
-	return resp, nil
+	var out schemas.Response
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("clear queue request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // GetClient returns the underlying client
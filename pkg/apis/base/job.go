@@ -0,0 +1,345 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/client"
+	schemas "github.com/modelslab/modelslab-go/pkg/schemas/base"
+	"github.com/modelslab/modelslab-go/pkg/utils"
+)
+
+// Job statuses reported by the API
+const (
+	StatusSuccess    = "success"
+	StatusProcessing = "processing"
+	StatusError      = "error"
+	StatusFailed     = "failed"
+)
+
+// maxPollInterval caps how long a job waits between polls when the server
+// reports a long ETA
+const maxPollInterval = 30 * time.Second
+
+// DefaultMaxPolls is how many times Wait polls a job before giving up, unless
+// changed with SetMaxPolls
+const DefaultMaxPolls = 600
+
+// maxUnknownStatus is how many responses in a row may report a status the
+// job does not know before it fails
+const maxUnknownStatus = 10
+
+// JobProgress describes the state of a job after a poll
+type JobProgress struct {
+	ID          string
+	Status      string
+	ETA         time.Duration
+	FutureLinks []string
+	Attempt     int
+	Elapsed     time.Duration
+}
+
+// Job tracks an asynchronous generation until its typed result is ready
+type Job[T any] struct {
+	api *BaseAPI
+
+	mu          sync.Mutex
+	id          string
+	status      string
+	message     string
+	eta         time.Duration
+	fetchURL    string
+	futureLinks []string
+	result      *T
+	err         error
+	attempts    int
+	unknown     int
+	started     time.Time
+	interval    time.Duration
+	maxPolls    int
+	progress    []func(JobProgress)
+}
+
+// NewJob creates a job from the initial response of an async-capable call
+func NewJob[T any](b *BaseAPI, resp *client.APIResponse) (*Job[T], error) {
+	if resp == nil {
+		return nil, fmt.Errorf("response cannot be nil")
+	}
+
+	j := &Job[T]{
+		api:      b,
+		started:  time.Now(),
+		interval: b.GetClient().GetFetchTimeout(),
+		maxPolls: DefaultMaxPolls,
+	}
+	if err := j.update(resp); err != nil {
+		return nil, err
+	}
+
+	if j.status == StatusError || j.status == StatusFailed {
+		return nil, j.err
+	}
+	if !j.done() && j.id == "" && j.fetchURL == "" {
+		return nil, fmt.Errorf("job is %s but the response has no id to fetch", j.status)
+	}
+
+	return j, nil
+}
+
+// ID returns the job id
+func (j *Job[T]) ID() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.id
+}
+
+// Status returns the last known job status
+func (j *Job[T]) Status() string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+// ETA returns the last estimate reported by the server
+func (j *Job[T]) ETA() time.Duration {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.eta
+}
+
+// FutureLinks returns the links where the output will be available once the
+// job completes
+func (j *Job[T]) FutureLinks() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]string(nil), j.futureLinks...)
+}
+
+// Done reports whether the job has finished, successfully or not
+func (j *Job[T]) Done() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.done()
+}
+
+// Result returns the most recent typed response. It is the final result once
+// Status reports success.
+func (j *Job[T]) Result() *T {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.result
+}
+
+// OnProgress registers a callback invoked after every poll
+func (j *Job[T]) OnProgress(fn func(JobProgress)) *Job[T] {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.progress = append(j.progress, fn)
+	return j
+}
+
+// SetPollInterval sets the minimum delay between polls
+func (j *Job[T]) SetPollInterval(d time.Duration) *Job[T] {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.interval = d
+	return j
+}
+
+// SetMaxPolls sets how many times Wait polls before giving up; zero or less
+// means no limit
+func (j *Job[T]) SetMaxPolls(n int) *Job[T] {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.maxPolls = n
+	return j
+}
+
+// Poll fetches the job status once and reports whether the job has finished
+func (j *Job[T]) Poll(ctx context.Context) (bool, error) {
+	j.mu.Lock()
+	if j.done() {
+		defer j.mu.Unlock()
+		return true, j.err
+	}
+	fetchURL := j.fetchURL
+	if fetchURL == "" {
+		fetchURL = j.api.GetBaseURL() + "fetch/" + j.id
+	}
+	j.attempts++
+	j.mu.Unlock()
+
+	resp, err := j.api.GetClient().Post(ctx, fetchURL, nil)
+
+	j.mu.Lock()
+	var apiErr *client.APIError
+	switch {
+	case err == nil:
+		err = j.update(resp)
+	case errors.As(err, &apiErr) && !apiErr.Retryable:
+		// The server rejected the job for good, e.g. {"status":"failed"}
+		j.status = StatusFailed
+		if apiErr.Status == StatusError {
+			j.status = StatusError
+		}
+		j.message = apiErr.Message
+		j.err = fmt.Errorf("job %s failed: %w", j.id, err)
+		err = nil
+	default:
+		err = fmt.Errorf("job %s poll failed: %w", j.id, err)
+	}
+	done := j.done()
+	if done && err == nil {
+		err = j.err
+	}
+	progress := JobProgress{
+		ID:          j.id,
+		Status:      j.status,
+		ETA:         j.eta,
+		FutureLinks: append([]string(nil), j.futureLinks...),
+		Attempt:     j.attempts,
+		Elapsed:     time.Since(j.started),
+	}
+	callbacks := make([]func(JobProgress), len(j.progress))
+	copy(callbacks, j.progress)
+	j.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(progress)
+	}
+
+	return done, err
+}
+
+// Wait polls the job until it finishes or ctx is done and returns the typed
+// final result. Transient poll errors are retried up to the client's fetch
+// retry count in a row, and Wait gives up after the job's maximum number of
+// polls.
+func (j *Job[T]) Wait(ctx context.Context) (*T, error) {
+	failures := 0
+	for {
+		done, err := j.Poll(ctx)
+		if done {
+			if err != nil {
+				return nil, err
+			}
+			return j.Result(), nil
+		}
+
+		if err != nil {
+			failures++
+			if failures >= j.api.GetClient().GetFetchRetry() {
+				return nil, err
+			}
+		} else {
+			failures = 0
+		}
+
+		if err := j.checkPolls(); err != nil {
+			return nil, err
+		}
+		if err := utils.SleepContext(ctx, j.nextDelay()); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Await returns the typed result of a call that answered with resp, polling
+// for it when the API queued the call
+func Await[T any](ctx context.Context, b *BaseAPI, resp *client.APIResponse) (*T, error) {
+	job, err := NewJob[T](b, resp)
+	if err != nil {
+		return nil, err
+	}
+	return job.Wait(ctx)
+}
+
+// checkPolls fails once the job has been polled its maximum number of times
+func (j *Job[T]) checkPolls() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.maxPolls > 0 && j.attempts >= j.maxPolls {
+		return fmt.Errorf("job %s is still %s after %d polls", j.id, j.status, j.attempts)
+	}
+	return nil
+}
+
+// nextDelay returns how long to wait before the next poll, using the server's
+// ETA when it is longer than the poll interval
+func (j *Job[T]) nextDelay() time.Duration {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	delay := j.interval
+	if j.eta > delay {
+		delay = j.eta
+	}
+	if delay > maxPollInterval {
+		delay = maxPollInterval
+	}
+	return delay
+}
+
+// done reports whether the job finished. The caller must hold j.mu.
+func (j *Job[T]) done() bool {
+	return j.status == StatusSuccess || j.status == StatusError || j.status == StatusFailed
+}
+
+// update applies a response to the job state. The caller must hold j.mu.
+func (j *Job[T]) update(resp *client.APIResponse) error {
+	var state schemas.Response
+	if err := resp.Decode(&state); err != nil {
+		return err
+	}
+
+	j.status = state.Status
+	j.message = state.Message
+	if state.ID != "" {
+		j.id = state.ID
+	}
+	if state.FetchResult != "" {
+		j.fetchURL = state.FetchResult
+	}
+	if len(state.FutureLinks) > 0 {
+		j.futureLinks = state.FutureLinks
+	}
+	j.eta = time.Duration(state.ETA * float64(time.Second))
+
+	switch j.status {
+	case StatusSuccess:
+		j.unknown = 0
+		var result T
+		if err := resp.Decode(&result); err != nil {
+			// A finished job always has a result, so one that cannot be
+			// decoded fails the job
+			j.status = StatusFailed
+			j.err = fmt.Errorf("job %s result could not be decoded: %w", j.id, err)
+			return nil
+		}
+		j.result = &result
+		j.eta = 0
+	case StatusProcessing:
+		j.unknown = 0
+		var result T
+		if err := resp.Decode(&result); err == nil {
+			j.result = &result
+		}
+	case StatusError, StatusFailed:
+		j.err = fmt.Errorf("job %s failed: %s", j.id, j.message)
+	default:
+		// Some endpoints answer with an unknown status while queued; keep
+		// polling them as if they were processing, up to maxUnknownStatus
+		// times in a row
+		j.unknown++
+		if j.unknown >= maxUnknownStatus {
+			j.err = fmt.Errorf("job %s reported unknown status %q %d times in a row", j.id, j.status, j.unknown)
+			j.status = StatusFailed
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package base
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/client"
+)
+
+type jobResult struct {
+	Status  string    `json:"status"`
+	Output  []string  `json:"output"`
+	Created time.Time `json:"created"`
+}
+
+// jobTestAPI returns an API whose fetch endpoint answers with bodies in turn,
+// repeating the last one
+func jobTestAPI(t *testing.T, bodies ...string) (*BaseAPI, *client.APIResponse) {
+	t.Helper()
+
+	polls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := bodies[min(polls, len(bodies)-1)]
+		polls++
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := client.NewClient(client.WithAPIKey("test-key"), client.WithBaseURL(srv.URL+"/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := client.APIResponse{"status": "processing", "id": "42", "fetch_result": srv.URL + "/fetch/42"}
+	return NewBaseAPI(c, false, "images"), &resp
+}
+
+func TestJobWait(t *testing.T) {
+	tests := []struct {
+		name    string
+		bodies  []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:   "success",
+			bodies: []string{`{"status": "processing"}`, `{"status": "success", "output": ["https://cdn/out.png"]}`},
+			want:   []string{"https://cdn/out.png"},
+		},
+		{
+			name:    "failed",
+			bodies:  []string{`{"status": "processing"}`, `{"status": "failed", "message": "out of memory"}`},
+			wantErr: "out of memory",
+		},
+		{
+			name:    "undecodable result",
+			bodies:  []string{`{"status": "success", "output": ["https://cdn/out.png"], "created": "yesterday"}`},
+			wantErr: "could not be decoded",
+		},
+		{
+			name:    "unknown status",
+			bodies:  []string{`{"status": "queued"}`},
+			wantErr: `unknown status "queued"`,
+		},
+		{
+			name:   "unknown status while queued",
+			bodies: []string{`{"status": "queued"}`, `{"status": "success", "output": ["https://cdn/out.png"]}`},
+			want:   []string{"https://cdn/out.png"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, resp := jobTestAPI(t, tt.bodies...)
+			job, err := NewJob[jobResult](api, resp)
+			if err != nil {
+				t.Fatal(err)
+			}
+			job.SetPollInterval(time.Millisecond)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			out, err := job.Wait(ctx)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Wait() error = %v, want %q", err, tt.wantErr)
+				}
+				if !job.Done() {
+					t.Error("job is not done after failing")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out == nil || strings.Join(out.Output, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Wait() = %+v, want output %v", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestJobMaxPolls(t *testing.T) {
+	api, resp := jobTestAPI(t, `{"status": "processing"}`)
+	job, err := NewJob[jobResult](api, resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.SetPollInterval(time.Millisecond).SetMaxPolls(3)
+
+	polls := 0
+	job.OnProgress(func(JobProgress) { polls++ })
+	if _, err := job.Wait(context.Background()); err == nil || !strings.Contains(err.Error(), "after 3 polls") {
+		t.Fatalf("Wait() error = %v, want it to give up after 3 polls", err)
+	}
+	if polls != 3 {
+		t.Errorf("polled %d times, want 3", polls)
+	}
+}
+
+func TestAwait(t *testing.T) {
+	tests := []struct {
+		name    string
+		initial client.APIResponse
+	}{
+		{name: "finished", initial: client.APIResponse{"status": "success", "output": []interface{}{"https://cdn/out.png"}}},
+		{name: "queued", initial: client.APIResponse{"status": "processing", "id": "42"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			api, _ := jobTestAPI(t, `{"status": "success", "output": ["https://cdn/out.png"]}`)
+			out, err := Await[jobResult](context.Background(), api, &tt.initial)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(out.Output) != 1 || out.Output[0] != "https://cdn/out.png" {
+				t.Errorf("Await() = %+v, want the finished output", out)
+			}
+		})
+	}
+}
@@ -23,7 +23,7 @@ func New(c *client.Client, enterprise bool) *API {
 }
 
 // TextToImage performs text-to-image generation
-func (c *API) TextToImage(ctx context.Context, req *community.Text2ImageRequest) (*client.APIResponse, error) {
+func (c *API) TextToImage(ctx context.Context, req *community.Text2ImageRequest) (*community.ImageResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -34,11 +34,16 @@ func (c *API) TextToImage(ctx context.Context, req *community.Text2ImageRequest)
 		return nil, fmt.Errorf("text-to-image request failed: %w", err)
 	}
 
-	return resp, nil
+	var out community.ImageResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("text-to-image request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // ImageToImage performs image-to-image generation
-func (c *API) ImageToImage(ctx context.Context, req *community.Image2ImageRequest) (*client.APIResponse, error) {
+func (c *API) ImageToImage(ctx context.Context, req *community.Image2ImageRequest) (*community.ImageResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -49,11 +54,16 @@ func (c *API) ImageToImage(ctx context.Context, req *community.Image2ImageReques
 		return nil, fmt.Errorf("image-to-image request failed: %w", err)
 	}
 
-	return resp, nil
+	var out community.ImageResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("image-to-image request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // Inpainting performs inpainting
-func (c *API) Inpainting(ctx context.Context, req *community.InpaintingRequest) (*client.APIResponse, error) {
+func (c *API) Inpainting(ctx context.Context, req *community.InpaintingRequest) (*community.ImageResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -64,11 +74,16 @@ func (c *API) Inpainting(ctx context.Context, req *community.InpaintingRequest)
 		return nil, fmt.Errorf("inpainting request failed: %w", err)
 	}
 
-	return resp, nil
+	var out community.ImageResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("inpainting request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // ControlNet performs ControlNet generation
-func (c *API) ControlNet(ctx context.Context, req *community.ControlNetRequest) (*client.APIResponse, error) {
+func (c *API) ControlNet(ctx context.Context, req *community.ControlNetRequest) (*community.ImageResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -79,5 +94,10 @@ func (c *API) ControlNet(ctx context.Context, req *community.ControlNetRequest)
 		return nil, fmt.Errorf("ControlNet request failed: %w", err)
 	}
 
-	return resp, nil
+	var out community.ImageResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("ControlNet request failed: %w", err)
+	}
+
+	return &out, nil
 }
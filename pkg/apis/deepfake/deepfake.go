@@ -23,7 +23,7 @@ func New(c *client.Client, enterprise bool) *API {
 }
 
 // SpecificFaceSwap performs specific face swap
-func (d *API) SpecificFaceSwap(ctx context.Context, req *deepfake.SpecificFaceSwapRequest) (*client.APIResponse, error) {
+func (d *API) SpecificFaceSwap(ctx context.Context, req *deepfake.SpecificFaceSwapRequest) (*base.Job[deepfake.DeepFakeResponse], error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -34,11 +34,16 @@ func (d *API) SpecificFaceSwap(ctx context.Context, req *deepfake.SpecificFaceSw
 		return nil, fmt.Errorf("specific face swap request failed: %w", err)
 	}
 
-	return resp, nil
+	job, err := base.NewJob[deepfake.DeepFakeResponse](d.BaseAPI, resp)
+	if err != nil {
+		return nil, fmt.Errorf("specific face swap request failed: %w", err)
+	}
+
+	return job, nil
 }
 
 // MultipleFaceSwap performs multiple face swap
-func (d *API) MultipleFaceSwap(ctx context.Context, req *deepfake.MultipleFaceSwapRequest) (*client.APIResponse, error) {
+func (d *API) MultipleFaceSwap(ctx context.Context, req *deepfake.MultipleFaceSwapRequest) (*base.Job[deepfake.DeepFakeResponse], error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -49,11 +54,16 @@ func (d *API) MultipleFaceSwap(ctx context.Context, req *deepfake.MultipleFaceSw
 		return nil, fmt.Errorf("multiple face swap request failed: %w", err)
 	}
 
-	return resp, nil
+	job, err := base.NewJob[deepfake.DeepFakeResponse](d.BaseAPI, resp)
+	if err != nil {
+		return nil, fmt.Errorf("multiple face swap request failed: %w", err)
+	}
+
+	return job, nil
 }
 
 // MultipleVideoSwap performs multiple video face swap
-func (d *API) MultipleVideoSwap(ctx context.Context, req *deepfake.SpecificVideoSwapRequest) (*client.APIResponse, error) {
+func (d *API) MultipleVideoSwap(ctx context.Context, req *deepfake.SpecificVideoSwapRequest) (*base.Job[deepfake.DeepFakeResponse], error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -64,11 +74,16 @@ func (d *API) MultipleVideoSwap(ctx context.Context, req *deepfake.SpecificVideo
 		return nil, fmt.Errorf("multiple video swap request failed: %w", err)
 	}
 
-	return resp, nil
+	job, err := base.NewJob[deepfake.DeepFakeResponse](d.BaseAPI, resp)
+	if err != nil {
+		return nil, fmt.Errorf("multiple video swap request failed: %w", err)
+	}
+
+	return job, nil
 }
 
 // SingleVideoSwap performs single video face swap
-func (d *API) SingleVideoSwap(ctx context.Context, req *deepfake.SingleVideoSwapRequest) (*client.APIResponse, error) {
+func (d *API) SingleVideoSwap(ctx context.Context, req *deepfake.SingleVideoSwapRequest) (*base.Job[deepfake.DeepFakeResponse], error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -79,5 +94,10 @@ func (d *API) SingleVideoSwap(ctx context.Context, req *deepfake.SingleVideoSwap
 		return nil, fmt.Errorf("single video swap request failed: %w", err)
 	}
 
-	return resp, nil
+	job, err := base.NewJob[deepfake.DeepFakeResponse](d.BaseAPI, resp)
+	if err != nil {
+		return nil, fmt.Errorf("single video swap request failed: %w", err)
+	}
+
+	return job, nil
 }
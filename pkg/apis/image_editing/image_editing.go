@@ -23,7 +23,7 @@ func New(c *client.Client, enterprise bool) *API {
 }
 
 // Outpainting performs outpainting
-func (i *API) Outpainting(ctx context.Context, req *image_editing.OutpaintingRequest) (*client.APIResponse, error) {
+func (i *API) Outpainting(ctx context.Context, req *image_editing.OutpaintingRequest) (*image_editing.ImageEditingResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -34,11 +34,16 @@ func (i *API) Outpainting(ctx context.Context, req *image_editing.OutpaintingReq
 		return nil, fmt.Errorf("outpainting request failed: %w", err)
 	}
 
-	return resp, nil
+	var out image_editing.ImageEditingResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("outpainting request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // BackgroundRemover performs background removal
-func (i *API) BackgroundRemover(ctx context.Context, req *image_editing.BackgroundRemoverRequest) (*client.APIResponse, error) {
+func (i *API) BackgroundRemover(ctx context.Context, req *image_editing.BackgroundRemoverRequest) (*image_editing.ImageEditingResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -49,11 +54,16 @@ func (i *API) BackgroundRemover(ctx context.Context, req *image_editing.Backgrou
 		return nil, fmt.Errorf("background removal request failed: %w", err)
 	}
 
-	return resp, nil
+	var out image_editing.ImageEditingResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("background removal request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // SuperResolution performs super resolution
-func (i *API) SuperResolution(ctx context.Context, req *image_editing.SuperResolutionRequest) (*client.APIResponse, error) {
+func (i *API) SuperResolution(ctx context.Context, req *image_editing.SuperResolutionRequest) (*image_editing.ImageEditingResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -64,11 +74,16 @@ func (i *API) SuperResolution(ctx context.Context, req *image_editing.SuperResol
 		return nil, fmt.Errorf("super resolution request failed: %w", err)
 	}
 
-	return resp, nil
+	var out image_editing.ImageEditingResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("super resolution request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // Fashion performs fashion generation
-func (i *API) Fashion(ctx context.Context, req *image_editing.FashionRequest) (*client.APIResponse, error) {
+func (i *API) Fashion(ctx context.Context, req *image_editing.FashionRequest) (*image_editing.ImageEditingResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -79,11 +94,16 @@ func (i *API) Fashion(ctx context.Context, req *image_editing.FashionRequest) (*
 		return nil, fmt.Errorf("fashion request failed: %w", err)
 	}
 
-	return resp, nil
+	var out image_editing.ImageEditingResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("fashion request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // ObjectRemover performs object removal
-func (i *API) ObjectRemover(ctx context.Context, req *image_editing.ObjectRemovalRequest) (*client.APIResponse, error) {
+func (i *API) ObjectRemover(ctx context.Context, req *image_editing.ObjectRemovalRequest) (*image_editing.ImageEditingResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -94,11 +114,16 @@ func (i *API) ObjectRemover(ctx context.Context, req *image_editing.ObjectRemova
 		return nil, fmt.Errorf("object removal request failed: %w", err)
 	}
 
-	return resp, nil
+	var out image_editing.ImageEditingResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("object removal request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // FaceGen performs face generation
-func (i *API) FaceGen(ctx context.Context, req *image_editing.FacegenRequest) (*client.APIResponse, error) {
+func (i *API) FaceGen(ctx context.Context, req *image_editing.FacegenRequest) (*image_editing.ImageEditingResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -109,11 +134,16 @@ func (i *API) FaceGen(ctx context.Context, req *image_editing.FacegenRequest) (*
 		return nil, fmt.Errorf("face generation request failed: %w", err)
 	}
 
-	return resp, nil
+	var out image_editing.ImageEditingResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("face generation request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // Inpainting performs inpainting
-func (i *API) Inpainting(ctx context.Context, req *image_editing.InpaintingRequest) (*client.APIResponse, error) {
+func (i *API) Inpainting(ctx context.Context, req *image_editing.InpaintingRequest) (*image_editing.ImageEditingResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -124,11 +154,16 @@ func (i *API) Inpainting(ctx context.Context, req *image_editing.InpaintingReque
 		return nil, fmt.Errorf("inpainting request failed: %w", err)
 	}
 
-	return resp, nil
+	var out image_editing.ImageEditingResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("inpainting request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // Headshot performs headshot generation
-func (i *API) Headshot(ctx context.Context, req *image_editing.HeadshotRequest) (*client.APIResponse, error) {
+func (i *API) Headshot(ctx context.Context, req *image_editing.HeadshotRequest) (*image_editing.ImageEditingResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -139,11 +174,16 @@ func (i *API) Headshot(ctx context.Context, req *image_editing.HeadshotRequest)
 		return nil, fmt.Errorf("headshot request failed: %w", err)
 	}
 
-	return resp, nil
+	var out image_editing.ImageEditingResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("headshot request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // FluxHeadshot performs flux headshot generation
-func (i *API) FluxHeadshot(ctx context.Context, req *image_editing.FluxHeadshotRequest) (*client.APIResponse, error) {
+func (i *API) FluxHeadshot(ctx context.Context, req *image_editing.FluxHeadshotRequest) (*image_editing.ImageEditingResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -154,5 +194,10 @@ func (i *API) FluxHeadshot(ctx context.Context, req *image_editing.FluxHeadshotR
 		return nil, fmt.Errorf("flux headshot request failed: %w", err)
 	}
 
-	return resp, nil
+	var out image_editing.ImageEditingResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("flux headshot request failed: %w", err)
+	}
+
+	return &out, nil
 }
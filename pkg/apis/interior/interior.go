@@ -23,7 +23,7 @@ func New(c *client.Client, enterprise bool) *API {
 }
 
 // Interior performs interior design generation
-func (i *API) Interior(ctx context.Context, req *interior.InteriorRequest) (*client.APIResponse, error) {
+func (i *API) Interior(ctx context.Context, req *interior.InteriorRequest) (*interior.InteriorResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -34,11 +34,16 @@ func (i *API) Interior(ctx context.Context, req *interior.InteriorRequest) (*cli
 		return nil, fmt.Errorf("interior design request failed: %w", err)
 	}
 
-	return resp, nil
+	var out interior.InteriorResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("interior design request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // RoomDecorator performs room decoration
-func (i *API) RoomDecorator(ctx context.Context, req *interior.RoomDecoratorRequest) (*client.APIResponse, error) {
+func (i *API) RoomDecorator(ctx context.Context, req *interior.RoomDecoratorRequest) (*interior.InteriorResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -49,11 +54,16 @@ func (i *API) RoomDecorator(ctx context.Context, req *interior.RoomDecoratorRequ
 		return nil, fmt.Errorf("room decoration request failed: %w", err)
 	}
 
-	return resp, nil
+	var out interior.InteriorResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("room decoration request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // Floor performs floor planning
-func (i *API) Floor(ctx context.Context, req *interior.FloorRequest) (*client.APIResponse, error) {
+func (i *API) Floor(ctx context.Context, req *interior.FloorRequest) (*interior.InteriorResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -64,11 +74,16 @@ func (i *API) Floor(ctx context.Context, req *interior.FloorRequest) (*client.AP
 		return nil, fmt.Errorf("floor planning request failed: %w", err)
 	}
 
-	return resp, nil
+	var out interior.InteriorResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("floor planning request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // Scenario performs scenario generation
-func (i *API) Scenario(ctx context.Context, req *interior.ScenarioRequest) (*client.APIResponse, error) {
+func (i *API) Scenario(ctx context.Context, req *interior.ScenarioRequest) (*interior.InteriorResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -79,11 +94,16 @@ func (i *API) Scenario(ctx context.Context, req *interior.ScenarioRequest) (*cli
 		return nil, fmt.Errorf("scenario generation request failed: %w", err)
 	}
 
-	return resp, nil
+	var out interior.InteriorResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("scenario generation request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // ExteriorRestorer performs exterior restoration
-func (i *API) ExteriorRestorer(ctx context.Context, req *interior.ExteriorRequest) (*client.APIResponse, error) {
+func (i *API) ExteriorRestorer(ctx context.Context, req *interior.ExteriorRequest) (*interior.InteriorResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -94,5 +114,10 @@ func (i *API) ExteriorRestorer(ctx context.Context, req *interior.ExteriorReques
 		return nil, fmt.Errorf("exterior restoration request failed: %w", err)
 	}
 
-	return resp, nil
+	var out interior.InteriorResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("exterior restoration request failed: %w", err)
+	}
+
+	return &out, nil
 }
@@ -23,7 +23,7 @@ func New(c *client.Client, enterprise bool) *API {
 }
 
 // TextToImage performs realtime text-to-image generation
-func (r *API) TextToImage(ctx context.Context, req *realtime.Text2ImageRequest) (*client.APIResponse, error) {
+func (r *API) TextToImage(ctx context.Context, req *realtime.Text2ImageRequest) (*realtime.RealtimeResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -34,11 +34,16 @@ func (r *API) TextToImage(ctx context.Context, req *realtime.Text2ImageRequest)
 		return nil, fmt.Errorf("realtime text-to-image request failed: %w", err)
 	}
 
-	return resp, nil
+	var out realtime.RealtimeResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("realtime text-to-image request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // ImageToImage performs realtime image-to-image generation
-func (r *API) ImageToImage(ctx context.Context, req *realtime.Image2ImageRequest) (*client.APIResponse, error) {
+func (r *API) ImageToImage(ctx context.Context, req *realtime.Image2ImageRequest) (*realtime.RealtimeResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -49,11 +54,16 @@ func (r *API) ImageToImage(ctx context.Context, req *realtime.Image2ImageRequest
 		return nil, fmt.Errorf("realtime image-to-image request failed: %w", err)
 	}
 
-	return resp, nil
+	var out realtime.RealtimeResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("realtime image-to-image request failed: %w", err)
+	}
+
+	return &out, nil
 }
 
 // Inpainting performs realtime inpainting
-func (r *API) Inpainting(ctx context.Context, req *realtime.InpaintingRequest) (*client.APIResponse, error) {
+func (r *API) Inpainting(ctx context.Context, req *realtime.InpaintingRequest) (*realtime.RealtimeResponse, error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -64,5 +74,10 @@ func (r *API) Inpainting(ctx context.Context, req *realtime.InpaintingRequest) (
 		return nil, fmt.Errorf("realtime inpainting request failed: %w", err)
 	}
 
-	return resp, nil
+	var out realtime.RealtimeResponse
+	if err := resp.Decode(&out); err != nil {
+		return nil, fmt.Errorf("realtime inpainting request failed: %w", err)
+	}
+
+	return &out, nil
 }
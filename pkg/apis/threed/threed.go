@@ -23,7 +23,7 @@ func New(c *client.Client, enterprise bool) *API {
 }
 
 // TextTo3D performs text-to-3D generation
-func (t *API) TextTo3D(ctx context.Context, req *threed.Text23DRequest) (*client.APIResponse, error) {
+func (t *API) TextTo3D(ctx context.Context, req *threed.Text23DRequest) (*base.Job[threed.ThreeDResponse], error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -34,11 +34,16 @@ func (t *API) TextTo3D(ctx context.Context, req *threed.Text23DRequest) (*client
 		return nil, fmt.Errorf("text-to-3D request failed: %w", err)
 	}
 
-	return resp, nil
+	job, err := base.NewJob[threed.ThreeDResponse](t.BaseAPI, resp)
+	if err != nil {
+		return nil, fmt.Errorf("text-to-3D request failed: %w", err)
+	}
+
+	return job, nil
 }
 
 // ImageTo3D performs image-to-3D generation
-func (t *API) ImageTo3D(ctx context.Context, req *threed.Image23DRequest) (*client.APIResponse, error) {
+func (t *API) ImageTo3D(ctx context.Context, req *threed.Image23DRequest) (*base.Job[threed.ThreeDResponse], error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -49,5 +54,10 @@ func (t *API) ImageTo3D(ctx context.Context, req *threed.Image23DRequest) (*clie
 		return nil, fmt.Errorf("image-to-3D request failed: %w", err)
 	}
 
-	return resp, nil
+	job, err := base.NewJob[threed.ThreeDResponse](t.BaseAPI, resp)
+	if err != nil {
+		return nil, fmt.Errorf("image-to-3D request failed: %w", err)
+	}
+
+	return job, nil
 }
@@ -23,7 +23,7 @@ func New(c *client.Client, enterprise bool) *API {
 }
 
 // TextToVideo performs text-to-video generation
-func (v *API) TextToVideo(ctx context.Context, req *video.Text2VideoRequest) (*client.APIResponse, error) {
+func (v *API) TextToVideo(ctx context.Context, req *video.Text2VideoRequest) (*base.Job[video.VideoResponse], error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -34,11 +34,16 @@ func (v *API) TextToVideo(ctx context.Context, req *video.Text2VideoRequest) (*c
 		return nil, fmt.Errorf("text-to-video request failed: %w", err)
 	}
 
-	return resp, nil
+	job, err := base.NewJob[video.VideoResponse](v.BaseAPI, resp)
+	if err != nil {
+		return nil, fmt.Errorf("text-to-video request failed: %w", err)
+	}
+
+	return job, nil
 }
 
 // ImageToVideo performs image-to-video generation
-func (v *API) ImageToVideo(ctx context.Context, req *video.Image2VideoRequest) (*client.APIResponse, error) {
+func (v *API) ImageToVideo(ctx context.Context, req *video.Image2VideoRequest) (*base.Job[video.VideoResponse], error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -49,11 +54,16 @@ func (v *API) ImageToVideo(ctx context.Context, req *video.Image2VideoRequest) (
 		return nil, fmt.Errorf("image-to-video request failed: %w", err)
 	}
 
-	return resp, nil
+	job, err := base.NewJob[video.VideoResponse](v.BaseAPI, resp)
+	if err != nil {
+		return nil, fmt.Errorf("image-to-video request failed: %w", err)
+	}
+
+	return job, nil
 }
 
 // TextToVideoUltra performs ultra text-to-video generation
-func (v *API) TextToVideoUltra(ctx context.Context, req *video.Text2VideoUltraRequest) (*client.APIResponse, error) {
+func (v *API) TextToVideoUltra(ctx context.Context, req *video.Text2VideoUltraRequest) (*base.Job[video.VideoResponse], error) {
 	if req == nil {
 		return nil, fmt.Errorf("request cannot be nil")
 	}
@@ -64,5 +74,10 @@ func (v *API) TextToVideoUltra(ctx context.Context, req *video.Text2VideoUltraRe
 		return nil, fmt.Errorf("ultra text-to-video request failed: %w", err)
 	}
 
-	return resp, nil
+	job, err := base.NewJob[video.VideoResponse](v.BaseAPI, resp)
+	if err != nil {
+		return nil, fmt.Errorf("ultra text-to-video request failed: %w", err)
+	}
+
+	return job, nil
 }
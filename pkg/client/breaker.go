@@ -0,0 +1,235 @@
+package client
+
+import (
+	"errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the server while the circuit
+// breaker for an endpoint is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a circuit breaker
+type BreakerState int
+
+// Circuit breaker states
+const (
+	// BreakerClosed lets requests through and counts failures
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects requests with ErrCircuitOpen until the cool-down
+	// has elapsed
+	BreakerOpen
+	// BreakerHalfOpen lets a limited number of trial requests through
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerConfig configures the circuit breaker
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// circuit
+	FailureThreshold int `validate:"min=1"`
+	// CoolDown is how long the circuit stays open before trial requests are
+	// allowed
+	CoolDown time.Duration `validate:"min=0"`
+	// HalfOpenRequests is the number of successful trial requests needed to
+	// close the circuit again; it defaults to 1
+	HalfOpenRequests int `validate:"min=0"`
+	// OnStateChange is called on every state transition with the breaker
+	// key, made of the base URL host and module path
+	OnStateChange func(key string, from, to BreakerState)
+}
+
+// DefaultBreakerConfig returns a circuit breaker configuration suitable for
+// most services
+func DefaultBreakerConfig() *BreakerConfig {
+	return &BreakerConfig{
+		FailureThreshold: 5,
+		CoolDown:         30 * time.Second,
+		HalfOpenRequests: 1,
+	}
+}
+
+// breakers tracks one circuit breaker per base URL and module path
+type breakers struct {
+	config BreakerConfig
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+// circuit is the state of a single circuit breaker
+type circuit struct {
+	state     BreakerState
+	failures  int
+	successes int
+	inFlight  int
+	openedAt  time.Time
+}
+
+// newBreakers returns the circuit breakers for config, or nil when config is
+// nil
+func newBreakers(config *BreakerConfig) *breakers {
+	if config == nil {
+		return nil
+	}
+
+	b := &breakers{
+		config:   *config,
+		circuits: make(map[string]*circuit),
+	}
+	if b.config.HalfOpenRequests <= 0 {
+		b.config.HalfOpenRequests = 1
+	}
+	return b
+}
+
+// breakerKey returns the key of the circuit guarding an endpoint
+func breakerKey(endpoint, module string) string {
+	host := endpoint
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	return host + "/" + module
+}
+
+// allow reports whether a request may be sent through the circuit for key.
+// It returns ErrCircuitOpen when the circuit rejects the request.
+func (b *breakers) allow(key string) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	c, ok := b.circuits[key]
+	if !ok {
+		c = &circuit{}
+		b.circuits[key] = c
+	}
+
+	var transition func()
+	if c.state == BreakerOpen && time.Since(c.openedAt) >= b.config.CoolDown {
+		transition = b.setState(key, c, BreakerHalfOpen)
+	}
+
+	var err error
+	switch c.state {
+	case BreakerOpen:
+		err = ErrCircuitOpen
+	case BreakerHalfOpen:
+		if c.inFlight >= b.config.HalfOpenRequests {
+			err = ErrCircuitOpen
+		} else {
+			c.inFlight++
+		}
+	}
+	b.mu.Unlock()
+
+	if transition != nil {
+		transition()
+	}
+	return err
+}
+
+// record reports the outcome of a request sent through the circuit for key
+func (b *breakers) record(key string, success bool) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	c := b.circuits[key]
+	var transition func()
+
+	switch c.state {
+	case BreakerClosed:
+		if success {
+			c.failures = 0
+		} else if c.failures++; c.failures >= b.config.FailureThreshold {
+			transition = b.setState(key, c, BreakerOpen)
+		}
+	case BreakerHalfOpen:
+		if c.inFlight > 0 {
+			c.inFlight--
+		}
+		if !success {
+			transition = b.setState(key, c, BreakerOpen)
+		} else if c.successes++; c.successes >= b.config.HalfOpenRequests {
+			transition = b.setState(key, c, BreakerClosed)
+		}
+	}
+	b.mu.Unlock()
+
+	if transition != nil {
+		transition()
+	}
+}
+
+// abandon releases a trial request that ended without a verdict, e.g. when
+// its context was cancelled
+func (b *breakers) abandon(key string) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c := b.circuits[key]; c.state == BreakerHalfOpen && c.inFlight > 0 {
+		c.inFlight--
+	}
+}
+
+// state returns the current state of the circuit for key
+func (b *breakers) state(key string) BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if c, ok := b.circuits[key]; ok {
+		return c.state
+	}
+	return BreakerClosed
+}
+
+// setState moves a circuit to a new state and returns the callback
+// notification to run once b.mu is released. The caller must hold b.mu.
+func (b *breakers) setState(key string, c *circuit, to BreakerState) func() {
+	from := c.state
+	c.state = to
+	c.failures = 0
+	c.successes = 0
+	c.inFlight = 0
+	if to == BreakerOpen {
+		c.openedAt = time.Now()
+	}
+
+	if b.config.OnStateChange == nil {
+		return nil
+	}
+	return func() {
+		b.config.OnStateChange(key, from, to)
+	}
+}
+
+// BreakerState returns the state of the circuit breaker guarding an API
+// module, such as "video". It reports BreakerClosed when no circuit breaker
+// is configured.
+func (c *Client) BreakerState(module string) BreakerState {
+	if c.breakers == nil {
+		return BreakerClosed
+	}
+	return c.breakers.state(breakerKey(c.baseURL, module))
+}
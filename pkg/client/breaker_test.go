@@ -0,0 +1,123 @@
+package client
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBreakerTransitions(t *testing.T) {
+	const key = "modelslab.com/video"
+	const coolDown = 20 * time.Millisecond
+
+	var transitions []string
+	b := newBreakers(&BreakerConfig{
+		FailureThreshold: 3,
+		CoolDown:         coolDown,
+		HalfOpenRequests: 2,
+		OnStateChange: func(k string, from, to BreakerState) {
+			if k != key {
+				t.Errorf("OnStateChange key = %q, want %q", k, key)
+			}
+			transitions = append(transitions, from.String()+" -> "+to.String())
+		},
+	})
+
+	send := func(success bool) {
+		t.Helper()
+		if err := b.allow(key); err != nil {
+			t.Fatalf("allow() = %v in state %v", err, b.state(key))
+		}
+		b.record(key, success)
+	}
+	expect := func(want BreakerState) {
+		t.Helper()
+		if got := b.state(key); got != want {
+			t.Fatalf("state = %v, want %v", got, want)
+		}
+	}
+
+	// A success resets the count of consecutive failures
+	send(false)
+	send(false)
+	send(true)
+	send(false)
+	send(false)
+	expect(BreakerClosed)
+	send(false)
+	expect(BreakerOpen)
+
+	if err := b.allow(key); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow() while open = %v, want ErrCircuitOpen", err)
+	}
+
+	// After the cool-down a failed trial opens the circuit again
+	time.Sleep(coolDown)
+	send(false)
+	expect(BreakerOpen)
+
+	// Only HalfOpenRequests trials are let through at a time
+	time.Sleep(coolDown)
+	if err := b.allow(key); err != nil {
+		t.Fatal(err)
+	}
+	expect(BreakerHalfOpen)
+	if err := b.allow(key); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.allow(key); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("allow() with all trials in flight = %v, want ErrCircuitOpen", err)
+	}
+
+	// An abandoned trial frees its slot without a verdict
+	b.abandon(key)
+	if err := b.allow(key); err != nil {
+		t.Fatalf("allow() after abandon = %v", err)
+	}
+	b.record(key, true)
+	expect(BreakerHalfOpen)
+	b.record(key, true)
+	expect(BreakerClosed)
+
+	want := []string{
+		"closed -> open",
+		"open -> half-open",
+		"half-open -> open",
+		"open -> half-open",
+		"half-open -> closed",
+	}
+	if !reflect.DeepEqual(transitions, want) {
+		t.Errorf("transitions = %q, want %q", transitions, want)
+	}
+}
+
+func TestBreakerKeys(t *testing.T) {
+	b := newBreakers(&BreakerConfig{FailureThreshold: 1, CoolDown: time.Hour})
+
+	video := breakerKey("https://modelslab.com/api/v6/video/text2video", "video")
+	images := breakerKey("https://modelslab.com/api/v6/images/text2img", "images")
+	if err := b.allow(video); err != nil {
+		t.Fatal(err)
+	}
+	b.record(video, false)
+
+	if err := b.allow(video); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("allow(video) = %v, want ErrCircuitOpen", err)
+	}
+	if err := b.allow(images); err != nil {
+		t.Errorf("allow(images) = %v, want the images circuit to stay closed", err)
+	}
+}
+
+func TestBreakerDisabled(t *testing.T) {
+	var b *breakers
+	if b = newBreakers(nil); b != nil {
+		t.Fatal("newBreakers(nil) is not nil")
+	}
+	if err := b.allow("any"); err != nil {
+		t.Errorf("allow() without a breaker = %v", err)
+	}
+	b.record("any", false)
+	b.abandon("any")
+}
@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ModuleURL returns the versioned base URL of an API module, such as
+// "https://modelslab.com/api/v6/images/" or, for enterprise users,
+// "https://modelslab.com/api/v1/enterprise/images/"
+func (c *Client) ModuleURL(module string, enterprise bool) string {
+	module = strings.Trim(module, "/")
+	if enterprise {
+		return c.baseURL + "v1/enterprise/" + module + "/"
+	}
+	return c.baseURL + "v6/" + module + "/"
+}
+
+// endpointURL resolves path against the versioned API root. Absolute URLs are
+// returned unchanged.
+func (c *Client) endpointURL(path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+
+	path = strings.TrimPrefix(path, "/")
+	module, rest, _ := strings.Cut(path, "/")
+	return c.ModuleURL(module, c.enterprise) + rest
+}
+
+// Do posts req to an endpoint the SDK does not wrap yet and decodes the
+// response into out. The path is relative to the versioned API root and
+// starts with the module, e.g. "images/text2img"; the v6 or enterprise prefix
+// is added according to the client configuration. The API key is injected
+// and struct requests are validated like any other call. out may be nil.
+func (c *Client) Do(ctx context.Context, path string, req, out interface{}) error {
+	resp, err := c.Post(ctx, c.endpointURL(path), addressable(req))
+	if err != nil {
+		return err
+	}
+
+	if out == nil {
+		return nil
+	}
+	return resp.Decode(out)
+}
+
+// Call posts req to path within module and decodes the response into a new
+// Resp. It is the typed counterpart of Client.Do:
+//
+//	resp, err := client.Call[MyRequest, MyResponse](ctx, c, "images", "new_endpoint", req)
+func Call[Req, Resp any](ctx context.Context, c *Client, module, path string, req Req) (*Resp, error) {
+	var out Resp
+	if err := c.Do(ctx, strings.Trim(module, "/")+"/"+strings.TrimPrefix(path, "/"), req, &out); err != nil {
+		return nil, fmt.Errorf("%s/%s request failed: %w", module, path, err)
+	}
+	return &out, nil
+}
+
+// addressable returns a pointer to struct values so that custom marshalers
+// with pointer receivers, such as base.FileInput, are used
+func addressable(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Struct {
+		return v
+	}
+
+	ptr := reflect.New(rv.Type())
+	ptr.Elem().Set(rv)
+	return ptr.Interface()
+}
+
+// isStruct reports whether v is a struct or a non-nil pointer to one
+func isStruct(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return false
+		}
+		rv = rv.Elem()
+	}
+	return rv.Kind() == reflect.Struct
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEndpointURL(t *testing.T) {
+	tests := []struct {
+		name       string
+		enterprise bool
+		path       string
+		want       string
+	}{
+		{name: "v6", path: "images/text2img", want: "https://modelslab.com/api/v6/images/text2img"},
+		{name: "leading slash", path: "/video/text2video", want: "https://modelslab.com/api/v6/video/text2video"},
+		{name: "enterprise", enterprise: true, path: "images/text2img", want: "https://modelslab.com/api/v1/enterprise/images/text2img"},
+		{name: "absolute", path: "https://cdn.example.com/api/v6/voice/fetch/42", want: "https://cdn.example.com/api/v6/voice/fetch/42"},
+		{name: "absolute enterprise", enterprise: true, path: "http://localhost:8080/custom", want: "http://localhost:8080/custom"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(WithAPIKey("test-key"), WithBaseURL("https://modelslab.com/api"), WithEnterprise(tt.enterprise))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.endpointURL(tt.path); got != tt.want {
+				t.Errorf("endpointURL(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+type callRequest struct {
+	Prompt string `json:"prompt" validate:"required"`
+}
+
+type callResponse struct {
+	Status string `json:"status"`
+	Seed   int64  `json:"seed"`
+}
+
+// callServer records the path and body of the last request and answers with
+// a finished response
+func callServer(t *testing.T) (*httptest.Server, *string, *map[string]interface{}) {
+	t.Helper()
+	path := new(string)
+	body := new(map[string]interface{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*path = r.URL.Path
+		data, _ := io.ReadAll(r.Body)
+		json.Unmarshal(data, body)
+		io.WriteString(w, `{"status": "success", "seed": 9007199254740993}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, path, body
+}
+
+func TestDo(t *testing.T) {
+	tests := []struct {
+		name       string
+		enterprise bool
+		path       string
+		wantPath   string
+	}{
+		{name: "v6", path: "images/new_endpoint", wantPath: "/v6/images/new_endpoint"},
+		{name: "enterprise", enterprise: true, path: "images/new_endpoint", wantPath: "/v1/enterprise/images/new_endpoint"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, path, body := callServer(t)
+			c, err := NewClient(WithAPIKey("test-key"), WithBaseURL(srv.URL), WithEnterprise(tt.enterprise))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var out callResponse
+			if err := c.Do(context.Background(), tt.path, callRequest{Prompt: "a cat"}, &out); err != nil {
+				t.Fatal(err)
+			}
+			if *path != tt.wantPath {
+				t.Errorf("posted to %s, want %s", *path, tt.wantPath)
+			}
+			if (*body)["key"] != "test-key" || (*body)["prompt"] != "a cat" {
+				t.Errorf("body = %v, want the prompt with the API key injected", *body)
+			}
+			if out.Status != "success" || out.Seed != 1<<53+1 {
+				t.Errorf("Do() decoded %+v, want the exact seed", out)
+			}
+		})
+	}
+}
+
+func TestDoAbsoluteURL(t *testing.T) {
+	srv, path, _ := callServer(t)
+	c, err := NewClient(WithAPIKey("test-key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Do(context.Background(), srv.URL+"/custom/path", nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if *path != "/custom/path" {
+		t.Errorf("posted to %s, want /custom/path", *path)
+	}
+}
+
+func TestDoValidation(t *testing.T) {
+	srv, path, _ := callServer(t)
+	c, err := NewClient(WithAPIKey("test-key"), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = c.Do(context.Background(), "images/new_endpoint", &callRequest{}, nil)
+	if !errors.Is(err, ErrValidation) {
+		t.Fatalf("Do() with a missing prompt = %v, want ErrValidation", err)
+	}
+	if *path != "" {
+		t.Errorf("invalid request was sent to %s", *path)
+	}
+}
+
+func TestCall(t *testing.T) {
+	srv, path, _ := callServer(t)
+	c, err := NewClient(WithAPIKey("test-key"), WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := Call[callRequest, callResponse](context.Background(), c, "/video/", "/new_endpoint", callRequest{Prompt: "a cat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *path != "/v6/video/new_endpoint" {
+		t.Errorf("posted to %s, want /v6/video/new_endpoint", *path)
+	}
+	if out.Status != "success" || out.Seed != 1<<53+1 {
+		t.Errorf("Call() = %+v, want the decoded response", out)
+	}
+
+	if _, err := Call[callRequest, callResponse](context.Background(), c, "video", "new_endpoint", callRequest{}); !errors.Is(err, ErrValidation) {
+		t.Errorf("Call() with a missing prompt = %v, want ErrValidation", err)
+	}
+}
@@ -5,23 +5,44 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/modelslab/modelslab-go/pkg/utils"
 )
 
+// DefaultUserAgent is the User-Agent header sent when none is configured
+const DefaultUserAgent = "modelslab-go"
+
 // Client represents the ModelsLab API client
 type Client struct {
 	apiKey       string
 	baseURL      string
+	enterprise   bool
+	userAgent    string
 	httpClient   *http.Client
 	fetchRetry   int
 	fetchTimeout time.Duration
+	retry        *RetryPolicy
+	logger       *slog.Logger
 	validator    *validator.Validate
+	limits       *limiter
+	moduleLimits map[string]*limiter
+	breakers     *breakers
+	uploads      *uploads
+
+	mu         sync.RWMutex
+	middleware []Middleware
 }
 
 // Config holds configuration options for the client
@@ -31,6 +52,37 @@ type Config struct {
 	FetchRetry   int           `validate:"min=1,max=100"`
 	FetchTimeout time.Duration `validate:"min=1s,max=300s"`
 	HTTPTimeout  time.Duration `validate:"min=1s,max=600s"`
+	// Retry controls retries of failed requests; DefaultRetryPolicy is used
+	// when nil
+	Retry *RetryPolicy
+	// Enterprise selects the enterprise endpoints
+	Enterprise bool
+	// HTTPClient replaces the default HTTP client; HTTPTimeout is ignored
+	// when it is set
+	HTTPClient *http.Client
+	// UserAgent is sent with every request; DefaultUserAgent is used when
+	// empty
+	UserAgent string
+	// Logger receives request logs; logging is disabled when nil
+	Logger *slog.Logger
+	// Middleware wraps every request, the first entry being the outermost
+	Middleware []Middleware
+	// RateLimit throttles all requests made by the client
+	RateLimit Limits
+	// ModuleLimits adds limits for individual API modules, keyed by module
+	// path such as "video", "3d" or "realtime". They apply on top of
+	// RateLimit.
+	ModuleLimits map[string]Limits `validate:"dive"`
+	// Breaker enables a circuit breaker per base URL and module path;
+	// it is disabled when nil
+	Breaker *BreakerConfig
+	// Uploader, when set, uploads local file inputs before each call and
+	// sends their URLs instead of inline base64
+	Uploader Uploader
+	// UploadCacheTTL is how long uploaded URLs are reused for identical
+	// contents. When zero, URLs are reused for half the uploader's URL
+	// expiry, or for the lifetime of the client if they do not expire.
+	UploadCacheTTL time.Duration `validate:"min=0"`
 }
 
 // DefaultConfig returns a default configuration
@@ -40,6 +92,7 @@ func DefaultConfig() *Config {
 		FetchRetry:   10,
 		FetchTimeout: 2 * time.Second,
 		HTTPTimeout:  30 * time.Second,
+		Retry:        DefaultRetryPolicy(),
 	}
 }
 
@@ -50,142 +103,452 @@ func New(apiKey string) *Client {
 	return NewWithConfig(config)
 }
 
-// NewWithConfig creates a new ModelsLab client with custom configuration
+// NewWithConfig creates a new ModelsLab client with custom configuration.
+// It panics when the configuration is invalid; use NewClient to get an error
+// instead.
 func NewWithConfig(config *Config) *Client {
+	c, err := newClient(*config)
+	if err != nil {
+		panic(err.Error())
+	}
+	return c
+}
+
+// NewClient creates a new ModelsLab client from DefaultConfig and the given
+// options. The API key falls back to the MODELSLAB_API_KEY environment
+// variable when no key is provided.
+func NewClient(opts ...Option) (*Client, error) {
+	config := DefaultConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+	return newClient(*config)
+}
+
+// newClient validates config and builds a client from it. The config is
+// passed by value so the caller's copy is never modified.
+func newClient(config Config) (*Client, error) {
 	if config.APIKey == "" {
 		config.APIKey = os.Getenv("MODELSLAB_API_KEY")
 	}
 
 	validate := validator.New()
-	if err := validate.Struct(config); err != nil {
-		panic(fmt.Sprintf("invalid client configuration: %v", err))
+	if err := validate.Struct(&config); err != nil {
+		return nil, fmt.Errorf("invalid client configuration: %w", err)
+	}
+	if err := checkLimits(config.RateLimit, config.ModuleLimits); err != nil {
+		return nil, fmt.Errorf("invalid client configuration: %w", err)
+	}
+
+	if !strings.HasSuffix(config.BaseURL, "/") {
+		config.BaseURL += "/"
+	}
+
+	retry := config.Retry
+	if retry == nil {
+		retry = DefaultRetryPolicy()
+	}
+
+	userAgent := config.UserAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout: config.HTTPTimeout,
+		}
+	}
+
+	moduleLimits := make(map[string]*limiter, len(config.ModuleLimits))
+	for module, limits := range config.ModuleLimits {
+		if lim := newLimiter(limits); lim != nil {
+			moduleLimits[module] = lim
+		}
 	}
 
 	return &Client{
 		apiKey:       config.APIKey,
 		baseURL:      config.BaseURL,
+		enterprise:   config.Enterprise,
+		userAgent:    userAgent,
+		httpClient:   httpClient,
 		fetchRetry:   config.FetchRetry,
 		fetchTimeout: config.FetchTimeout,
+		retry:        retry,
+		logger:       config.Logger,
 		validator:    validate,
-		httpClient: &http.Client{
-			Timeout: config.HTTPTimeout,
-		},
-	}
+		middleware:   append([]Middleware(nil), config.Middleware...),
+		limits:       newLimiter(config.RateLimit),
+		moduleLimits: moduleLimits,
+		breakers:     newBreakers(config.Breaker),
+		uploads:      newUploads(config.Uploader, config.UploadCacheTTL),
+	}, nil
 }
 
 // APIResponse represents a standard API response
-// Changed to map[string]interface{} to preserve all fields from API
+// Changed to map[string]interface{} to preserve all fields from API.
+// Numbers are decoded as json.Number so that seeds and ids keep their exact
+// value.
 type APIResponse map[string]interface{}
 
-// APIError represents an API error
-type APIError struct {
-	StatusCode int    `json:"status_code"`
-	Message    string `json:"message"`
-	Details    string `json:"details,omitempty"`
+// decodeJSON unmarshals data into v, keeping numbers as json.Number
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
 }
 
-func (e *APIError) Error() string {
-	if e.Details != "" {
-		return fmt.Sprintf("API error %d: %s - %s", e.StatusCode, e.Message, e.Details)
+// maxCoercions bounds how many mistyped fields Decode converts in one response
+const maxCoercions = 32
+
+// Decode decodes the response into a typed response struct. When v exposes a
+// SetRaw method (as every schema response does through base.Response), the
+// raw map is stored on it so unmodelled fields stay reachable. Numbers in
+// untyped fields decode as json.Number, like those in the raw map.
+//
+// The API is loose with JSON types, so numbers sent as strings and ids sent
+// as numbers are converted to the field's type. Any other mismatch leaves
+// that field unset instead of failing the call, since the generation has
+// already been paid for; the value is still available in the raw map.
+func (r *APIResponse) Decode(v interface{}) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal response: %w", err)
 	}
-	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Message)
-}
 
-func (c *Client) Post(ctx context.Context, endpoint string, data interface{}) (*APIResponse, error) {
-	if data != nil {
-		if err := c.validator.Struct(data); err != nil {
-			return nil, fmt.Errorf("validation error: %w", err)
+	var fields interface{}
+	for i := 0; ; i++ {
+		err := decodeJSON(data, v)
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			if err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+			break
+		}
+		if fields == nil {
+			if err := decodeJSON(data, &fields); err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+		}
+		if i == maxCoercions || !coerce(fields, strings.Split(typeErr.Field, "."), typeErr.Type.Kind()) {
+			break
+		}
+		if data, err = json.Marshal(fields); err != nil {
+			return fmt.Errorf("failed to marshal response: %w", err)
 		}
 	}
 
-	requestData := map[string]interface{}{
-		"key": c.apiKey,
+	if raw, ok := v.(interface{ SetRaw(map[string]interface{}) }); ok {
+		raw.SetRaw(*r)
 	}
 
-	if data != nil {
-		dataBytes, err := json.Marshal(data)
-		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request data: %w", err)
-		}
+	return nil
+}
 
-		var dataMap map[string]interface{}
-		if err := json.Unmarshal(dataBytes, &dataMap); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal request data: %w", err)
+// coerce repairs the value at path so that it decodes into kind. Numbers
+// become strings and numeric strings become numbers; other mismatched values
+// are dropped so the field is left unset. Arrays on the path apply to the
+// element named by the next path segment, or to every element when the path
+// does not name one. It reports whether anything changed.
+func coerce(v interface{}, path []string, kind reflect.Kind) bool {
+	switch val := v.(type) {
+	case []interface{}:
+		if len(path) > 0 {
+			if i, err := strconv.Atoi(path[0]); err == nil {
+				return i < len(val) && coerceAt(val, i, path[1:], kind)
+			}
 		}
-
-		for key, value := range dataMap {
-			requestData[key] = value
+		changed := false
+		for i := range val {
+			changed = coerceAt(val, i, path, kind) || changed
+		}
+		return changed
+	case map[string]interface{}:
+		if len(path) == 0 {
+			return false
+		}
+		if len(path) > 1 {
+			return coerce(val[path[0]], path[1:], kind)
+		}
+		field, ok := val[path[0]]
+		if !ok {
+			return false
+		}
+		if elems, ok := field.([]interface{}); ok && kind != reflect.Slice && kind != reflect.Array {
+			return coerce(elems, nil, kind)
+		}
+		if conv, ok := coerceValue(field, kind); ok {
+			val[path[0]] = conv
+			return true
+		}
+		if !matchesKind(field, kind) {
+			delete(val, path[0])
+			return true
 		}
 	}
+	return false
+}
 
-	jsonData, err := json.Marshal(requestData)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+// coerceAt repairs element i of arr, or a value below it when path is not
+// empty
+func coerceAt(arr []interface{}, i int, path []string, kind reflect.Kind) bool {
+	if len(path) > 0 {
+		return coerce(arr[i], path, kind)
+	}
+	if conv, ok := coerceValue(arr[i], kind); ok {
+		arr[i] = conv
+		return true
+	}
+	if arr[i] != nil && !matchesKind(arr[i], kind) {
+		arr[i] = nil
+		return true
 	}
+	return false
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// coerceValue converts a single JSON value to kind
+func coerceValue(v interface{}, kind reflect.Kind) (interface{}, bool) {
+	switch kind {
+	case reflect.String:
+		if num, ok := v.(json.Number); ok {
+			return num.String(), true
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		str, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		str = strings.TrimSpace(str)
+		var num float64
+		if err := json.Unmarshal([]byte(str), &num); err != nil {
+			return nil, false
+		}
+		return json.Number(str), true
 	}
+	return nil, false
+}
 
-	req.Header.Set("Content-Type", "application/json")
+// matchesKind reports whether a decoded JSON value has the shape of kind
+func matchesKind(v interface{}, kind reflect.Kind) bool {
+	switch v.(type) {
+	case string:
+		return kind == reflect.String
+	case json.Number:
+		return kind >= reflect.Int && kind <= reflect.Float64
+	case bool:
+		return kind == reflect.Bool
+	case []interface{}:
+		return kind == reflect.Slice || kind == reflect.Array
+	case map[string]interface{}:
+		return kind == reflect.Map || kind == reflect.Struct
+	}
+	return v == nil
+}
 
-	resp, err := c.httpClient.Do(req)
+// Post sends data to endpoint through the client's middleware chain and
+// returns the decoded response. When an Uploader is configured, local file
+// inputs in data are uploaded first and a copy of data referring to their URLs
+// is sent; data itself is not changed.
+func (c *Client) Post(ctx context.Context, endpoint string, data interface{}) (*APIResponse, error) {
+	data, err := c.uploaded(ctx, data)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	req := &Request{
+		Endpoint: endpoint,
+		Module:   c.moduleOf(endpoint),
+		Data:     data,
+		Header:   make(http.Header),
+		client:   c,
+		readers:  &bodyReaders{},
+	}
+	defer req.readers.close()
+
+	resp, err := c.handler()(ctx, req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, &APIError{
-			StatusCode: resp.StatusCode,
-			Message:    "Request failed",
-			Details:    string(body),
+	return &resp.Data, nil
+}
+
+// do is the innermost handler: it validates and encodes the request, sends it
+// and parses the response
+func (c *Client) do(ctx context.Context, req *Request) (resp *Response, err error) {
+	start := time.Now()
+	c.logStart(ctx, req)
+	defer func() {
+		c.logFinish(ctx, req, resp, err, start)
+	}()
+
+	if isStruct(req.Data) {
+		if err := c.validator.Struct(req.Data); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrValidation, err)
 		}
 	}
 
-	var apiResp APIResponse
-	if err := json.Unmarshal(body, &apiResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
+	resp, err = c.send(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	if err := decodeJSON(resp.Body, &resp.Data); err != nil {
+		return resp, fmt.Errorf("failed to parse response: %w", err)
 	}
 
-	return &apiResp, nil
+	return resp, nil
 }
 
-// Fetch performs a fetch operation with retry logic
+// send posts the request body to the endpoint, retrying transient failures
+// according to the call's retry policy. Unless middleware materialized the
+// body, every attempt encodes Data afresh straight into the connection.
+// Failed responses, including in-body errors, are returned as *APIError
+// together with the response.
+func (c *Client) send(ctx context.Context, r *Request) (*Response, error) {
+	policy := c.retryPolicy(ctx)
+
+	for attempt := 1; ; attempt++ {
+		body, encodeErr := r.open()
+		req, err := http.NewRequestWithContext(ctx, "POST", r.Endpoint, body)
+		if err != nil {
+			body.Close()
+			encodeErr()
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if r.body != nil {
+			req.ContentLength = int64(len(r.body))
+		}
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, _ := r.open()
+			return body, nil
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", c.userAgent)
+		for key, values := range r.Header {
+			req.Header[key] = append([]string(nil), values...)
+		}
+
+		// An open circuit fails fast instead of waiting for capacity
+		breakerKey := breakerKey(r.Endpoint, r.Module)
+		if err := c.breakers.allow(breakerKey); err != nil {
+			body.Close()
+			encodeErr()
+			return nil, fmt.Errorf("%w: %s", err, breakerKey)
+		}
+
+		release, err := c.acquireLimits(ctx, r.Module)
+		if err != nil {
+			c.breakers.abandon(breakerKey)
+			body.Close()
+			encodeErr()
+			return nil, err
+		}
+
+		var retryAfter time.Duration
+		var reason string
+		resp, err := c.httpClient.Do(req)
+		body.Close()
+		if encErr := encodeErr(); encErr != nil {
+			if err == nil {
+				resp.Body.Close()
+			}
+			release()
+			c.breakers.abandon(breakerKey)
+			return nil, encErr
+		}
+		if err != nil {
+			release()
+			if ctx.Err() != nil {
+				c.breakers.abandon(breakerKey)
+			} else {
+				c.breakers.record(breakerKey, false)
+			}
+			if attempt >= policy.MaxAttempts || !policy.retryableError(ctx, err) {
+				return nil, fmt.Errorf("request failed: %w", err)
+			}
+			reason = err.Error()
+		} else {
+			body, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			release()
+			if err != nil {
+				c.breakers.abandon(breakerKey)
+				return nil, fmt.Errorf("failed to read response: %w", err)
+			}
+
+			result := &Response{
+				StatusCode: resp.StatusCode,
+				Header:     resp.Header,
+				Body:       body,
+			}
+
+			err = checkResponse(r.Endpoint, resp.StatusCode, body)
+			if err == nil {
+				c.breakers.record(breakerKey, true)
+				return result, nil
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				c.breakers.abandon(breakerKey)
+				return result, err
+			}
+			c.breakers.record(breakerKey, !apiErr.serverFailure())
+			if attempt >= policy.MaxAttempts || !policy.retryableStatus(apiErr.retryStatus()) {
+				return result, apiErr
+			}
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			reason = fmt.Sprintf("status %d: %s", apiErr.StatusCode, apiErr.Message)
+		}
+
+		delay := policy.backoff(attempt, retryAfter)
+		c.logRetry(ctx, r, attempt, delay, reason)
+		if err := utils.SleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Fetch performs a fetch operation with retry logic. It returns once the
+// status is success; any other status, including ones it does not know, is
+// polled again up to the fetch retry count. Failed jobs are reported by the
+// API as errors and returned immediately unless the error is retryable.
 func (c *Client) Fetch(ctx context.Context, endpoint, id string) (*APIResponse, error) {
 	fetchURL := fmt.Sprintf("%sfetch/%s", endpoint, id)
 
 	var lastErr error
 	for i := 0; i < c.fetchRetry; i++ {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
-
-		resp, err := c.Post(ctx, fetchURL, map[string]string{"key": c.apiKey})
+		resp, err := c.Post(ctx, fetchURL, nil)
 		if err != nil {
+			var apiErr *APIError
+			if errors.As(err, &apiErr) && !apiErr.Retryable {
+				return nil, fmt.Errorf("fetch failed: %w", err)
+			}
+
 			lastErr = err
-			time.Sleep(c.fetchTimeout)
+			if err := utils.SleepContext(ctx, c.fetchTimeout); err != nil {
+				return nil, err
+			}
 			continue
 		}
 
-		if status, ok := (*resp)["status"].(string); ok && status == "success" {
+		status, _ := (*resp)["status"].(string)
+		message, _ := (*resp)["message"].(string)
+		if status == "success" {
 			return resp, nil
 		}
 
-		message := ""
-		if msg, ok := (*resp)["message"].(string); ok {
-			message = msg
+		lastErr = fmt.Errorf("fetch incomplete: status %q %s", status, message)
+		if err := utils.SleepContext(ctx, c.fetchTimeout); err != nil {
+			return nil, err
 		}
-		lastErr = fmt.Errorf("fetch failed: %s", message)
-		time.Sleep(c.fetchTimeout)
 	}
 
 	return nil, fmt.Errorf("fetch failed after %d retries: %w", c.fetchRetry, lastErr)
@@ -201,6 +564,21 @@ func (c *Client) GetBaseURL() string {
 	return c.baseURL
 }
 
+// IsEnterprise returns whether the client targets the enterprise endpoints
+func (c *Client) IsEnterprise() bool {
+	return c.enterprise
+}
+
+// GetFetchRetry returns the number of fetch attempts
+func (c *Client) GetFetchRetry() int {
+	return c.fetchRetry
+}
+
+// GetFetchTimeout returns the delay between fetch attempts
+func (c *Client) GetFetchTimeout() time.Duration {
+	return c.fetchTimeout
+}
+
 // SetHTTPClient allows setting a custom HTTP client
 func (c *Client) SetHTTPClient(client *http.Client) {
 	c.httpClient = client
@@ -0,0 +1,171 @@
+package client
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/modelslab/modelslab-go/pkg/schemas/audio"
+	"github.com/modelslab/modelslab-go/pkg/schemas/base"
+	"github.com/modelslab/modelslab-go/pkg/schemas/community"
+	"github.com/modelslab/modelslab-go/pkg/schemas/deepfake"
+	"github.com/modelslab/modelslab-go/pkg/schemas/image_editing"
+	"github.com/modelslab/modelslab-go/pkg/schemas/interior"
+	"github.com/modelslab/modelslab-go/pkg/schemas/realtime"
+	"github.com/modelslab/modelslab-go/pkg/schemas/threed"
+	"github.com/modelslab/modelslab-go/pkg/schemas/video"
+)
+
+func TestDecodeTypedResponses(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		got     interface{ SetRaw(map[string]interface{}) }
+		want    interface{}
+	}{
+		{
+			name:    "community image",
+			payload: `{"status":"success","generationTime":"1.52","id":118725623,"output":["https://pub.example.com/generations/a.png"],"proxy_links":["https://cdn.example.com/a.png"],"seed":"2837492"}`,
+			got:     &community.ImageResponse{},
+			want: &community.ImageResponse{
+				Response: base.Response{Status: "success", GenerationTime: 1.52, ID: "118725623",
+					Output: []string{"https://pub.example.com/generations/a.png"}, ProxyLinks: []string{"https://cdn.example.com/a.png"}},
+				Seed: 2837492,
+			},
+		},
+		{
+			name:    "audio processing",
+			payload: `{"status":"processing","tip":"queued","eta":"14","id":"79402","fetch_result":"https://modelslab.com/api/v6/voice/fetch/79402","future_links":["https://pub.example.com/79402.wav"],"message":"Try to fetch request after given estimated time"}`,
+			got:     &audio.AudioResponse{},
+			want: &audio.AudioResponse{Response: base.Response{
+				Status: "processing", ETA: 14, ID: "79402", FetchResult: "https://modelslab.com/api/v6/voice/fetch/79402",
+				FutureLinks: []string{"https://pub.example.com/79402.wav"}, Message: "Try to fetch request after given estimated time",
+			}},
+		},
+		{
+			name:    "transcription",
+			payload: `{"status":"success","id":5521,"transcription":"hello there","timestamps":[{"text":"hello","start_time":"0","end_time":"0.42"},{"text":"there","start_time":0.5,"end_time":"0.9"}]}`,
+			got:     &audio.TranscriptionResponse{},
+			want: &audio.TranscriptionResponse{
+				Response:      base.Response{Status: "success", ID: "5521"},
+				Transcription: "hello there",
+				Timestamps: []audio.TranscriptionSegment{
+					{Text: "hello", StartTime: 0, EndTime: 0.42},
+					{Text: "there", StartTime: 0.5, EndTime: 0.9},
+				},
+			},
+		},
+		{
+			name:    "realtime",
+			payload: `{"status":"success","id":"301","images":["https://pub.example.com/r.png"],"process_time":"3"}`,
+			got:     &realtime.RealtimeResponse{},
+			want: &realtime.RealtimeResponse{
+				Response: base.Response{Status: "success", ID: "301"},
+				Images:   []string{"https://pub.example.com/r.png"}, ProcessTime: 3,
+			},
+		},
+		{
+			name:    "deepfake",
+			payload: `{"status":"success","id":302,"result_url":"https://pub.example.com/d.mp4","process_time":" 12 "}`,
+			got:     &deepfake.DeepFakeResponse{},
+			want: &deepfake.DeepFakeResponse{
+				Response:  base.Response{Status: "success", ID: "302"},
+				ResultURL: "https://pub.example.com/d.mp4", ProcessTime: 12,
+			},
+		},
+		{
+			name:    "interior",
+			payload: `{"status":"success","id":303,"result_url":"https://pub.example.com/i.png","process_time":4}`,
+			got:     &interior.InteriorResponse{},
+			want: &interior.InteriorResponse{
+				Response:  base.Response{Status: "success", ID: "303"},
+				ResultURL: "https://pub.example.com/i.png", ProcessTime: 4,
+			},
+		},
+		{
+			name:    "image editing",
+			payload: `{"status":"success","id":"304","images":["https://pub.example.com/e.png"],"process_time":"2"}`,
+			got:     &image_editing.ImageEditingResponse{},
+			want: &image_editing.ImageEditingResponse{
+				Response: base.Response{Status: "success", ID: "304"},
+				Images:   []string{"https://pub.example.com/e.png"}, ProcessTime: 2,
+			},
+		},
+		{
+			name:    "3d",
+			payload: `{"status":"success","id":305,"model_url":"https://pub.example.com/m.glb","meshes":["https://pub.example.com/m.obj"],"process_time":"40"}`,
+			got:     &threed.ThreeDResponse{},
+			want: &threed.ThreeDResponse{
+				Response: base.Response{Status: "success", ID: "305"},
+				ModelURL: "https://pub.example.com/m.glb", Meshes: []string{"https://pub.example.com/m.obj"}, ProcessTime: 40,
+			},
+		},
+		{
+			name:    "video",
+			payload: `{"status":"success","id":306,"video_url":"https://pub.example.com/v.mp4","duration":"5","fps":24,"width":"512","height":"768"}`,
+			got:     &video.VideoResponse{},
+			want: &video.VideoResponse{
+				Response: base.Response{Status: "success", ID: "306"},
+				VideoURL: "https://pub.example.com/v.mp4", Duration: 5, FPS: 24, Width: 512, Height: 768,
+			},
+		},
+		{
+			name:    "mismatched field",
+			payload: `{"status":"success","id":307,"output":"https://pub.example.com/single.png","eta":"soon"}`,
+			got:     &base.Response{},
+			want:    &base.Response{Status: "success", ID: "307"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp APIResponse
+			if err := decodeJSON([]byte(tt.payload), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if err := resp.Decode(tt.got); err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			raw := reflect.ValueOf(tt.got).Elem().FieldByName("Raw")
+			if raw.Len() != len(resp) {
+				t.Errorf("Raw has %d fields, want %d", raw.Len(), len(resp))
+			}
+			tt.got.SetRaw(nil)
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("Decode() = %+v, want %+v", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeKeepsLargeSeeds(t *testing.T) {
+	const seed int64 = 1<<53 + 1
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{name: "numbers", payload: `{"status":"success","seed":9007199254740993,"meta":{"seed":9007199254740993},"data":{"seed":9007199254740993}}`},
+		{name: "strings", payload: `{"status":"success","seed":"9007199254740993","meta":{"seed":"9007199254740993"},"data":{"seed":9007199254740993}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp APIResponse
+			if err := decodeJSON([]byte(tt.payload), &resp); err != nil {
+				t.Fatal(err)
+			}
+			var out community.ImageResponse
+			if err := resp.Decode(&out); err != nil {
+				t.Fatal(err)
+			}
+			if out.Seed != seed {
+				t.Errorf("ImageResponse.Seed = %d, want %d", out.Seed, seed)
+			}
+			if got := out.Meta.Seed(); got == nil || *got != seed {
+				t.Errorf("Meta.Seed() = %v, want %d", got, seed)
+			}
+			data, _ := out.Data.(map[string]interface{})
+			if got, _ := data["seed"].(json.Number); got.String() != "9007199254740993" {
+				t.Errorf("Data seed = %v, want 9007199254740993", data["seed"])
+			}
+		})
+	}
+}
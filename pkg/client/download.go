@@ -0,0 +1,59 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/utils"
+)
+
+// Download fetches a generated file, such as a link from a response's
+// output, using the client's HTTP client and retry policy. Generated files
+// are often published shortly after the job reports success, so a 404 is
+// retried as well.
+func (c *Client) Download(ctx context.Context, url string) ([]byte, error) {
+	policy := c.retryPolicy(ctx)
+
+	for attempt := 1; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create download request: %w", err)
+		}
+		req.Header.Set("User-Agent", c.userAgent)
+
+		var retryAfter time.Duration
+		var reason string
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if attempt >= policy.MaxAttempts || !policy.retryableError(ctx, err) {
+				return nil, fmt.Errorf("download failed: %w", err)
+			}
+			reason = err.Error()
+		} else {
+			data, err := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read download: %w", err)
+			}
+			if resp.StatusCode == http.StatusOK {
+				return data, nil
+			}
+
+			retryable := resp.StatusCode == http.StatusNotFound || policy.retryableStatus(resp.StatusCode)
+			if attempt >= policy.MaxAttempts || !retryable {
+				return nil, fmt.Errorf("download of %s failed with status %d", url, resp.StatusCode)
+			}
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+			reason = fmt.Sprintf("download status %d", resp.StatusCode)
+		}
+
+		delay := policy.backoff(attempt, retryAfter)
+		c.logRetry(ctx, &Request{Endpoint: url, Module: "download"}, attempt, delay, reason)
+		if err := utils.SleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
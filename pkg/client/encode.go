@@ -0,0 +1,537 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/modelslab/modelslab-go/pkg/utils"
+)
+
+// JSONStreamer is implemented by request values that can write their JSON
+// encoding directly to the request body. The client prefers it over
+// json.Marshaler so that large payloads, such as base64 files, are never
+// buffered in memory.
+type JSONStreamer interface {
+	WriteJSON(w io.Writer) error
+}
+
+// ReaderStreamer is implemented by JSONStreamer values that stream a reader
+// owned by the caller, such as a base.FileInput built from an io.Reader. The
+// client passes WriteJSONFrom the reader to send on each attempt, so that a
+// retried request sends the same contents again.
+type ReaderStreamer interface {
+	JSONStreamer
+	// StreamReader returns the reader whose contents are written, or nil
+	StreamReader() io.Reader
+	// WriteJSONFrom writes the JSON encoding with the contents read from r
+	WriteJSONFrom(w io.Writer, r io.Reader) error
+}
+
+// bodyBufferSize is the size of the buffer between the encoder and the
+// request body
+const bodyBufferSize = 32 * 1024
+
+var (
+	jsonStreamerType = reflect.TypeOf((*JSONStreamer)(nil)).Elem()
+	marshalerType    = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// writeBody writes data as a JSON object with the API key injected. Top-level
+// fields are encoded one at a time, straight to w, so the request is encoded
+// in a single pass and large string fields are never copied. Readers are
+// taken from readers, which may be nil when data is encoded only once.
+func (c *Client) writeBody(w io.Writer, data interface{}, readers *bodyReaders) error {
+	bw := bufio.NewWriterSize(w, bodyBufferSize)
+	enc := &objectEncoder{w: bw, readers: readers}
+
+	if err := enc.encode(c.apiKey, data); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// encode returns the JSON request body for data with the API key injected
+func (c *Client) encode(data interface{}, readers *bodyReaders) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := c.writeBody(&buf, data, readers); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// objectEncoder writes the members of a single JSON object
+type objectEncoder struct {
+	w       *bufio.Writer
+	readers *bodyReaders
+	members int
+}
+
+// encode writes data as an object, injecting apiKey unless data has its own
+// "key" member
+func (e *objectEncoder) encode(apiKey string, data interface{}) error {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			v = reflect.Value{}
+			break
+		}
+		v = v.Elem()
+	}
+
+	if err := e.w.WriteByte('{'); err != nil {
+		return err
+	}
+
+	switch {
+	case !v.IsValid():
+		if err := e.member("key", reflect.ValueOf(apiKey)); err != nil {
+			return err
+		}
+	case v.Kind() == reflect.Struct && !implementsAny(v):
+		if err := e.structMembers(apiKey, v); err != nil {
+			return err
+		}
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		if err := e.mapMembers(apiKey, v); err != nil {
+			return err
+		}
+	default:
+		if err := e.splice(apiKey, data); err != nil {
+			return err
+		}
+	}
+
+	return e.w.WriteByte('}')
+}
+
+// structMembers writes the fields of a struct. The API key is injected unless
+// a "key" field is written, as a "key" field left out by omitempty does not
+// replace it.
+func (e *objectEncoder) structMembers(apiKey string, v reflect.Value) error {
+	fields := cachedFields(v.Type())
+
+	hasKey := false
+	for _, f := range fields {
+		if f.name == "key" {
+			_, hasKey = fieldValue(v, f)
+		}
+	}
+	if !hasKey {
+		if err := e.member("key", reflect.ValueOf(apiKey)); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range fields {
+		fv, ok := fieldValue(v, f)
+		if !ok {
+			continue
+		}
+		if f.quoted {
+			if err := e.quotedMember(f.name, fv); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := e.member(f.name, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldValue returns the value of field f of v, reporting false when it is
+// not written: when it is reached through a nil embedded pointer or omitted
+// as empty
+func fieldValue(v reflect.Value, f field) (reflect.Value, bool) {
+	fv, ok := fieldByIndex(v, f.index)
+	if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+		return reflect.Value{}, false
+	}
+	return fv, true
+}
+
+// mapMembers writes the entries of a map with string keys in sorted order,
+// like encoding/json
+func (e *objectEncoder) mapMembers(apiKey string, v reflect.Value) error {
+	keys := make([]string, 0, v.Len())
+	values := make(map[string]reflect.Value, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key := iter.Key().String()
+		keys = append(keys, key)
+		values[key] = iter.Value()
+	}
+	sort.Strings(keys)
+
+	if _, ok := values["key"]; !ok {
+		if err := e.member("key", reflect.ValueOf(apiKey)); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range keys {
+		if err := e.member(key, values[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splice handles values with a custom marshaler by marshaling them and
+// merging the resulting object
+func (e *objectEncoder) splice(apiKey string, data interface{}) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request data: %w", err)
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &members); err != nil {
+		return fmt.Errorf("request data must encode to a JSON object: %w", err)
+	}
+	return e.mapMembers(apiKey, reflect.ValueOf(members))
+}
+
+// member writes a single "name":value member
+func (e *objectEncoder) member(name string, v reflect.Value) error {
+	if err := e.name(name); err != nil {
+		return err
+	}
+	return e.value(v)
+}
+
+// quotedMember writes a member declared with the ",string" tag option. Nil
+// pointers are written as null, like encoding/json does.
+func (e *objectEncoder) quotedMember(name string, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		if err := e.name(name); err != nil {
+			return err
+		}
+		_, err := e.w.WriteString("null")
+		return err
+	}
+
+	raw, err := json.Marshal(v.Interface())
+	if err != nil {
+		return fmt.Errorf("failed to marshal field %s: %w", name, err)
+	}
+	if err := e.name(name); err != nil {
+		return err
+	}
+	return utils.WriteJSONString(e.w, string(raw))
+}
+
+// name writes the separator and member name
+func (e *objectEncoder) name(name string) error {
+	if e.members > 0 {
+		if err := e.w.WriteByte(','); err != nil {
+			return err
+		}
+	}
+	e.members++
+
+	if err := utils.WriteJSONString(e.w, name); err != nil {
+		return err
+	}
+	return e.w.WriteByte(':')
+}
+
+// value writes a member value, streaming it when possible
+func (e *objectEncoder) value(v reflect.Value) error {
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+
+	if streamer, ok := asStreamer(v); ok {
+		if rs, ok := streamer.(ReaderStreamer); ok && rs.StreamReader() != nil {
+			r, err := e.readers.reader(rs.StreamReader())
+			if err != nil {
+				return err
+			}
+			return rs.WriteJSONFrom(e.w, r)
+		}
+		return streamer.WriteJSON(e.w)
+	}
+
+	if v.Kind() == reflect.String && !implementsAny(v) {
+		return utils.WriteJSONString(e.w, v.String())
+	}
+	if v.Kind() == reflect.Ptr && !v.IsNil() && v.Elem().Kind() == reflect.String && !implementsAny(v) {
+		return utils.WriteJSONString(e.w, v.Elem().String())
+	}
+
+	target := v.Interface()
+	if v.CanAddr() {
+		target = v.Addr().Interface()
+	}
+	raw, err := json.Marshal(target)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request data: %w", err)
+	}
+	_, err = e.w.Write(raw)
+	return err
+}
+
+// asStreamer returns the JSONStreamer implemented by v or its address
+func asStreamer(v reflect.Value) (JSONStreamer, bool) {
+	if v.Kind() == reflect.Ptr && v.IsNil() {
+		return nil, false
+	}
+	if v.Type().Implements(jsonStreamerType) {
+		return v.Interface().(JSONStreamer), true
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(jsonStreamerType) {
+		return v.Addr().Interface().(JSONStreamer), true
+	}
+	return nil, false
+}
+
+// implementsAny reports whether v or its address has a custom JSON encoding
+func implementsAny(v reflect.Value) bool {
+	t := v.Type()
+	pt := reflect.PointerTo(t)
+	return t.Implements(marshalerType) || pt.Implements(marshalerType) ||
+		t.Implements(jsonStreamerType) || pt.Implements(jsonStreamerType)
+}
+
+// field describes an encoded struct field
+type field struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	quoted    bool
+	tagged    bool
+}
+
+var fieldCache sync.Map // map[reflect.Type][]field
+
+// cachedFields returns the encoded fields of a struct type
+func cachedFields(t reflect.Type) []field {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]field)
+	}
+	fields, _ := fieldCache.LoadOrStore(t, typeFields(t))
+	return fields.([]field)
+}
+
+// typeFields lists the fields encoding/json would encode for a struct type,
+// flattening embedded structs and applying its dominance rules
+func typeFields(t reflect.Type) []field {
+	type candidate struct {
+		field
+		depth int
+		order int
+	}
+
+	var candidates []candidate
+	var walk func(t reflect.Type, index []int, depth int, visited map[reflect.Type]bool)
+	walk = func(t reflect.Type, index []int, depth int, visited map[reflect.Type]bool) {
+		if visited[t] {
+			return
+		}
+		visited[t] = true
+
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			tag := sf.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+
+			name, opts, _ := strings.Cut(tag, ",")
+			fieldIndex := append(append([]int(nil), index...), i)
+
+			ft := sf.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if sf.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				walk(ft, fieldIndex, depth+1, visited)
+				continue
+			}
+			if !sf.IsExported() {
+				continue
+			}
+
+			f := field{
+				name:      name,
+				index:     fieldIndex,
+				omitEmpty: hasOption(opts, "omitempty"),
+				quoted:    hasOption(opts, "string") && quotable(ft),
+				tagged:    name != "",
+			}
+			if f.name == "" {
+				f.name = sf.Name
+			}
+			candidates = append(candidates, candidate{field: f, depth: depth, order: len(candidates)})
+		}
+		delete(visited, t)
+	}
+	walk(t, nil, 0, map[reflect.Type]bool{})
+
+	byName := make(map[string][]candidate)
+	for _, c := range candidates {
+		byName[c.name] = append(byName[c.name], c)
+	}
+
+	var dominant []candidate
+	for _, group := range byName {
+		sort.SliceStable(group, func(i, j int) bool { return group[i].depth < group[j].depth })
+		best := group[:1]
+		for _, c := range group[1:] {
+			if c.depth == best[0].depth {
+				best = append(best, c)
+			}
+		}
+
+		if len(best) == 1 {
+			dominant = append(dominant, best[0])
+			continue
+		}
+
+		var tagged []candidate
+		for _, c := range best {
+			if c.tagged {
+				tagged = append(tagged, c)
+			}
+		}
+		if len(tagged) == 1 {
+			dominant = append(dominant, tagged[0])
+		}
+	}
+
+	sort.Slice(dominant, func(i, j int) bool { return dominant[i].order < dominant[j].order })
+	fields := make([]field, len(dominant))
+	for i, c := range dominant {
+		fields[i] = c.field
+	}
+	return fields
+}
+
+// hasOption reports whether a comma separated tag option list contains opt
+func hasOption(opts, opt string) bool {
+	for opts != "" {
+		var o string
+		o, opts, _ = strings.Cut(opts, ",")
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// quotable reports whether the ",string" option applies to a type
+func quotable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// fieldByIndex returns the field at index, reporting false when it is
+// reached through a nil embedded pointer
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// isEmptyValue reports whether v is empty for the omitempty option
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// bodyReaders keeps the caller's readers of one request so that every attempt
+// sends the same contents. Seekable readers are rewound to where they stood
+// on the first attempt; other readers are copied to a temporary file when
+// first sent. A request owns its bodyReaders, so no state is kept on the
+// caller's FileInput.
+type bodyReaders struct {
+	mu      sync.Mutex
+	sources map[io.Reader]*bodyReader
+}
+
+// bodyReader is a reader as sent on the first attempt
+type bodyReader struct {
+	src     io.ReadSeeker
+	start   int64
+	cleanup func()
+}
+
+// reader returns r positioned where it stood on the first attempt. Readers
+// whose type cannot be a map key are returned as they are.
+func (b *bodyReaders) reader(r io.Reader) (io.Reader, error) {
+	if b == nil || !reflect.TypeOf(r).Comparable() {
+		return r, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if br, ok := b.sources[r]; ok {
+		if _, err := br.src.Seek(br.start, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to rewind file input: %w", err)
+		}
+		return br.src, nil
+	}
+
+	src, cleanup, err := utils.Seekable(r)
+	if err != nil {
+		return nil, err
+	}
+	start, err := src.Seek(0, io.SeekCurrent)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to seek file input: %w", err)
+	}
+	if b.sources == nil {
+		b.sources = make(map[io.Reader]*bodyReader)
+	}
+	b.sources[r] = &bodyReader{src: src, start: start, cleanup: cleanup}
+	return src, nil
+}
+
+// close leaves seekable readers where they stood before the request and
+// removes the temporary copies of the others
+func (b *bodyReaders) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, br := range b.sources {
+		br.src.Seek(br.start, io.SeekStart)
+		br.cleanup()
+	}
+	b.sources = nil
+}
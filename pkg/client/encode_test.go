@@ -0,0 +1,370 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/modelslab/modelslab-go/pkg/schemas/base"
+	"github.com/modelslab/modelslab-go/pkg/schemas/community"
+	"github.com/modelslab/modelslab-go/pkg/schemas/deepfake"
+)
+
+// legacyEncode is the former request encoding path, which marshaled the
+// request, decoded it into a map, merged the key and marshaled it again
+func legacyEncode(apiKey string, data interface{}) ([]byte, error) {
+	requestData := map[string]interface{}{
+		"key": apiKey,
+	}
+
+	dataBytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var dataMap map[string]interface{}
+	if err := decodeJSON(dataBytes, &dataMap); err != nil {
+		return nil, err
+	}
+	for key, value := range dataMap {
+		requestData[key] = value
+	}
+
+	return json.Marshal(requestData)
+}
+
+// payload returns a base64 string encoding size bytes
+func payload(size int) *string {
+	s := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("\x89PNG", size/4)))
+	return &s
+}
+
+func image2ImageRequest() *community.Image2ImageRequest {
+	modelID := "realistic-vision-v51"
+	width, height, steps := 512, 512, 30
+	seed := int64(1234567890123)
+	return &community.Image2ImageRequest{
+		Prompt:            "a watercolor painting of a lighthouse at dusk",
+		ModelID:           &modelID,
+		InitImage:         &base.FileInput{Base64: payload(3 << 20)},
+		Width:             &width,
+		Height:            &height,
+		NumInferenceSteps: &steps,
+		Seed:              &seed,
+	}
+}
+
+func singleVideoSwapRequest() *deepfake.SingleVideoSwapRequest {
+	format := "mp4"
+	return &deepfake.SingleVideoSwapRequest{
+		InitImage:    base.FileInput{Base64: payload(1 << 20)},
+		InitVideo:    base.FileInput{Base64: payload(15 << 20)},
+		OutputFormat: &format,
+	}
+}
+
+func benchmarkLegacy(b *testing.B, data interface{}) {
+	body, err := legacyEncode("test-key", data)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := legacyEncode("test-key", data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkStream(b *testing.B, data interface{}) {
+	c := New("test-key")
+	body, err := c.encode(data, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if err := c.writeBody(io.Discard, data, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeImage2ImageLegacy(b *testing.B) {
+	benchmarkLegacy(b, image2ImageRequest())
+}
+
+func BenchmarkEncodeImage2ImageStream(b *testing.B) {
+	benchmarkStream(b, image2ImageRequest())
+}
+
+func BenchmarkEncodeSingleVideoSwapLegacy(b *testing.B) {
+	benchmarkLegacy(b, singleVideoSwapRequest())
+}
+
+func BenchmarkEncodeSingleVideoSwapStream(b *testing.B) {
+	benchmarkStream(b, singleVideoSwapRequest())
+}
+
+// Types exercising the encoding/json rules the streaming encoder reimplements
+
+type encodeInner struct {
+	Shared string `json:"shared"`
+	Deep   string `json:"deep,omitempty"`
+	Hidden string
+}
+
+type encodeTagged struct {
+	Name string `json:"name"`
+}
+
+type encodeUntagged struct {
+	Name string
+}
+
+type encodeConflictA struct {
+	Clash string
+}
+
+type encodeConflictB struct {
+	Clash string
+}
+
+type encodeEmbedded struct {
+	encodeInner
+	*encodeTagged
+	encodeUntagged
+	encodeConflictA
+	encodeConflictB
+	Shared string `json:"shared"`
+	hidden string
+}
+
+type encodeUpper string
+
+func (u encodeUpper) MarshalJSON() ([]byte, error) {
+	return json.Marshal(strings.ToUpper(string(u)))
+}
+
+type encodePoint struct {
+	X, Y int
+}
+
+func (p *encodePoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]int{p.X, p.Y})
+}
+
+type encodeMarshaledObject struct {
+	Prompt string
+}
+
+func (m encodeMarshaledObject) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"prompt": m.Prompt, "source": "marshaler"})
+}
+
+type encodeFields struct {
+	String    string          `json:"string"`
+	Empty     string          `json:"empty,omitempty"`
+	Zero      int             `json:"zero,omitempty"`
+	False     bool            `json:"false,omitempty"`
+	NilPtr    *int            `json:"nil_ptr"`
+	NilOmit   *int            `json:"nil_omit,omitempty"`
+	Float     float64         `json:"float"`
+	Big       int64           `json:"big"`
+	Quoted    int             `json:"quoted,string"`
+	QuotedPtr *int            `json:"quoted_ptr,string"`
+	QuotedNil *int            `json:"quoted_nil,string"`
+	QuotedStr string          `json:"quoted_str,string"`
+	QuotedOmt *bool           `json:"quoted_omit,string,omitempty"`
+	Iface     interface{}     `json:"iface"`
+	NilIface  interface{}     `json:"nil_iface"`
+	Map       map[string]int  `json:"map"`
+	NilMap    map[string]int  `json:"nil_map,omitempty"`
+	Slice     []string        `json:"slice"`
+	Nested    *encodeInner    `json:"nested"`
+	Upper     encodeUpper     `json:"upper"`
+	Point     encodePoint     `json:"point"`
+	PointPtr  *encodePoint    `json:"point_ptr"`
+	Raw       json.RawMessage `json:"raw"`
+	Escaped   string          `json:"escaped"`
+	Skipped   string          `json:"-"`
+	Dash      string          `json:"-,"`
+	Untagged  string
+	Files     []*base.FileInput `json:"files"`
+}
+
+type encodeKey struct {
+	Key    string `json:"key"`
+	Prompt string `json:"prompt"`
+}
+
+type encodeOptionalKey struct {
+	Key    string `json:"key,omitempty"`
+	Prompt string `json:"prompt"`
+}
+
+type encodeFile struct {
+	Image base.FileInput  `json:"image"`
+	Mask  *base.FileInput `json:"mask,omitempty"`
+	Init  *base.FileInput `json:"init_image"`
+}
+
+func TestEncodeMatchesLegacy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.png")
+	if err := os.WriteFile(path, []byte("\x89PNG\r\n\x1a\nfile contents"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fromPath, err := base.FileFromPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seven, yes := 7, true
+	url := "https://example.com/a.png"
+	b64 := base64.StdEncoding.EncodeToString([]byte("inline"))
+
+	tests := []struct {
+		name string
+		data interface{}
+	}{
+		{"nil", nil},
+		{"empty struct", &struct{}{}},
+		{"embedded", &encodeEmbedded{
+			encodeInner:     encodeInner{Shared: "inner", Deep: "deep", Hidden: "promoted"},
+			encodeTagged:    &encodeTagged{Name: "tagged"},
+			encodeUntagged:  encodeUntagged{Name: "untagged"},
+			encodeConflictA: encodeConflictA{Clash: "a"},
+			encodeConflictB: encodeConflictB{Clash: "b"},
+			Shared:          "outer",
+			hidden:          "unexported",
+		}},
+		{"embedded nil pointer", &encodeEmbedded{Shared: "outer"}},
+		{"embedded by value", encodeEmbedded{encodeTagged: &encodeTagged{Name: "value"}}},
+		{"fields", &encodeFields{
+			String:    "text",
+			Float:     0.1,
+			Big:       1 << 60,
+			Quoted:    42,
+			QuotedPtr: &seven,
+			QuotedStr: `say "hi"`,
+			QuotedOmt: &yes,
+			Iface:     &encodeInner{Shared: "in interface"},
+			Map:       map[string]int{"b": 2, "a": 1},
+			Slice:     []string{"x", "y"},
+			Nested:    &encodeInner{Shared: "nested"},
+			Upper:     "shout",
+			Point:     encodePoint{1, 2},
+			PointPtr:  &encodePoint{3, 4},
+			Raw:       json.RawMessage(`{"raw":[1,2]}`),
+			Escaped:   "<tag> &   \"quote\" \\ \n",
+			Skipped:   "skipped",
+			Dash:      "dash",
+			Untagged:  "untagged",
+			Files:     []*base.FileInput{{URL: &url}, nil},
+		}},
+		{"zero fields", &encodeFields{}},
+		{"map", map[string]interface{}{"prompt": "a cat", "steps": 30, "nested": map[string]bool{"ok": true}}},
+		{"map with key", map[string]string{"key": "own-key", "prompt": "p"}},
+		{"marshaler", encodeMarshaledObject{Prompt: "from marshaler"}},
+		{"key", &encodeKey{Key: "own-key", Prompt: "p"}},
+		{"empty key", &encodeKey{Prompt: "p"}},
+		{"omitted key", &encodeOptionalKey{Prompt: "p"}},
+		{"optional key", &encodeOptionalKey{Key: "own-key", Prompt: "p"}},
+		{"file inputs", &encodeFile{
+			Image: base.FileInput{Base64: &b64},
+			Mask:  &base.FileInput{Reader: strings.NewReader("reader contents"), MIMEType: "image/png"},
+			Init:  fromPath,
+		}},
+		{"file URL", &encodeFile{Image: base.FileInput{URL: &url}}},
+		{"image2image", image2ImageRequest()},
+		{"single video swap", singleVideoSwapRequest()},
+	}
+
+	c := New("api-key")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readers := &bodyReaders{}
+			got, err := c.encode(tt.data, readers)
+			readers.close()
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+			want, err := legacyEncode("api-key", tt.data)
+			if err != nil {
+				t.Fatalf("legacyEncode: %v", err)
+			}
+
+			var gotValue, wantValue interface{}
+			if err := decodeJSON(got, &gotValue); err != nil {
+				t.Fatalf("encode wrote invalid JSON %s: %v", got, err)
+			}
+			if err := decodeJSON(want, &wantValue); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(gotValue, wantValue) {
+				t.Errorf("encode wrote\n%.2000s\nwant\n%.2000s", got, want)
+			}
+		})
+	}
+}
+
+func TestEncodeKey(t *testing.T) {
+	c := New("api-key")
+	tests := []struct {
+		name string
+		data interface{}
+		want string
+	}{
+		{"injected", &encodeOptionalKey{Prompt: "p"}, "api-key"},
+		{"request key", &encodeOptionalKey{Key: "own-key"}, "own-key"},
+		{"empty request key", &encodeKey{}, ""},
+		{"nil", nil, "api-key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := c.encode(tt.data, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var members map[string]json.RawMessage
+			if err := json.Unmarshal(body, &members); err != nil {
+				t.Fatalf("invalid JSON %s: %v", body, err)
+			}
+			if n := strings.Count(string(body), `"key":`); n != 1 {
+				t.Errorf("%s has %d key members, want 1", body, n)
+			}
+			var key string
+			if err := json.Unmarshal(members["key"], &key); err != nil || key != tt.want {
+				t.Errorf("key = %s, want %q", members["key"], tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeQuotedNil(t *testing.T) {
+	var data struct {
+		Count *int `json:"count,string"`
+	}
+	want, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := New("api-key").encode(&data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), `"count":null`) || !strings.Contains(string(want), `"count":null`) {
+		t.Errorf("encode wrote %s, encoding/json %s", got, want)
+	}
+}
@@ -0,0 +1,229 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Sentinel errors describing why an API call failed. Use errors.Is to test an
+// error returned by the client against them.
+var (
+	ErrInsufficientCredits = errors.New("insufficient credits")
+	ErrRateLimited         = errors.New("rate limited")
+	ErrInvalidAPIKey       = errors.New("invalid API key")
+	ErrModelNotFound       = errors.New("model not found")
+	ErrNSFWBlocked         = errors.New("NSFW content blocked")
+	ErrValidation          = errors.New("validation failed")
+	ErrServerBusy          = errors.New("server busy")
+)
+
+// APIError represents an API error
+type APIError struct {
+	StatusCode int    `json:"status_code"`
+	Message    string `json:"message"`
+	// Details holds the raw response body
+	Details  string `json:"details,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+	// Status is the in-body status, such as "error" or "failed"
+	Status    string `json:"status,omitempty"`
+	Retryable bool   `json:"retryable"`
+	// Kind is the sentinel error matching the cause, or nil when unknown
+	Kind error `json:"-"`
+}
+
+func (e *APIError) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("API error %d: %s - %s", e.StatusCode, e.Message, e.Details)
+	}
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Message)
+}
+
+// Unwrap returns the sentinel error matching the cause so errors.Is works
+func (e *APIError) Unwrap() error {
+	return e.Kind
+}
+
+// retryStatus returns the HTTP status code used to decide whether the error
+// is retried. In-body errors arrive with HTTP 200, so they are mapped to the
+// status code matching their cause.
+func (e *APIError) retryStatus() int {
+	if e.StatusCode != http.StatusOK {
+		return e.StatusCode
+	}
+	switch e.Kind {
+	case ErrRateLimited:
+		return http.StatusTooManyRequests
+	case ErrServerBusy:
+		return http.StatusServiceUnavailable
+	}
+	return e.StatusCode
+}
+
+// serverFailure reports whether the error means the service itself is
+// failing, as opposed to a problem with the request or the account
+func (e *APIError) serverFailure() bool {
+	return e.StatusCode >= http.StatusInternalServerError || e.Kind == ErrRateLimited || e.Kind == ErrServerBusy
+}
+
+// errorPayload is the JSON shape of an error response
+type errorPayload struct {
+	Status  string          `json:"status"`
+	Message json.RawMessage `json:"message"`
+	Messege json.RawMessage `json:"messege"`
+	Error   json.RawMessage `json:"error"`
+}
+
+// checkResponse returns an *APIError when the response is a failure, either
+// through its HTTP status or through a {"status":"error"} body
+func checkResponse(endpoint string, statusCode int, body []byte) error {
+	var payload errorPayload
+	parsed := json.Unmarshal(body, &payload) == nil
+
+	if statusCode == http.StatusOK {
+		if !parsed || (payload.Status != "error" && payload.Status != "failed") {
+			return nil
+		}
+	}
+
+	message := ""
+	if parsed {
+		message = payloadMessage(payload)
+	}
+	if message == "" {
+		message = "Request failed"
+	}
+
+	apiErr := &APIError{
+		StatusCode: statusCode,
+		Message:    message,
+		Details:    string(body),
+		Endpoint:   endpoint,
+		Status:     payload.Status,
+	}
+	apiErr.Kind = classifyError(statusCode, message)
+	if apiErr.Kind == nil && parsed && isFieldErrors(payload) {
+		apiErr.Kind = ErrValidation
+	}
+	apiErr.Retryable = apiErr.Kind == ErrRateLimited || apiErr.Kind == ErrServerBusy ||
+		statusCode == http.StatusBadGateway || statusCode == http.StatusGatewayTimeout
+
+	return apiErr
+}
+
+// payloadMessage extracts a readable message from an error payload. Messages
+// may be plain strings or objects mapping field names to errors.
+func payloadMessage(payload errorPayload) string {
+	for _, raw := range []json.RawMessage{payload.Message, payload.Messege, payload.Error} {
+		if msg := rawMessage(raw); msg != "" {
+			return msg
+		}
+	}
+	return ""
+}
+
+// isFieldErrors reports whether the payload's message maps field names to
+// errors, as the API does when a request fails validation
+func isFieldErrors(payload errorPayload) bool {
+	for _, raw := range []json.RawMessage{payload.Message, payload.Messege, payload.Error} {
+		if len(raw) > 0 {
+			var fields map[string]interface{}
+			return json.Unmarshal(raw, &fields) == nil
+		}
+	}
+	return false
+}
+
+// rawMessage flattens a JSON message value into a single string
+func rawMessage(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+
+	var str string
+	if err := json.Unmarshal(raw, &str); err == nil {
+		return str
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err == nil {
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		parts := make([]string, 0, len(keys))
+		for _, key := range keys {
+			parts = append(parts, fmt.Sprintf("%s: %v", key, flattenValue(fields[key])))
+		}
+		return strings.Join(parts, "; ")
+	}
+
+	return string(raw)
+}
+
+// flattenValue renders a decoded JSON value as text
+func flattenValue(v interface{}) string {
+	switch val := v.(type) {
+	case []interface{}:
+		parts := make([]string, 0, len(val))
+		for _, item := range val {
+			parts = append(parts, flattenValue(item))
+		}
+		return strings.Join(parts, ", ")
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// knownMessages maps the error messages the API is known to send, lower-cased
+// and without trailing punctuation, to sentinel errors
+var knownMessages = map[string]error{
+	"invalid api key":                     ErrInvalidAPIKey,
+	"api key not found":                   ErrInvalidAPIKey,
+	"unauthorized":                        ErrInvalidAPIKey,
+	"insufficient credits":                ErrInsufficientCredits,
+	"insufficient credits in your wallet": ErrInsufficientCredits,
+	"insufficient balance":                ErrInsufficientCredits,
+	"rate limit exceeded":                 ErrRateLimited,
+	"too many requests":                   ErrRateLimited,
+	"nsfw content detected":               ErrNSFWBlocked,
+	"nsfw image detected":                 ErrNSFWBlocked,
+	"model not found":                     ErrModelNotFound,
+	"model does not exist":                ErrModelNotFound,
+	"invalid model id":                    ErrModelNotFound,
+	"server is busy, try again later":     ErrServerBusy,
+	"server busy":                         ErrServerBusy,
+	"server overloaded":                   ErrServerBusy,
+}
+
+// classifyError maps an HTTP status and message to a sentinel error. Statuses
+// with a single meaning decide on their own; otherwise only messages listed
+// in knownMessages are recognised, and any other 400 or 422 is a validation
+// error.
+func classifyError(statusCode int, message string) error {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrInvalidAPIKey
+	case http.StatusPaymentRequired:
+		return ErrInsufficientCredits
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusServiceUnavailable:
+		return ErrServerBusy
+	}
+
+	msg := strings.TrimRight(strings.ToLower(strings.TrimSpace(message)), ".!")
+	if kind, ok := knownMessages[msg]; ok {
+		return kind
+	}
+
+	if statusCode == http.StatusBadRequest || statusCode == http.StatusUnprocessableEntity {
+		return ErrValidation
+	}
+	return nil
+}
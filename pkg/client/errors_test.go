@@ -0,0 +1,147 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestCheckResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantKind    error
+		wantMessage string
+		retryable   bool
+	}{
+		{
+			name:        "invalid key",
+			status:      http.StatusOK,
+			body:        `{"status": "error", "message": "Invalid API key"}`,
+			wantKind:    ErrInvalidAPIKey,
+			wantMessage: "Invalid API key",
+		},
+		{
+			name:        "credits",
+			status:      http.StatusOK,
+			body:        `{"status": "error", "messege": "Insufficient credits in your wallet"}`,
+			wantKind:    ErrInsufficientCredits,
+			wantMessage: "Insufficient credits in your wallet",
+		},
+		{
+			name:        "rate limit",
+			status:      http.StatusTooManyRequests,
+			body:        `{"error": "slow down"}`,
+			wantKind:    ErrRateLimited,
+			wantMessage: "slow down",
+			retryable:   true,
+		},
+		{
+			name:        "field errors",
+			status:      http.StatusUnprocessableEntity,
+			body:        `{"status": "error", "message": {"width": ["must be a multiple of 8"], "prompt": "is required"}}`,
+			wantKind:    ErrValidation,
+			wantMessage: "prompt: is required; width: must be a multiple of 8",
+		},
+		{
+			name:        "model",
+			status:      http.StatusOK,
+			body:        `{"status": "failed", "message": "Model not found"}`,
+			wantKind:    ErrModelNotFound,
+			wantMessage: "Model not found",
+		},
+		{
+			name:        "nsfw",
+			status:      http.StatusOK,
+			body:        `{"status": "error", "message": "NSFW content detected"}`,
+			wantKind:    ErrNSFWBlocked,
+			wantMessage: "NSFW content detected",
+		},
+		{
+			name:        "busy",
+			status:      http.StatusOK,
+			body:        `{"status": "error", "message": "Server is busy, try again later"}`,
+			wantKind:    ErrServerBusy,
+			wantMessage: "Server is busy, try again later",
+			retryable:   true,
+		},
+		{
+			name:        "in-body field errors",
+			status:      http.StatusOK,
+			body:        `{"status": "error", "message": {"prompt": ["is required"]}}`,
+			wantKind:    ErrValidation,
+			wantMessage: "prompt: is required",
+		},
+		{
+			name:        "status decides",
+			status:      http.StatusUnauthorized,
+			body:        `{"status": "error", "message": "Model not found"}`,
+			wantKind:    ErrInvalidAPIKey,
+			wantMessage: "Model not found",
+		},
+		{
+			name:        "unknown message",
+			status:      http.StatusOK,
+			body:        `{"status": "error", "message": "Prompt is invalid for this model, which was not found in the credits table"}`,
+			wantMessage: "Prompt is invalid for this model, which was not found in the credits table",
+		},
+		{
+			name:        "known message on bad request",
+			status:      http.StatusBadRequest,
+			body:        `{"status": "error", "message": "Model not found."}`,
+			wantKind:    ErrModelNotFound,
+			wantMessage: "Model not found.",
+		},
+		{
+			name:        "gateway",
+			status:      http.StatusBadGateway,
+			body:        `<html>bad gateway</html>`,
+			wantMessage: "Request failed",
+			retryable:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkResponse("https://modelslab.com/api/v6/images/text2img", tt.status, []byte(tt.body))
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("checkResponse() = %v, want an *APIError", err)
+			}
+			if apiErr.Kind != tt.wantKind || (tt.wantKind != nil && !errors.Is(err, tt.wantKind)) {
+				t.Errorf("kind = %v, want %v", apiErr.Kind, tt.wantKind)
+			}
+			if apiErr.Message != tt.wantMessage {
+				t.Errorf("message = %q, want %q", apiErr.Message, tt.wantMessage)
+			}
+			if apiErr.Retryable != tt.retryable {
+				t.Errorf("retryable = %v, want %v", apiErr.Retryable, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestCheckResponseSuccess(t *testing.T) {
+	for _, body := range []string{`{"status": "success"}`, `{"status": "processing", "eta": 5}`, `not json`} {
+		if err := checkResponse("", http.StatusOK, []byte(body)); err != nil {
+			t.Errorf("checkResponse(200, %s) = %v, want nil", body, err)
+		}
+	}
+}
+
+func TestRetryStatus(t *testing.T) {
+	tests := []struct {
+		err  *APIError
+		want int
+	}{
+		{err: &APIError{StatusCode: http.StatusOK, Kind: ErrRateLimited}, want: http.StatusTooManyRequests},
+		{err: &APIError{StatusCode: http.StatusOK, Kind: ErrServerBusy}, want: http.StatusServiceUnavailable},
+		{err: &APIError{StatusCode: http.StatusOK, Kind: ErrValidation}, want: http.StatusOK},
+		{err: &APIError{StatusCode: http.StatusBadGateway}, want: http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		if got := tt.err.retryStatus(); got != tt.want {
+			t.Errorf("retryStatus(%d, %v) = %d, want %d", tt.err.StatusCode, tt.err.Kind, got, tt.want)
+		}
+	}
+}
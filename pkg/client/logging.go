@@ -0,0 +1,277 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	// redactedValue replaces secrets in logged bodies
+	redactedValue = "[REDACTED]"
+	// maxLoggedString is the longest string value logged verbatim; longer
+	// values such as base64 payloads are truncated
+	maxLoggedString = 256
+	// truncatedPrefix is how much of a truncated string is kept
+	truncatedPrefix = 32
+	// maxLoggedBody is the largest body that is decoded for logging; the
+	// size of larger bodies is logged instead
+	maxLoggedBody = 64 << 10
+)
+
+// secretNames are the member names whose string values are redacted from
+// logged bodies, compared without case at any depth
+var secretNames = map[string]bool{
+	"key":           true,
+	"api_key":       true,
+	"apikey":        true,
+	"token":         true,
+	"access_token":  true,
+	"refresh_token": true,
+	"secret":        true,
+	"password":      true,
+	"authorization": true,
+}
+
+// contentHolder is implemented by request values that write file contents
+// into the request body, such as base.FileInput
+type contentHolder interface {
+	HasLocalContents() bool
+}
+
+var contentHolderType = reflect.TypeOf((*contentHolder)(nil)).Elem()
+
+// logStart logs the start of a request
+func (c *Client) logStart(ctx context.Context, req *Request) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := []interface{}{
+		slog.String("endpoint", req.Endpoint),
+		slog.String("module", req.Module),
+	}
+	if trackID := requestTrackID(req.Data); trackID != "" {
+		attrs = append(attrs, slog.String("track_id", trackID))
+	}
+	c.logger.DebugContext(ctx, "modelslab request started", attrs...)
+
+	if c.logger.Enabled(ctx, slog.LevelDebug) {
+		if body, ok := c.loggedBody(req); ok {
+			c.logger.DebugContext(ctx, "modelslab request body",
+				slog.String("endpoint", req.Endpoint),
+				slog.String("body", body),
+			)
+		}
+	}
+}
+
+// loggedBody renders the request body for logging without changing how it is
+// sent. Bodies holding file contents are not encoded, so files are still
+// streamed rather than buffered to be logged.
+func (c *Client) loggedBody(req *Request) (string, bool) {
+	body := req.body
+	if body == nil {
+		if holdsContents(reflect.ValueOf(req.Data)) {
+			return "[file contents omitted]", true
+		}
+		encoded, err := c.encode(req.Data, req.readers)
+		if err != nil {
+			return "", false
+		}
+		body = encoded
+	}
+	return redactBody(body), true
+}
+
+// holdsContents reports whether a request value holds file contents that are
+// written into the body
+func holdsContents(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return false
+		}
+		if v.Type().Implements(contentHolderType) {
+			return v.Interface().(contentHolder).HasLocalContents()
+		}
+		return holdsContents(v.Elem())
+	case reflect.Struct:
+		if reflect.PointerTo(v.Type()).Implements(contentHolderType) {
+			cp := reflect.New(v.Type())
+			cp.Elem().Set(v)
+			return cp.Interface().(contentHolder).HasLocalContents()
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() && holdsContents(v.Field(i)) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if holdsContents(v.Index(i)) {
+				return true
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if holdsContents(iter.Value()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// logFinish logs the outcome of a request
+func (c *Client) logFinish(ctx context.Context, req *Request, resp *Response, err error, start time.Time) {
+	if c.logger == nil {
+		return
+	}
+
+	attrs := []interface{}{
+		slog.String("endpoint", req.Endpoint),
+		slog.String("module", req.Module),
+		slog.Duration("latency", time.Since(start)),
+	}
+	if resp != nil {
+		attrs = append(attrs, slog.Int("http_status", resp.StatusCode))
+		if status, ok := resp.Data["status"].(string); ok {
+			attrs = append(attrs, slog.String("status", status))
+		}
+		if id, ok := resp.Data["id"]; ok && id != nil {
+			attrs = append(attrs, slog.String("job_id", fmt.Sprint(id)))
+		}
+	}
+	trackID := requestTrackID(req.Data)
+	if trackID == "" && resp != nil {
+		if id, ok := resp.Data["track_id"]; ok && id != nil {
+			trackID = fmt.Sprint(id)
+		}
+	}
+	if trackID != "" {
+		attrs = append(attrs, slog.String("track_id", trackID))
+	}
+
+	if err != nil {
+		// APIError messages embed the raw body, so only the parsed message
+		// is logged
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			attrs = append(attrs, slog.String("error", apiErr.Message), slog.Bool("retryable", apiErr.Retryable))
+		} else {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		c.logger.ErrorContext(ctx, "modelslab request failed", attrs...)
+	} else {
+		c.logger.InfoContext(ctx, "modelslab request finished", attrs...)
+	}
+
+	if resp != nil && c.logger.Enabled(ctx, slog.LevelDebug) {
+		c.logger.DebugContext(ctx, "modelslab response body",
+			slog.String("endpoint", req.Endpoint),
+			slog.String("body", redactBody(resp.Body)),
+		)
+	}
+}
+
+// logRetry logs a retried attempt
+func (c *Client) logRetry(ctx context.Context, req *Request, attempt int, delay time.Duration, reason string) {
+	if c.logger == nil {
+		return
+	}
+
+	c.logger.WarnContext(ctx, "modelslab request retrying",
+		slog.String("endpoint", req.Endpoint),
+		slog.Int("attempt", attempt),
+		slog.Duration("delay", delay),
+		slog.String("reason", reason),
+	)
+}
+
+// requestTrackID returns the TrackID field of a request struct, if any
+func requestTrackID(data interface{}) string {
+	v := reflect.ValueOf(data)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return ""
+	}
+
+	field := v.FieldByName("TrackID")
+	switch field.Kind() {
+	case reflect.String:
+		return field.String()
+	case reflect.Ptr:
+		if !field.IsNil() && field.Elem().Kind() == reflect.String {
+			return field.Elem().String()
+		}
+	}
+	return ""
+}
+
+// redactBody returns a loggable copy of a JSON body with secrets redacted and
+// long strings, such as base64 payloads, truncated. Bodies larger than
+// maxLoggedBody are replaced by their size.
+func redactBody(body []byte) string {
+	if len(body) > maxLoggedBody {
+		return fmt.Sprintf("[%d bytes omitted]", len(body))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return truncateString(string(body))
+	}
+
+	redacted, err := json.Marshal(redactValue("", value))
+	if err != nil {
+		return truncateString(string(body))
+	}
+	return string(redacted)
+}
+
+// redactValue redacts a decoded JSON value found under key. Strings under
+// the secretNames are redacted in nested objects and arrays as well.
+func redactValue(key string, value interface{}) interface{} {
+	switch val := value.(type) {
+	case map[string]interface{}:
+		for k, v := range val {
+			val[k] = redactValue(k, v)
+		}
+		return val
+	case []interface{}:
+		for i, v := range val {
+			val[i] = redactValue(key, v)
+		}
+		return val
+	case string:
+		if secretNames[strings.ToLower(key)] {
+			return redactedValue
+		}
+		return truncateString(val)
+	default:
+		return val
+	}
+}
+
+// truncateString shortens strings longer than maxLoggedString
+func truncateString(s string) string {
+	if len(s) <= maxLoggedString {
+		return s
+	}
+	return fmt.Sprintf("%s...[%d bytes]", s[:truncatedPrefix], len(s))
+}
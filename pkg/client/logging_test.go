@@ -0,0 +1,78 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/modelslab/modelslab-go/pkg/schemas/base"
+)
+
+type loggedRequest struct {
+	Prompt   string                 `json:"prompt"`
+	Image    *base.FileInput        `json:"init_image,omitempty"`
+	Settings map[string]interface{} `json:"settings,omitempty"`
+}
+
+func TestLogStartDoesNotBufferBody(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c, err := NewClient(WithAPIKey("test-key"), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    *loggedRequest
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "nested secrets",
+			data: &loggedRequest{
+				Prompt:   "a cat",
+				Image:    base.FileFromURL("https://example.com/cat.png"),
+				Settings: map[string]interface{}{"webhook": map[string]interface{}{"Token": "hook-secret"}},
+			},
+			want:    []string{"a cat", "https://example.com/cat.png", redactedValue},
+			notWant: []string{"test-key", "hook-secret"},
+		},
+		{
+			name:    "file contents",
+			data:    &loggedRequest{Prompt: "a cat", Image: &base.FileInput{Reader: strings.NewReader("image bytes")}},
+			want:    []string{"[file contents omitted]"},
+			notWant: []string{"a cat"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.Reset()
+			req := &Request{Endpoint: "https://example.com/v6/images/img2img", Data: tt.data, client: c}
+			c.logStart(context.Background(), req)
+
+			if req.body != nil {
+				t.Error("logging buffered the request body")
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(logs.String(), s) {
+					t.Errorf("log does not contain %q:\n%s", s, logs.String())
+				}
+			}
+			for _, s := range tt.notWant {
+				if strings.Contains(logs.String(), s) {
+					t.Errorf("log contains %q:\n%s", s, logs.String())
+				}
+			}
+		})
+	}
+}
+
+func TestRedactBodyLimit(t *testing.T) {
+	body := []byte(`{"prompt": "` + strings.Repeat("a", maxLoggedBody) + `"}`)
+	if got := redactBody(body); !strings.Contains(got, "bytes omitted") {
+		t.Errorf("redactBody(large) = %.64q, want the size only", got)
+	}
+}
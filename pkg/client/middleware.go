@@ -0,0 +1,112 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// Request is an API call as seen by middleware
+type Request struct {
+	// Endpoint is the full URL the request is posted to
+	Endpoint string
+	// Module is the API module path of the endpoint, such as "images" or
+	// "video"
+	Module string
+	// Data is the request struct; it is nil for calls without a payload
+	Data interface{}
+	// Header holds extra HTTP headers sent with the request. They are applied
+	// after the client's own, so they can replace Content-Type or User-Agent.
+	Header http.Header
+
+	client  *Client
+	body    []byte
+	readers *bodyReaders
+}
+
+// Body returns the outgoing JSON, including the injected API key. The body is
+// encoded from Data on the first call; later changes to Data are ignored, use
+// SetBody to replace it. Requests whose body is never materialized are
+// streamed to the server without being held in memory.
+func (r *Request) Body() ([]byte, error) {
+	if r.body == nil {
+		body, err := r.client.encode(r.Data, r.readers)
+		if err != nil {
+			return nil, err
+		}
+		r.body = body
+	}
+	return r.body, nil
+}
+
+// SetBody replaces the outgoing JSON
+func (r *Request) SetBody(body []byte) {
+	r.body = body
+}
+
+// open returns a reader over the outgoing JSON. Unless the body has been
+// materialized, Data is encoded into a pipe by a separate goroutine. The
+// returned function waits for the encoder once the reader is closed and
+// reports any encoding error.
+func (r *Request) open() (io.ReadCloser, func() error) {
+	if r.body != nil {
+		return io.NopCloser(bytes.NewReader(r.body)), func() error { return nil }
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := r.client.writeBody(pw, r.Data, r.readers)
+		pw.CloseWithError(err)
+		done <- err
+	}()
+
+	return pr, func() error {
+		// The transport closing the body early is not an encoding error
+		if err := <-done; err != nil && !errors.Is(err, io.ErrClosedPipe) {
+			return err
+		}
+		return nil
+	}
+}
+
+// Response is an API response as seen by middleware
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	// Body is the raw response body
+	Body []byte
+	// Data is the parsed response
+	Data APIResponse
+}
+
+// Handler processes a request and returns its response. Handlers may return a
+// non-nil response together with an error when the server answered with a
+// failure.
+type Handler func(ctx context.Context, req *Request) (*Response, error)
+
+// Middleware wraps a handler with cross-cutting behaviour such as header
+// injection, logging or metrics
+type Middleware func(next Handler) Handler
+
+// Use appends middleware to the client. Middleware registered first is the
+// outermost and sees each request first.
+func (c *Client) Use(middleware ...Middleware) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.middleware = append(c.middleware, middleware...)
+}
+
+// handler builds the middleware chain around the client's transport
+func (c *Client) handler() Handler {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	h := Handler(c.do)
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		h = c.middleware[i](h)
+	}
+	return h
+}
@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// recordingServer answers every request with body and records the last
+// request's headers and body
+func recordingServer(t *testing.T, status int, body string) (*httptest.Server, *http.Header, *[]byte) {
+	t.Helper()
+	header := new(http.Header)
+	sent := new([]byte)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*header = r.Header.Clone()
+		*sent, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, header, sent
+}
+
+func middlewareTestClient(t *testing.T, srv *httptest.Server, middleware ...Middleware) *Client {
+	t.Helper()
+	retry := DefaultRetryPolicy()
+	retry.MaxAttempts = 1
+	c, err := NewClient(WithAPIKey("test-key"), WithBaseURL(srv.URL), WithRetry(retry), WithMiddleware(middleware...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	srv, _, _ := recordingServer(t, http.StatusOK, `{"status": "success"}`)
+
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, req *Request) (*Response, error) {
+				calls = append(calls, name+" before")
+				resp, err := next(ctx, req)
+				calls = append(calls, name+" after")
+				return resp, err
+			}
+		}
+	}
+
+	c := middlewareTestClient(t, srv, trace("first"), trace("second"))
+	c.Use(trace("third"))
+	if _, err := c.Post(context.Background(), srv.URL+"/v6/images/text2img", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "first before,second before,third before,third after,second after,first after"
+	if got := strings.Join(calls, ","); got != want {
+		t.Errorf("calls = %s, want %s", got, want)
+	}
+}
+
+func TestMiddlewareHeaders(t *testing.T) {
+	srv, header, _ := recordingServer(t, http.StatusOK, `{"status": "success"}`)
+
+	c := middlewareTestClient(t, srv, func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			req.Header.Set("X-Request-ID", "req-1")
+			req.Header.Set("User-Agent", "my-app/1.0")
+			req.Header.Set("Content-Type", "application/json; charset=utf-8")
+			return next(ctx, req)
+		}
+	})
+	if _, err := c.Post(context.Background(), srv.URL+"/v6/images/text2img", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, want := range map[string]string{
+		"X-Request-Id": "req-1",
+		"User-Agent":   "my-app/1.0",
+		"Content-Type": "application/json; charset=utf-8",
+	} {
+		if got := header.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestMiddlewareBody(t *testing.T) {
+	srv, _, sent := recordingServer(t, http.StatusOK, `{"status": "success"}`)
+
+	var seen map[string]interface{}
+	c := middlewareTestClient(t, srv, func(next Handler) Handler {
+		return func(ctx context.Context, req *Request) (*Response, error) {
+			body, err := req.Body()
+			if err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal(body, &seen); err != nil {
+				return nil, err
+			}
+			req.SetBody([]byte(`{"key": "test-key", "prompt": "replaced"}`))
+			return next(ctx, req)
+		}
+	})
+	data := map[string]interface{}{"prompt": "a cat"}
+	if _, err := c.Post(context.Background(), srv.URL+"/v6/images/text2img", data); err != nil {
+		t.Fatal(err)
+	}
+
+	if seen["key"] != "test-key" || seen["prompt"] != "a cat" {
+		t.Errorf("Body() = %v, want the prompt with the API key injected", seen)
+	}
+	if got := string(*sent); got != `{"key": "test-key", "prompt": "replaced"}` {
+		t.Errorf("server received %s, want the body set by SetBody", got)
+	}
+}
+
+func TestMiddlewareResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr bool
+	}{
+		{name: "success", status: http.StatusOK, body: `{"status": "success", "id": 7}`},
+		{name: "in-body error", status: http.StatusOK, body: `{"status": "error", "message": "Invalid API key"}`, wantErr: true},
+		{name: "HTTP error", status: http.StatusBadRequest, body: `{"status": "error", "message": "bad prompt"}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _, _ := recordingServer(t, tt.status, tt.body)
+
+			var seen *Response
+			var seenErr error
+			c := middlewareTestClient(t, srv, func(next Handler) Handler {
+				return func(ctx context.Context, req *Request) (*Response, error) {
+					resp, err := next(ctx, req)
+					seen, seenErr = resp, err
+					return resp, err
+				}
+			})
+			_, err := c.Post(context.Background(), srv.URL+"/v6/images/text2img", nil)
+
+			var apiErr *APIError
+			if tt.wantErr != errors.As(err, &apiErr) || tt.wantErr != errors.As(seenErr, &apiErr) {
+				t.Fatalf("Post() error = %v, middleware saw %v, want API error %v", err, seenErr, tt.wantErr)
+			}
+			if seen == nil {
+				t.Fatal("middleware saw no response")
+			}
+			if seen.StatusCode != tt.status || string(seen.Body) != tt.body {
+				t.Errorf("response = %d %s, want %d %s", seen.StatusCode, seen.Body, tt.status, tt.body)
+			}
+			if !tt.wantErr && seen.Data["status"] != "success" {
+				t.Errorf("response data = %v, want the parsed body", seen.Data)
+			}
+		})
+	}
+}
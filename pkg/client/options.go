@@ -0,0 +1,139 @@
+package client
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Option configures a client created with NewClient
+type Option func(*Config)
+
+// WithConfig replaces the whole configuration with a copy of config. Options
+// given after it are applied on top. A nil config changes nothing.
+func WithConfig(config *Config) Option {
+	return func(c *Config) {
+		if config != nil {
+			*c = *config
+		}
+	}
+}
+
+// WithAPIKey sets the API key
+func WithAPIKey(apiKey string) Option {
+	return func(c *Config) {
+		c.APIKey = apiKey
+	}
+}
+
+// WithBaseURL sets the API base URL
+func WithBaseURL(baseURL string) Option {
+	return func(c *Config) {
+		c.BaseURL = baseURL
+	}
+}
+
+// WithEnterprise selects the enterprise endpoints
+func WithEnterprise(enterprise bool) Option {
+	return func(c *Config) {
+		c.Enterprise = enterprise
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Config) {
+		c.HTTPClient = httpClient
+	}
+}
+
+// WithHTTPTimeout sets the timeout of the default HTTP client
+func WithHTTPTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.HTTPTimeout = timeout
+	}
+}
+
+// WithFetchRetry sets how many times Fetch polls for a result
+func WithFetchRetry(retries int) Option {
+	return func(c *Config) {
+		c.FetchRetry = retries
+	}
+}
+
+// WithFetchTimeout sets the delay between fetch attempts
+func WithFetchTimeout(timeout time.Duration) Option {
+	return func(c *Config) {
+		c.FetchTimeout = timeout
+	}
+}
+
+// WithRetry sets the retry policy for failed requests
+func WithRetry(policy *RetryPolicy) Option {
+	return func(c *Config) {
+		c.Retry = policy
+	}
+}
+
+// WithLogger sets the logger that receives request logs
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Config) {
+		c.Logger = logger
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request
+func WithUserAgent(userAgent string) Option {
+	return func(c *Config) {
+		c.UserAgent = userAgent
+	}
+}
+
+// WithMiddleware appends middleware wrapping every request
+func WithMiddleware(middleware ...Middleware) Option {
+	return func(c *Config) {
+		c.Middleware = append(append([]Middleware(nil), c.Middleware...), middleware...)
+	}
+}
+
+// WithRateLimit sets the limits applied to all requests
+func WithRateLimit(limits Limits) Option {
+	return func(c *Config) {
+		c.RateLimit = limits
+	}
+}
+
+// WithModuleLimits sets additional limits for one API module, such as
+// "video" or "3d"
+func WithModuleLimits(module string, limits Limits) Option {
+	return func(c *Config) {
+		moduleLimits := make(map[string]Limits, len(c.ModuleLimits)+1)
+		for k, v := range c.ModuleLimits {
+			moduleLimits[k] = v
+		}
+		moduleLimits[module] = limits
+		c.ModuleLimits = moduleLimits
+	}
+}
+
+// WithCircuitBreaker enables the circuit breaker
+func WithCircuitBreaker(config *BreakerConfig) Option {
+	return func(c *Config) {
+		c.Breaker = config
+	}
+}
+
+// WithUploader uploads local file inputs through uploader before each call
+func WithUploader(uploader Uploader) Option {
+	return func(c *Config) {
+		c.Uploader = uploader
+	}
+}
+
+// WithUploadCacheTTL sets how long uploaded URLs are reused for identical
+// contents
+func WithUploadCacheTTL(ttl time.Duration) Option {
+	return func(c *Config) {
+		c.UploadCacheTTL = ttl
+	}
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithConfig(t *testing.T) {
+	config := DefaultConfig()
+	config.APIKey = "config-key"
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{name: "config", opts: []Option{WithConfig(config)}, want: "config-key"},
+		{name: "options after config", opts: []Option{WithConfig(config), WithAPIKey("option-key")}, want: "option-key"},
+		{name: "nil config", opts: []Option{WithAPIKey("option-key"), WithConfig(nil)}, want: "option-key"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.GetAPIKey(); got != tt.want {
+				t.Errorf("API key = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewClientInvalidOptions(t *testing.T) {
+	t.Setenv("MODELSLAB_API_KEY", "")
+
+	tests := []struct {
+		name    string
+		opts    []Option
+		wantErr string
+	}{
+		{name: "empty API key", opts: nil, wantErr: "APIKey"},
+		{name: "negative HTTP timeout", opts: []Option{WithAPIKey("key"), WithHTTPTimeout(-time.Second)}, wantErr: "HTTPTimeout"},
+		{name: "negative fetch timeout", opts: []Option{WithAPIKey("key"), WithFetchTimeout(-time.Second)}, wantErr: "FetchTimeout"},
+		{name: "invalid base URL", opts: []Option{WithAPIKey("key"), WithBaseURL("not a url")}, wantErr: "BaseURL"},
+		{name: "negative rate", opts: []Option{WithAPIKey("key"), WithRateLimit(Limits{RequestsPerSecond: -1})}, wantErr: "RequestsPerSecond"},
+		{name: "burst without rate", opts: []Option{WithAPIKey("key"), WithRateLimit(Limits{Burst: 5})}, wantErr: "burst of 5"},
+		{
+			name:    "module allows more than the client",
+			opts:    []Option{WithAPIKey("key"), WithRateLimit(Limits{MaxInFlight: 2}), WithModuleLimits("video", Limits{MaxInFlight: 4})},
+			wantErr: "video module allows 4 requests in flight",
+		},
+		{name: "unnamed module", opts: []Option{WithAPIKey("key"), WithModuleLimits("", Limits{MaxInFlight: 1})}, wantErr: "module name"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := NewClient(tt.opts...)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewClient() = %v, %v, want error containing %q", c, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewClientAPIKeyFromEnvironment(t *testing.T) {
+	t.Setenv("MODELSLAB_API_KEY", "env-key")
+	c, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetAPIKey(); got != "env-key" {
+		t.Errorf("API key = %q, want env-key", got)
+	}
+}
@@ -0,0 +1,192 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/utils"
+)
+
+// Limits throttles requests on the client side
+type Limits struct {
+	// RequestsPerSecond is the sustained request rate; 0 disables rate
+	// limiting
+	RequestsPerSecond float64 `validate:"min=0"`
+	// Burst is the number of requests allowed at once above the sustained
+	// rate; it defaults to the rate rounded up, with a minimum of 1
+	Burst int `validate:"min=0"`
+	// MaxInFlight caps the number of concurrent requests; 0 means unlimited
+	MaxInFlight int `validate:"min=0"`
+}
+
+// check reports settings that cannot take effect
+func (l Limits) check() error {
+	if l.Burst > 0 && l.RequestsPerSecond <= 0 {
+		return fmt.Errorf("burst of %d is set without a request rate", l.Burst)
+	}
+	return nil
+}
+
+// checkLimits reports client and module limits that conflict with each other
+func checkLimits(global Limits, modules map[string]Limits) error {
+	if err := global.check(); err != nil {
+		return fmt.Errorf("rate limit: %w", err)
+	}
+	for module, limits := range modules {
+		if module == "" {
+			return fmt.Errorf("module limits need a module name")
+		}
+		if err := limits.check(); err != nil {
+			return fmt.Errorf("%s module limits: %w", module, err)
+		}
+		if global.MaxInFlight > 0 && limits.MaxInFlight > global.MaxInFlight {
+			return fmt.Errorf("%s module allows %d requests in flight, more than the client-wide %d",
+				module, limits.MaxInFlight, global.MaxInFlight)
+		}
+	}
+	return nil
+}
+
+// limiter enforces a set of limits
+type limiter struct {
+	bucket *tokenBucket
+	slots  chan struct{}
+}
+
+// newLimiter returns a limiter for l, or nil when l sets no limits
+func newLimiter(l Limits) *limiter {
+	if l.RequestsPerSecond <= 0 && l.MaxInFlight <= 0 {
+		return nil
+	}
+
+	lim := &limiter{}
+	if l.RequestsPerSecond > 0 {
+		burst := l.Burst
+		if burst <= 0 {
+			burst = int(math.Max(1, math.Ceil(l.RequestsPerSecond)))
+		}
+		lim.bucket = &tokenBucket{
+			rate:   l.RequestsPerSecond,
+			burst:  float64(burst),
+			tokens: float64(burst),
+			last:   time.Now(),
+		}
+	}
+	if l.MaxInFlight > 0 {
+		lim.slots = make(chan struct{}, l.MaxInFlight)
+	}
+	return lim
+}
+
+// acquire blocks until a request may be sent or ctx is done. The returned
+// function releases the in-flight slot and must be called once the request
+// completes.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	release := func() {}
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+			release = func() { <-l.slots }
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if l.bucket != nil {
+		if err := l.bucket.wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+
+	return release, nil
+}
+
+// tokenBucket is a token bucket rate limiter
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// wait takes a token, blocking until one is available or ctx is done
+func (b *tokenBucket) wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+
+		delay := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		b.mu.Unlock()
+
+		if err := utils.SleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// acquireLimits waits for both the module and the client-wide limits. The
+// module limits are taken first so that requests queued behind a saturated
+// module do not hold client-wide slots needed by other modules.
+func (c *Client) acquireLimits(ctx context.Context, module string) (func(), error) {
+	releaseModule, err := c.moduleLimits[module].acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	releaseClient, err := c.limits.acquire(ctx)
+	if err != nil {
+		releaseModule()
+		return nil, err
+	}
+
+	return func() {
+		releaseClient()
+		releaseModule()
+	}, nil
+}
+
+// versionPrefix matches the versioned API prefixes, e.g. "v6/" or
+// "v1/enterprise/"
+var versionPrefix = regexp.MustCompile(`^v\d+/(enterprise/)?`)
+
+// moduleOf returns the API module path of an endpoint, such as "images" for
+// ".../api/v6/images/text2img"
+func (c *Client) moduleOf(endpoint string) string {
+	path := strings.TrimPrefix(endpoint, c.baseURL)
+	if path == endpoint {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return ""
+		}
+		path = u.Path
+		if i := strings.Index(path, "/api/"); i >= 0 {
+			path = path[i+len("/api/"):]
+		}
+	}
+
+	path = versionPrefix.ReplaceAllString(strings.TrimPrefix(path, "/"), "")
+	if i := strings.Index(path, "/"); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLimiterRate(t *testing.T) {
+	if newLimiter(Limits{}) != nil {
+		t.Fatal("newLimiter without limits is not nil")
+	}
+
+	lim := newLimiter(Limits{RequestsPerSecond: 50, Burst: 2})
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		release, err := lim.acquire(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+
+	// The burst passes at once and the other two wait 20ms each
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond || elapsed > time.Second {
+		t.Errorf("4 requests at 50/s with a burst of 2 took %v, want about 40ms", elapsed)
+	}
+}
+
+func TestLimiterInFlight(t *testing.T) {
+	lim := newLimiter(Limits{MaxInFlight: 1})
+
+	release, err := lim.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := lim.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire() with the slot taken = %v, want context.DeadlineExceeded", err)
+	}
+
+	release()
+	release, err = lim.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire() after release = %v", err)
+	}
+	release()
+}
+
+func TestLimiterCancelReleasesSlot(t *testing.T) {
+	lim := newLimiter(Limits{RequestsPerSecond: 1, Burst: 1, MaxInFlight: 1})
+	release, err := lim.acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	release()
+
+	// Waiting for a token is cancelled, and the in-flight slot is freed
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := lim.acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("acquire() without tokens = %v, want context.DeadlineExceeded", err)
+	}
+	if n := len(lim.slots); n != 0 {
+		t.Errorf("%d slots held after a cancelled acquire, want 0", n)
+	}
+}
+
+func TestModuleOf(t *testing.T) {
+	c, err := NewClient(WithAPIKey("test-key"), WithBaseURL("https://modelslab.com/api/"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{endpoint: "https://modelslab.com/api/v6/images/text2img", want: "images"},
+		{endpoint: "https://modelslab.com/api/v1/enterprise/video/text2video", want: "video"},
+		{endpoint: "https://modelslab.com/api/v6/3d/text_to_3d", want: "3d"},
+		{endpoint: "https://cdn.example.com/api/v6/voice/fetch/42", want: "voice"},
+		{endpoint: "https://modelslab.com/api/v6/system_details", want: "system_details"},
+	}
+	for _, tt := range tests {
+		if got := c.moduleOf(tt.endpoint); got != tt.want {
+			t.Errorf("moduleOf(%q) = %q, want %q", tt.endpoint, got, tt.want)
+		}
+	}
+}
+
+func TestSaturatedModuleDoesNotBlockOthers(t *testing.T) {
+	c, err := NewClient(WithAPIKey("test-key"),
+		WithRateLimit(Limits{MaxInFlight: 2}),
+		WithModuleLimits("video", Limits{MaxInFlight: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	release, err := c.acquireLimits(context.Background(), "video")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	// A second video request queues behind the first
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queued := make(chan error, 1)
+	go func() {
+		release, err := c.acquireLimits(ctx, "video")
+		if err == nil {
+			release()
+		}
+		queued <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	imagesCtx, imagesCancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer imagesCancel()
+	releaseImages, err := c.acquireLimits(imagesCtx, "images")
+	if err != nil {
+		t.Fatalf("acquireLimits(images) with video saturated = %v, want a free client slot", err)
+	}
+	releaseImages()
+
+	cancel()
+	if err := <-queued; !errors.Is(err, context.Canceled) {
+		t.Errorf("queued video request = %v, want context.Canceled", err)
+	}
+}
+
+func TestOpenCircuitSkipsLimits(t *testing.T) {
+	c, err := NewClient(WithAPIKey("test-key"),
+		WithRateLimit(Limits{MaxInFlight: 1}),
+		WithCircuitBreaker(&BreakerConfig{FailureThreshold: 1, CoolDown: time.Minute}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	endpoint := "https://modelslab.com/api/v6/images/text2img"
+	key := breakerKey(endpoint, "images")
+	if err := c.breakers.allow(key); err != nil {
+		t.Fatal(err)
+	}
+	c.breakers.record(key, false)
+
+	release, err := c.acquireLimits(context.Background(), "images")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := c.Post(ctx, endpoint, nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Post() through an open circuit = %v, want ErrCircuitOpen without waiting for a slot", err)
+	}
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// A value of 1 disables retries.
+	MaxAttempts int `validate:"min=1,max=20"`
+	// BaseDelay is the delay before the first retry; it doubles on every
+	// following attempt
+	BaseDelay time.Duration `validate:"min=0"`
+	// MaxDelay caps the backoff delay and any Retry-After header
+	MaxDelay time.Duration `validate:"min=0"`
+	// Jitter is the fraction of the delay that is randomized, from 0 to 1
+	Jitter float64 `validate:"min=0,max=1"`
+	// RetryableStatusCodes lists the HTTP status codes that are retried
+	RetryableStatusCodes []int
+	// RetryNetworkErrors retries requests that failed before a response
+	// was received
+	RetryNetworkErrors bool
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is configured
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+		RetryableStatusCodes: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryNetworkErrors: true,
+	}
+}
+
+// NoRetry returns a retry policy that never retries
+func NoRetry() *RetryPolicy {
+	return &RetryPolicy{MaxAttempts: 1}
+}
+
+type retryPolicyKey struct{}
+
+// WithRetryPolicy returns a context that overrides the client's retry policy
+// for calls made with it
+func WithRetryPolicy(ctx context.Context, policy *RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, policy)
+}
+
+// retryPolicy returns the policy for a call, preferring a context override
+func (c *Client) retryPolicy(ctx context.Context) *RetryPolicy {
+	if policy, ok := ctx.Value(retryPolicyKey{}).(*RetryPolicy); ok && policy != nil {
+		return policy
+	}
+	return c.retry
+}
+
+// retryableStatus reports whether a response status code should be retried
+func (p *RetryPolicy) retryableStatus(code int) bool {
+	for _, c := range p.RetryableStatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+// retryableError reports whether a transport error should be retried
+func (p *RetryPolicy) retryableError(ctx context.Context, err error) bool {
+	if !p.RetryNetworkErrors || ctx.Err() != nil {
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// backoff returns the delay before the given retry (1 for the first retry).
+// A positive retryAfter from the server takes precedence over the computed
+// exponential delay.
+func (p *RetryPolicy) backoff(retry int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		if p.MaxDelay > 0 && retryAfter > p.MaxDelay {
+			return p.MaxDelay
+		}
+		return retryAfter
+	}
+
+	delay := float64(p.BaseDelay) * math.Pow(2, float64(retry-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay -= delay * p.Jitter * rand.Float64()
+	}
+	return time.Duration(delay)
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an
+// HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if d := time.Until(date); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,162 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/schemas/base"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		min   time.Duration
+		max   time.Duration
+	}{
+		{name: "empty", value: "", min: 0, max: 0},
+		{name: "seconds", value: "120", min: 2 * time.Minute, max: 2 * time.Minute},
+		{name: "zero seconds", value: "0", min: 0, max: 0},
+		{name: "negative seconds", value: "-5", min: 0, max: 0},
+		{name: "date", value: time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), min: 58 * time.Second, max: time.Minute},
+		{name: "past date", value: "Wed, 21 Oct 2015 07:28:00 GMT", min: 0, max: 0},
+		{name: "garbage", value: "soon", min: 0, max: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+				t.Errorf("parseRetryAfter(%q) = %v, want between %v and %v", tt.value, got, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := &RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	tests := []struct {
+		retry      int
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		{retry: 1, want: 100 * time.Millisecond},
+		{retry: 2, want: 200 * time.Millisecond},
+		{retry: 4, want: 800 * time.Millisecond},
+		{retry: 5, want: time.Second},
+		{retry: 1, retryAfter: 300 * time.Millisecond, want: 300 * time.Millisecond},
+		{retry: 1, retryAfter: time.Minute, want: time.Second},
+	}
+	for _, tt := range tests {
+		if got := p.backoff(tt.retry, tt.retryAfter); got != tt.want {
+			t.Errorf("backoff(%d, %v) = %v, want %v", tt.retry, tt.retryAfter, got, tt.want)
+		}
+	}
+
+	p.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if got := p.backoff(2, 0); got < 100*time.Millisecond || got > 200*time.Millisecond {
+			t.Fatalf("backoff with jitter = %v, want between 100ms and 200ms", got)
+		}
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		io.WriteString(w, `{"status": "success"}`)
+	}))
+	defer srv.Close()
+
+	policy := &RetryPolicy{
+		MaxAttempts:          2,
+		MaxDelay:             10 * time.Millisecond,
+		RetryableStatusCodes: []int{http.StatusTooManyRequests},
+	}
+	config := DefaultConfig()
+	config.APIKey = "test-key"
+	config.BaseURL = srv.URL
+	config.Retry = policy
+	c := NewWithConfig(config)
+
+	// The hour-long Retry-After is capped by MaxDelay
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := c.Post(ctx, srv.URL+"/v6/images/text2img", nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&attempts); n != 2 {
+		t.Errorf("server saw %d attempts, want 2", n)
+	}
+}
+
+func TestRetryResendsFileReaders(t *testing.T) {
+	seekable := strings.NewReader("skipped file contents")
+	seekable.Seek(int64(len("skipped ")), io.SeekStart)
+
+	tests := []struct {
+		name   string
+		reader io.Reader
+	}{
+		{name: "non-seekable", reader: io.MultiReader(strings.NewReader("file contents"))},
+		{name: "seekable", reader: seekable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var images []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var body struct {
+					Image string `json:"image"`
+				}
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("decoding body: %v", err)
+				}
+				mu.Lock()
+				images = append(images, body.Image)
+				first := len(images) == 1
+				mu.Unlock()
+				if first {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				io.WriteString(w, `{"status": "success"}`)
+			}))
+			defer srv.Close()
+
+			config := DefaultConfig()
+			config.APIKey = "test-key"
+			config.BaseURL = srv.URL
+			config.Retry = &RetryPolicy{
+				MaxAttempts:          2,
+				MaxDelay:             time.Millisecond,
+				RetryableStatusCodes: []int{http.StatusServiceUnavailable},
+			}
+			c := NewWithConfig(config)
+
+			data := &encodeFile{Image: base.FileInput{Reader: tt.reader, MIMEType: "text/plain"}}
+			if _, err := c.Post(context.Background(), srv.URL+"/v6/images/img2img", data); err != nil {
+				t.Fatal(err)
+			}
+
+			want := "data:text/plain;base64,ZmlsZSBjb250ZW50cw=="
+			if len(images) != 2 || images[0] != want || images[1] != want {
+				t.Errorf("attempts sent %q, want %q twice", images, want)
+			}
+			if rest, _ := io.ReadAll(tt.reader); tt.reader == seekable && string(rest) != "file contents" {
+				t.Errorf("seekable reader left at %q, want it where it was", rest)
+			}
+		})
+	}
+}
@@ -0,0 +1,337 @@
+package client
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/modelslab/modelslab-go/pkg/utils"
+)
+
+// Uploader stores media and returns a URL the API can download it from
+type Uploader interface {
+	// Upload stores size bytes read from r under name and returns the URL
+	// of the stored object
+	Upload(ctx context.Context, name, contentType string, size int64, r io.Reader) (string, error)
+}
+
+// Uploadable is implemented by request values holding local media that an
+// Uploader can replace with a hosted URL, such as base.FileInput
+type Uploadable interface {
+	// UploadSource returns the local contents with their file name and MIME
+	// type, if known. It returns a nil reader when there is nothing to
+	// upload.
+	UploadSource() (r io.ReadCloser, name, mimeType string, err error)
+	// SetUploadedURL makes the value refer to the uploaded copy
+	SetUploadedURL(url string)
+}
+
+// urlExpirer is implemented by uploaders whose URLs stop working after a
+// while, such as presigned S3 URLs
+type urlExpirer interface {
+	URLExpiry() time.Duration
+}
+
+var uploadableType = reflect.TypeOf((*Uploadable)(nil)).Elem()
+
+// uploads uploads local media through an Uploader, caching the resulting
+// URLs by content hash
+type uploads struct {
+	uploader Uploader
+	ttl      time.Duration
+
+	mu    sync.Mutex
+	cache map[string]*upload
+}
+
+// upload is a cached or in-flight upload
+type upload struct {
+	done      chan struct{}
+	url       string
+	err       error
+	expiresAt time.Time
+}
+
+// newUploads returns the upload cache for uploader, or nil when uploader is
+// nil. URLs are cached for ttl; a zero ttl caches them for half the
+// uploader's URL expiry, or for the lifetime of the client.
+func newUploads(uploader Uploader, ttl time.Duration) *uploads {
+	if uploader == nil {
+		return nil
+	}
+
+	if ttl == 0 {
+		if expirer, ok := uploader.(urlExpirer); ok {
+			ttl = expirer.URLExpiry() / 2
+		}
+	}
+	return &uploads{
+		uploader: uploader,
+		ttl:      ttl,
+		cache:    make(map[string]*upload),
+	}
+}
+
+// walk returns v with the Uploadable values found in it pointing at their
+// uploaded copies. v itself is left unchanged: the values on the path to each
+// upload are copied, and changed reports whether anything was replaced.
+// Uploadable values are still read through v, so readers keep track of their
+// position across calls.
+func (u *uploads) walk(ctx context.Context, v reflect.Value) (out reflect.Value, changed bool, err error) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v, false, nil
+		}
+		if v.Type().Implements(uploadableType) {
+			url, err := u.upload(ctx, v.Interface().(Uploadable))
+			if err != nil || url == "" {
+				return v, false, err
+			}
+			cp := reflect.New(v.Type().Elem())
+			cp.Elem().Set(v.Elem())
+			cp.Interface().(Uploadable).SetUploadedURL(url)
+			return cp, true, nil
+		}
+		elem, changed, err := u.walk(ctx, v.Elem())
+		if err != nil || !changed {
+			return v, false, err
+		}
+		cp := reflect.New(v.Type().Elem())
+		cp.Elem().Set(elem)
+		return cp, true, nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return v, false, nil
+		}
+		elem, changed, err := u.walk(ctx, v.Elem())
+		if err != nil || !changed {
+			return v, false, err
+		}
+		cp := reflect.New(v.Type()).Elem()
+		cp.Set(elem)
+		return cp, true, nil
+	case reflect.Struct:
+		if v.CanAddr() && v.Addr().Type().Implements(uploadableType) {
+			url, err := u.upload(ctx, v.Addr().Interface().(Uploadable))
+			if err != nil || url == "" {
+				return v, false, err
+			}
+			cp := reflect.New(v.Type())
+			cp.Elem().Set(v)
+			cp.Interface().(Uploadable).SetUploadedURL(url)
+			return cp.Elem(), true, nil
+		}
+		var cp reflect.Value
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			field, changed, err := u.walk(ctx, v.Field(i))
+			if err != nil {
+				return v, false, err
+			}
+			if !changed {
+				continue
+			}
+			if !cp.IsValid() {
+				cp = reflect.New(v.Type()).Elem()
+				cp.Set(v)
+			}
+			cp.Field(i).Set(field)
+		}
+		if cp.IsValid() {
+			return cp, true, nil
+		}
+	case reflect.Slice, reflect.Array:
+		var cp reflect.Value
+		for i := 0; i < v.Len(); i++ {
+			elem, changed, err := u.walk(ctx, v.Index(i))
+			if err != nil {
+				return v, false, err
+			}
+			if !changed {
+				continue
+			}
+			if !cp.IsValid() {
+				if v.Kind() == reflect.Slice {
+					cp = reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+					reflect.Copy(cp, v)
+				} else {
+					cp = reflect.New(v.Type()).Elem()
+					cp.Set(v)
+				}
+			}
+			cp.Index(i).Set(elem)
+		}
+		if cp.IsValid() {
+			return cp, true, nil
+		}
+	}
+	return v, false, nil
+}
+
+// upload uploads the contents of f, or reuses a cached upload of the same
+// contents, and returns the resulting URL. It returns "" when f has nothing
+// to upload.
+func (u *uploads) upload(ctx context.Context, f Uploadable) (string, error) {
+	r, name, mimeType, err := f.UploadSource()
+	if err != nil {
+		return "", err
+	}
+	if r == nil {
+		return "", nil
+	}
+	defer r.Close()
+
+	src, cleanup, err := utils.Seekable(r)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
+
+	start, err := src.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return "", fmt.Errorf("failed to seek upload: %w", err)
+	}
+
+	// Hash the contents, keeping the head to detect the MIME type
+	hash := sha256.New()
+	head := make([]byte, 3072)
+	n, err := io.ReadFull(src, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return "", fmt.Errorf("failed to read upload: %w", err)
+	}
+	hash.Write(head[:n])
+	rest, err := io.Copy(hash, src)
+	if err != nil {
+		return "", fmt.Errorf("failed to read upload: %w", err)
+	}
+	size := int64(n) + rest
+	sum := hex.EncodeToString(hash.Sum(nil))
+
+	mtype := mimetype.Lookup(mimeType)
+	if mimeType == "" {
+		mtype = mimetype.Detect(head[:n])
+		mimeType = mtype.String()
+	}
+
+	url, err := u.get(ctx, sum, func() (string, error) {
+		if _, err := src.Seek(start, io.SeekStart); err != nil {
+			return "", fmt.Errorf("failed to seek upload: %w", err)
+		}
+		return u.uploader.Upload(ctx, objectName(sum, name, mtype), mimeType, size, src)
+	})
+	if err != nil {
+		return "", fmt.Errorf("upload failed: %w", err)
+	}
+	return url, nil
+}
+
+// get returns the cached URL for the content hash sum, running upload when
+// there is none. Concurrent calls for the same contents share one upload.
+func (u *uploads) get(ctx context.Context, sum string, upload func() (string, error)) (string, error) {
+	for {
+		u.mu.Lock()
+		entry, ok := u.cache[sum]
+		if ok {
+			select {
+			case <-entry.done:
+				if entry.err == nil && (entry.expiresAt.IsZero() || time.Now().Before(entry.expiresAt)) {
+					u.mu.Unlock()
+					return entry.url, nil
+				}
+				// Failed or expired: upload again
+				ok = false
+			default:
+			}
+		}
+		if !ok {
+			entry = u.start(sum)
+			u.mu.Unlock()
+			return u.finish(sum, entry, upload)
+		}
+		u.mu.Unlock()
+
+		select {
+		case <-entry.done:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+}
+
+// start registers an in-flight upload. The caller must hold u.mu.
+func (u *uploads) start(sum string) *upload {
+	entry := &upload{done: make(chan struct{})}
+	u.cache[sum] = entry
+	return entry
+}
+
+// finish runs upload and publishes its outcome to waiting callers
+func (u *uploads) finish(sum string, entry *upload, upload func() (string, error)) (string, error) {
+	entry.url, entry.err = upload()
+	if entry.err == nil && u.ttl > 0 {
+		entry.expiresAt = time.Now().Add(u.ttl)
+	}
+
+	u.mu.Lock()
+	if entry.err != nil && u.cache[sum] == entry {
+		delete(u.cache, sum)
+	}
+	u.mu.Unlock()
+
+	close(entry.done)
+	return entry.url, entry.err
+}
+
+// objectName names an upload after its content hash, keeping the extension
+// of the original file name or the detected type
+func objectName(sum, name string, mtype *mimetype.MIME) string {
+	ext := filepath.Ext(name)
+	if ext == "" && mtype != nil {
+		ext = mtype.Extension()
+	}
+	return sum + ext
+}
+
+// UploadFiles uploads the local file inputs reachable from data through the
+// configured Uploader and replaces them with their URLs. Inputs are updated in
+// place, so data must be a pointer for them to be found. It does nothing when
+// no Uploader is configured. Post does not change its data; it sends an
+// uploaded copy instead.
+func (c *Client) UploadFiles(ctx context.Context, data interface{}) error {
+	if c.uploads == nil || data == nil {
+		return nil
+	}
+	v := reflect.ValueOf(data)
+	out, changed, err := c.uploads.walk(ctx, v)
+	if err != nil || !changed {
+		return err
+	}
+	if v.Kind() == reflect.Ptr {
+		v.Elem().Set(out.Elem())
+	}
+	return nil
+}
+
+// uploaded returns data with its local file inputs replaced by the URLs of
+// their uploads, leaving data unchanged. It returns data itself when there is
+// nothing to upload.
+func (c *Client) uploaded(ctx context.Context, data interface{}) (interface{}, error) {
+	if c.uploads == nil || data == nil {
+		return data, nil
+	}
+	out, changed, err := c.uploads.walk(ctx, reflect.ValueOf(data))
+	if err != nil || !changed {
+		return data, err
+	}
+	return out.Interface(), nil
+}
@@ -0,0 +1,124 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// LocalUploader stores uploads in a temporary directory and serves them from
+// its own HTTP server. It is meant for tests and local development against a
+// server that can reach this machine, e.g. a mock API or a tunnel.
+type LocalUploader struct {
+	dir     string
+	baseURL string
+	server  *http.Server
+
+	mu    sync.RWMutex
+	types map[string]string
+}
+
+// NewLocalUploader starts a file server listening on addr, such as
+// "127.0.0.1:0" for a random local port. publicURL is the base URL the API
+// reaches the server at; when empty, the listener address is used.
+func NewLocalUploader(addr, publicURL string) (*LocalUploader, error) {
+	if addr == "" {
+		addr = "127.0.0.1:0"
+	}
+
+	dir, err := os.MkdirTemp("", "modelslab-uploads-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	if publicURL == "" {
+		publicURL = "http://" + listener.Addr().String()
+	}
+
+	u := &LocalUploader{
+		dir:     dir,
+		baseURL: strings.TrimSuffix(publicURL, "/") + "/",
+		types:   make(map[string]string),
+	}
+	u.server = &http.Server{Handler: u}
+	go u.server.Serve(listener)
+
+	return u, nil
+}
+
+// Upload stores the file and returns its URL on the local server
+func (u *LocalUploader) Upload(ctx context.Context, name, contentType string, size int64, r io.Reader) (string, error) {
+	name = path.Base("/" + name)
+	if name == "/" || name == "." {
+		return "", fmt.Errorf("invalid upload name")
+	}
+
+	file, err := os.Create(filepath.Join(u.dir, name))
+	if err != nil {
+		return "", fmt.Errorf("failed to store upload: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, r); err != nil {
+		return "", fmt.Errorf("failed to store upload: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to store upload: %w", err)
+	}
+
+	u.mu.Lock()
+	u.types[name] = contentType
+	u.mu.Unlock()
+
+	return u.baseURL + url.PathEscape(name), nil
+}
+
+// ServeHTTP serves the stored uploads
+func (u *LocalUploader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Base(r.URL.Path)
+
+	u.mu.RLock()
+	contentType, ok := u.types[name]
+	u.mu.RUnlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	http.ServeFile(w, r, filepath.Join(u.dir, name))
+}
+
+// URL returns the base URL uploads are served from
+func (u *LocalUploader) URL() string {
+	return u.baseURL
+}
+
+// Close stops the server and deletes the stored uploads
+func (u *LocalUploader) Close() error {
+	err := u.server.Close()
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	if rmErr := os.RemoveAll(u.dir); err == nil {
+		err = rmErr
+	}
+	return err
+}
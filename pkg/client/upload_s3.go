@@ -0,0 +1,309 @@
+package client
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// s3Algorithm is the AWS Signature Version 4 algorithm name
+	s3Algorithm = "AWS4-HMAC-SHA256"
+	// s3UnsignedPayload is sent in place of the payload hash so uploads
+	// are streamed without hashing them twice
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+	// s3MaxPresignExpiry is the longest validity of a presigned URL
+	s3MaxPresignExpiry = 7 * 24 * time.Hour
+)
+
+// S3Config configures an S3Uploader
+type S3Config struct {
+	// Endpoint is the S3 endpoint, such as
+	// "https://s3.us-east-1.amazonaws.com" or the endpoint of an
+	// S3-compatible service
+	Endpoint string `validate:"required,url"`
+	// Region is the bucket region; S3-compatible services that ignore
+	// regions usually accept "auto" or "us-east-1"
+	Region string `validate:"required"`
+	// Bucket is the bucket uploads are stored in
+	Bucket string `validate:"required"`
+	// AccessKeyID and SecretAccessKey are the credentials used to sign
+	// requests
+	AccessKeyID     string `validate:"required"`
+	SecretAccessKey string `validate:"required"`
+	// SessionToken is set when using temporary credentials
+	SessionToken string
+	// Prefix is prepended to object keys, e.g. "modelslab/"
+	Prefix string
+	// PublicBaseURL, when set, is the public location of the bucket, such
+	// as a CDN, and URLs are built from it. Otherwise presigned GET URLs
+	// are returned.
+	PublicBaseURL string `validate:"omitempty,url"`
+	// PresignExpiry is the validity of presigned URLs; it defaults to 24
+	// hours and is capped at 7 days
+	PresignExpiry time.Duration `validate:"min=0"`
+	// PathStyle addresses the bucket as a path on the endpoint instead of
+	// a subdomain; most S3-compatible services require it
+	PathStyle bool
+	// HTTPClient replaces http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// S3Uploader uploads files to an S3-compatible bucket
+type S3Uploader struct {
+	config   S3Config
+	endpoint *url.URL
+}
+
+// NewS3Uploader creates an uploader for an S3-compatible bucket
+func NewS3Uploader(config S3Config) (*S3Uploader, error) {
+	if err := validator.New().Struct(&config); err != nil {
+		return nil, fmt.Errorf("invalid S3 configuration: %w", err)
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(config.Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+
+	if config.PresignExpiry <= 0 {
+		config.PresignExpiry = 24 * time.Hour
+	}
+	if config.PresignExpiry > s3MaxPresignExpiry {
+		config.PresignExpiry = s3MaxPresignExpiry
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+
+	return &S3Uploader{
+		config:   config,
+		endpoint: endpoint,
+	}, nil
+}
+
+// Upload stores the file with a PUT request and returns its URL
+func (u *S3Uploader) Upload(ctx context.Context, name, contentType string, size int64, r io.Reader) (string, error) {
+	objectURL := u.objectURL(u.config.Prefix + name)
+
+	// The reader is wrapped so the transport does not close it
+	body := io.NopCloser(r)
+	if size == 0 {
+		body = http.NoBody
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL.String(), body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create S3 request: %w", err)
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	u.sign(req, time.Now().UTC())
+
+	resp, err := u.config.HTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("S3 request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("S3 upload failed with status %d: %s", resp.StatusCode, body)
+	}
+
+	if u.config.PublicBaseURL != "" {
+		return strings.TrimSuffix(u.config.PublicBaseURL, "/") + "/" + s3EscapePath(u.config.Prefix+name), nil
+	}
+	return u.presign(objectURL, time.Now().UTC()), nil
+}
+
+// URLExpiry returns how long the returned URLs stay valid, or 0 when they
+// do not expire
+func (u *S3Uploader) URLExpiry() time.Duration {
+	if u.config.PublicBaseURL != "" {
+		return 0
+	}
+	return u.config.PresignExpiry
+}
+
+// objectURL returns the URL of an object key
+func (u *S3Uploader) objectURL(key string) *url.URL {
+	objectURL := *u.endpoint
+	if u.config.PathStyle {
+		objectURL.Path = strings.TrimSuffix(objectURL.Path, "/") + "/" + u.config.Bucket + "/" + key
+	} else {
+		objectURL.Host = u.config.Bucket + "." + objectURL.Host
+		objectURL.Path = strings.TrimSuffix(objectURL.Path, "/") + "/" + key
+	}
+	objectURL.RawPath = s3EscapePath(objectURL.Path)
+	return &objectURL
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req
+func (u *S3Uploader) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+	if u.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", u.config.SessionToken)
+	}
+
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"content-length":       strconv.FormatInt(req.ContentLength, 10),
+		"x-amz-date":           amzDate,
+		"x-amz-content-sha256": s3UnsignedPayload,
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		headers["content-type"] = contentType
+	}
+	if u.config.SessionToken != "" {
+		headers["x-amz-security-token"] = u.config.SessionToken
+	}
+
+	canonicalRequest, signedHeaders := s3CanonicalRequest(req.Method, req.URL.EscapedPath(), "", headers, s3UnsignedPayload)
+
+	scope, signature := u.signature(now, canonicalRequest)
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3Algorithm, u.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// presign returns a presigned GET URL for an object
+func (u *S3Uploader) presign(objectURL *url.URL, now time.Time) string {
+	amzDate := now.Format("20060102T150405Z")
+	scope := u.scope(now)
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", s3Algorithm)
+	query.Set("X-Amz-Credential", u.config.AccessKeyID+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", strconv.Itoa(int(u.config.PresignExpiry/time.Second)))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if u.config.SessionToken != "" {
+		query.Set("X-Amz-Security-Token", u.config.SessionToken)
+	}
+	canonicalQuery := s3CanonicalQuery(query)
+
+	canonicalRequest, _ := s3CanonicalRequest(http.MethodGet, objectURL.EscapedPath(), canonicalQuery,
+		map[string]string{"host": objectURL.Host}, s3UnsignedPayload)
+
+	_, signature := u.signature(now, canonicalRequest)
+
+	presigned := *objectURL
+	presigned.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+	return presigned.String()
+}
+
+// scope returns the credential scope for a signing time
+func (u *S3Uploader) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + u.config.Region + "/s3/aws4_request"
+}
+
+// signature signs a canonical request and returns the credential scope and
+// the hex signature
+func (u *S3Uploader) signature(now time.Time, canonicalRequest string) (string, string) {
+	key := hmacSHA256([]byte("AWS4"+u.config.SecretAccessKey), now.Format("20060102"))
+	key = hmacSHA256(key, u.config.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return u.scope(now), hex.EncodeToString(hmacSHA256(key, u.stringToSign(now, canonicalRequest)))
+}
+
+// stringToSign returns the Signature Version 4 string to sign for a
+// canonical request
+func (u *S3Uploader) stringToSign(now time.Time, canonicalRequest string) string {
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	return strings.Join([]string{
+		s3Algorithm,
+		now.Format("20060102T150405Z"),
+		u.scope(now),
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+}
+
+// s3CanonicalRequest returns the Signature Version 4 canonical request and
+// the signed header list. headers maps lower-case names to values; all of
+// them are signed.
+func s3CanonicalRequest(method, path, canonicalQuery string, headers map[string]string, payloadHash string) (string, string) {
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	return strings.Join([]string{
+		method,
+		path,
+		canonicalQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n"), signedHeaders
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3CanonicalQuery encodes query parameters sorted by name, as required by
+// Signature Version 4
+func s3CanonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		for _, value := range query[name] {
+			parts = append(parts, s3Escape(name, true)+"="+s3Escape(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// s3EscapePath escapes an object path, keeping the slashes
+func s3EscapePath(path string) string {
+	return s3Escape(path, false)
+}
+
+// s3Escape percent-encodes every byte except the unreserved characters, and
+// slashes unless escapeSlash is set
+func s3Escape(s string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !escapeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,183 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/modelslab/modelslab-go/pkg/schemas/base"
+)
+
+// memoryUploader records uploads and returns fake URLs for them
+type memoryUploader struct {
+	mu      sync.Mutex
+	uploads map[string][]byte
+}
+
+func (u *memoryUploader) Upload(ctx context.Context, name, contentType string, size int64, r io.Reader) (string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) != size {
+		return "", fmt.Errorf("read %d bytes, want %d", len(data), size)
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.uploads == nil {
+		u.uploads = make(map[string][]byte)
+	}
+	u.uploads[name] = data
+	return "https://cdn.example.com/" + name, nil
+}
+
+func (u *memoryUploader) count() int {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return len(u.uploads)
+}
+
+type uploadRequest struct {
+	Prompt string            `json:"prompt"`
+	Image  *base.FileInput   `json:"image,omitempty"`
+	Mask   base.FileInput    `json:"mask"`
+	Extra  []*base.FileInput `json:"extra,omitempty"`
+}
+
+// uploadTestClient returns a client posting to a server that records the
+// bodies it receives
+func uploadTestClient(t *testing.T, uploader Uploader) (*Client, func() []map[string]interface{}) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var bodies []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+		mu.Lock()
+		bodies = append(bodies, body)
+		mu.Unlock()
+		io.WriteString(w, `{"status": "success"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	c, err := NewClient(WithAPIKey("test-key"), WithBaseURL(srv.URL), WithUploader(uploader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, func() []map[string]interface{} {
+		mu.Lock()
+		defer mu.Unlock()
+		return bodies
+	}
+}
+
+func TestPostUploadsCopy(t *testing.T) {
+	uploader := &memoryUploader{}
+	c, bodies := uploadTestClient(t, uploader)
+
+	req := &uploadRequest{
+		Prompt: "a cat",
+		Image:  &base.FileInput{Reader: strings.NewReader("\x89PNG image"), MIMEType: "image/png"},
+		Mask:   base.FileInput{Reader: strings.NewReader("\x89PNG mask"), MIMEType: "image/png"},
+		Extra: []*base.FileInput{
+			base.FileFromURL("https://example.com/hosted.png"),
+			{Reader: strings.NewReader("\x89PNG extra"), MIMEType: "image/png"},
+		},
+	}
+	image, extra := req.Image, req.Extra
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Post(context.Background(), c.baseURL+"test", req); err != nil {
+			t.Fatalf("post %d: %v", i, err)
+		}
+	}
+
+	if req.Image != image || req.Image.URL != nil || req.Mask.URL != nil || req.Extra[1].URL != nil || &req.Extra[0] != &extra[0] {
+		t.Errorf("Post changed the request: %+v", req)
+	}
+	if n := uploader.count(); n != 3 {
+		t.Errorf("uploaded %d files, want 3", n)
+	}
+
+	for i, body := range bodies() {
+		for _, field := range []string{"image", "mask"} {
+			if url, _ := body[field].(string); !strings.HasPrefix(url, "https://cdn.example.com/") {
+				t.Errorf("post %d sent %s = %.40q, want an uploaded URL", i, field, body[field])
+			}
+		}
+		extra, _ := body["extra"].([]interface{})
+		if len(extra) != 2 || extra[0] != "https://example.com/hosted.png" ||
+			!strings.HasPrefix(fmt.Sprint(extra[1]), "https://cdn.example.com/") {
+			t.Errorf("post %d sent extra = %v", i, body["extra"])
+		}
+		if body["key"] != "test-key" || body["prompt"] != "a cat" {
+			t.Errorf("post %d sent %v", i, body)
+		}
+	}
+}
+
+func TestPostUploadsConcurrently(t *testing.T) {
+	uploader := &memoryUploader{}
+	c, bodies := uploadTestClient(t, uploader)
+
+	// A shared input is safe to post from several goroutines, unless it
+	// wraps a reader
+	path := filepath.Join(t.TempDir(), "shared.png")
+	if err := os.WriteFile(path, []byte("\x89PNG shared"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	req := &uploadRequest{Image: &base.FileInput{FilePath: &path}}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Post(context.Background(), c.baseURL+"test", &uploadRequest{Prompt: "p", Image: req.Image}); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if req.Image.URL != nil {
+		t.Errorf("Post changed the shared input")
+	}
+	if n := uploader.count(); n != 1 {
+		t.Errorf("uploaded %d files, want 1", n)
+	}
+	if len(bodies()) != 8 {
+		t.Errorf("sent %d requests, want 8", len(bodies()))
+	}
+}
+
+func TestUploadFilesInPlace(t *testing.T) {
+	uploader := &memoryUploader{}
+	c, _ := uploadTestClient(t, uploader)
+
+	req := &uploadRequest{
+		Image: &base.FileInput{Reader: strings.NewReader("\x89PNG image"), MIMEType: "image/png"},
+		Mask:  base.FileInput{Reader: strings.NewReader("\x89PNG mask"), MIMEType: "image/png"},
+	}
+	if err := c.UploadFiles(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, f := range map[string]*base.FileInput{"image": req.Image, "mask": &req.Mask} {
+		if f.URL == nil || !strings.HasPrefix(*f.URL, "https://cdn.example.com/") || !strings.HasSuffix(*f.URL, ".png") {
+			t.Errorf("%s URL = %v", name, f.URL)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+// Package testutil holds audio fixtures shared by the SDK's tests
+package testutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// Tone is a stretch of sine at a peak level in dBFS; a level of -Inf is silence
+type Tone struct {
+	Level    float64
+	Duration time.Duration
+}
+
+// Sine returns interleaved samples of a freq Hz tone whose peak is level dBFS
+// on every channel
+func Sine(sampleRate, channels int, freq, level float64, d time.Duration) []float32 {
+	frames := int(d.Seconds() * float64(sampleRate))
+	samples := make([]float32, frames*channels)
+	peak := math.Pow(10, level/20)
+	for i := 0; i < frames; i++ {
+		v := float32(peak * math.Sin(2*math.Pi*freq*float64(i)/float64(sampleRate)))
+		for c := 0; c < channels; c++ {
+			samples[i*channels+c] = v
+		}
+	}
+	return samples
+}
+
+// Tones joins tones of freq Hz into one run of interleaved samples
+func Tones(sampleRate, channels int, freq float64, tones ...Tone) []float32 {
+	var samples []float32
+	for _, t := range tones {
+		samples = append(samples, Sine(sampleRate, channels, freq, t.Level, t.Duration)...)
+	}
+	return samples
+}
+
+// WAV encodes interleaved samples as a 16-bit PCM WAV file
+func WAV(sampleRate, channels int, samples []float32) []byte {
+	data := make([]byte, 2*len(samples))
+	for i, s := range samples {
+		v := math.Max(-1, math.Min(1, float64(s)))
+		binary.LittleEndian.PutUint16(data[2*i:], uint16(int16(math.Round(v*math.MaxInt16))))
+	}
+	return WAVFile(WAVFmt(1, channels, sampleRate, 16, 0), data)
+}
+
+// WAVFile builds a WAV file from a fmt chunk body and sample data
+func WAVFile(fmtChunk, data []byte) []byte {
+	var b bytes.Buffer
+	b.WriteString("RIFF")
+	binary.Write(&b, binary.LittleEndian, uint32(4+8+len(fmtChunk)+8+len(data)))
+	b.WriteString("WAVEfmt ")
+	binary.Write(&b, binary.LittleEndian, uint32(len(fmtChunk)))
+	b.Write(fmtChunk)
+	b.WriteString("data")
+	binary.Write(&b, binary.LittleEndian, uint32(len(data)))
+	b.Write(data)
+	return b.Bytes()
+}
+
+// WAVFmt builds a fmt chunk body, padded with extra bytes
+func WAVFmt(format, channels, sampleRate, bits, extra int) []byte {
+	b := make([]byte, 16+extra)
+	binary.LittleEndian.PutUint16(b[0:2], uint16(format))
+	binary.LittleEndian.PutUint16(b[2:4], uint16(channels))
+	binary.LittleEndian.PutUint32(b[4:8], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(b[8:12], uint32(sampleRate*channels*bits/8))
+	binary.LittleEndian.PutUint16(b[12:14], uint16(channels*bits/8))
+	binary.LittleEndian.PutUint16(b[14:16], uint16(bits))
+	return b
+}
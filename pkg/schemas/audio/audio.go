@@ -92,12 +92,17 @@ type SongGeneratorRequest struct {
 	Lyrics           *string         `json:"lyrics,omitempty"`
 }
 
-// Speech2TextRequest represents a speech-to-text conversion request
+// Speech2TextRequest represents a speech-to-text conversion request. The
+// audio is given either as a hosted AudioURL or as a local Audio file in WAV,
+// MP3, FLAC or M4A format.
 type Speech2TextRequest struct {
 	base.BaseRequest
-	AudioURL       string  `json:"audio_url" validate:"required,url"`
-	InputLanguage  *string `json:"input_language,omitempty"`
-	TimestampLevel *string `json:"timestamp_level,omitempty" validate:"omitempty,oneof=word sentence"`
+	AudioURL string `json:"audio_url" validate:"required_without=Audio,excluded_with=Audio,omitempty,url"`
+	// Audio is uploaded through the client's Uploader when one is
+	// configured, and sent inline otherwise
+	Audio          *base.FileInput `json:"-"`
+	InputLanguage  *string         `json:"input_language,omitempty"`
+	TimestampLevel *string         `json:"timestamp_level,omitempty" validate:"omitempty,oneof=word sentence"`
 }
 
 // SFXRequest represents a sound effects generation request
@@ -0,0 +1,300 @@
+package audio
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// CaptionOptions controls how a transcript is split into caption cues
+type CaptionOptions struct {
+	// MaxLineLength wraps cue text at this many characters; 0 disables
+	// wrapping
+	MaxLineLength int
+	// MaxLines is the most lines a cue may hold; longer text continues in
+	// the next cue. 0 means unlimited.
+	MaxLines int
+	// MaxDuration splits cues that would last longer; 0 disables splitting
+	MaxDuration time.Duration
+	// MergeWords joins word-level timestamps, as returned for
+	// TimestampLevel "word", into sentence cues
+	MergeWords bool
+	// MaxGap ends a merged sentence early at a pause longer than this; 0
+	// disables the check
+	MaxGap time.Duration
+}
+
+// DefaultCaptionOptions returns options following common subtitle guidelines
+func DefaultCaptionOptions() *CaptionOptions {
+	return &CaptionOptions{
+		MaxLineLength: 42,
+		MaxLines:      2,
+		MaxDuration:   7 * time.Second,
+		MaxGap:        1500 * time.Millisecond,
+	}
+}
+
+// Cue is a caption shown between Start and End
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	// Text holds the cue lines separated by newlines
+	Text string
+}
+
+// timedWord is a word with its timing while cues are built
+type timedWord struct {
+	text  string
+	start time.Duration
+	end   time.Duration
+	// brk ends the cue after this word
+	brk bool
+}
+
+// Cues splits the transcript into caption cues. When opts is nil,
+// DefaultCaptionOptions is used.
+func (r *TranscriptionResponse) Cues(opts *CaptionOptions) []Cue {
+	if opts == nil {
+		opts = DefaultCaptionOptions()
+	}
+
+	var words []timedWord
+	if opts.MergeWords {
+		words = mergedWords(r.Timestamps, opts.MaxGap)
+	} else {
+		words = segmentWords(r.Timestamps)
+	}
+
+	var cues []Cue
+	var current []timedWord
+	flush := func() {
+		if len(current) == 0 {
+			return
+		}
+		cues = append(cues, Cue{
+			Start: current[0].start,
+			End:   current[len(current)-1].end,
+			Text:  strings.Join(wrapText(joinWords(current), opts.MaxLineLength), "\n"),
+		})
+		current = nil
+	}
+
+	for _, w := range words {
+		if len(current) > 0 && !fits(append(current, w), opts) {
+			flush()
+		}
+		current = append(current, w)
+		if w.brk {
+			flush()
+		}
+	}
+	flush()
+	return cues
+}
+
+// fits reports whether words fit in a single cue
+func fits(words []timedWord, opts *CaptionOptions) bool {
+	if opts.MaxDuration > 0 && words[len(words)-1].end-words[0].start > opts.MaxDuration {
+		return false
+	}
+	if opts.MaxLines > 0 && opts.MaxLineLength > 0 {
+		return len(wrapText(joinWords(words), opts.MaxLineLength)) <= opts.MaxLines
+	}
+	return true
+}
+
+// segmentWords splits each segment into words, spreading the segment time
+// over them by length. Cues never span segments.
+func segmentWords(segments []TranscriptionSegment) []timedWord {
+	var words []timedWord
+	for _, seg := range segments {
+		fields := strings.Fields(seg.Text)
+		if len(fields) == 0 {
+			continue
+		}
+
+		start, end := seconds(seg.StartTime), seconds(seg.EndTime)
+		total := 0
+		for _, f := range fields {
+			total += utf8.RuneCountInString(f)
+		}
+
+		done := 0
+		for i, f := range fields {
+			wordStart := start + time.Duration(float64(end-start)*float64(done)/float64(total))
+			done += utf8.RuneCountInString(f)
+			wordEnd := start + time.Duration(float64(end-start)*float64(done)/float64(total))
+			words = append(words, timedWord{
+				text:  f,
+				start: wordStart,
+				end:   wordEnd,
+				brk:   i == len(fields)-1,
+			})
+		}
+	}
+	return words
+}
+
+// mergedWords treats each segment as a word and breaks after the end of each
+// sentence or at pauses longer than maxGap
+func mergedWords(segments []TranscriptionSegment, maxGap time.Duration) []timedWord {
+	var words []timedWord
+	for _, seg := range segments {
+		text := strings.TrimSpace(seg.Text)
+		if text == "" {
+			continue
+		}
+
+		start := seconds(seg.StartTime)
+		if n := len(words); n > 0 && maxGap > 0 && start-words[n-1].end > maxGap {
+			words[n-1].brk = true
+		}
+		words = append(words, timedWord{
+			text:  text,
+			start: start,
+			end:   seconds(seg.EndTime),
+			brk:   endsSentence(text),
+		})
+	}
+	return words
+}
+
+// endsSentence reports whether a word ends with sentence punctuation
+func endsSentence(word string) bool {
+	word = strings.TrimRight(word, `"')]}»”’`)
+	r, _ := utf8.DecodeLastRuneInString(word)
+	switch r {
+	case '.', '!', '?', '…', '。', '！', '？':
+		return true
+	}
+	return false
+}
+
+// closingPunct lists the characters that attach to the preceding word
+const closingPunct = ",.!?;:)]}%…'’”」、。，！？"
+
+// joinWords joins words with spaces, except before punctuation that the
+// recogniser returned as separate words
+func joinWords(words []timedWord) string {
+	var b strings.Builder
+	for i, w := range words {
+		if i > 0 {
+			if r, _ := utf8.DecodeRuneInString(w.text); !strings.ContainsRune(closingPunct, r) {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(w.text)
+	}
+	return b.String()
+}
+
+// wrapText breaks text into lines of at most width characters, keeping words
+// whole. A width of 0 returns the text as a single line.
+func wrapText(text string, width int) []string {
+	if width <= 0 {
+		return []string{text}
+	}
+
+	var lines []string
+	var line strings.Builder
+	lineLen := 0
+	for _, word := range strings.Fields(text) {
+		n := utf8.RuneCountInString(word)
+		if lineLen > 0 && lineLen+1+n > width {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineLen = 0
+		}
+		if lineLen > 0 {
+			line.WriteByte(' ')
+			lineLen++
+		}
+		line.WriteString(word)
+		lineLen += n
+	}
+	if lineLen > 0 || len(lines) == 0 {
+		lines = append(lines, line.String())
+	}
+	return lines
+}
+
+// seconds converts a timestamp in seconds to a duration, rounded to the
+// millisecond
+func seconds(s float64) time.Duration {
+	return time.Duration(math.Round(s*1000)) * time.Millisecond
+}
+
+// SRT renders the transcript as SubRip subtitles
+func (r *TranscriptionResponse) SRT(opts *CaptionOptions) string {
+	var b strings.Builder
+	for i, cue := range r.Cues(opts) {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1,
+			formatTimestamp(cue.Start, ','), formatTimestamp(cue.End, ','), cue.Text)
+	}
+	return b.String()
+}
+
+// WebVTT renders the transcript as WebVTT captions
+func (r *TranscriptionResponse) WebVTT(opts *CaptionOptions) string {
+	escaper := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, cue := range r.Cues(opts) {
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n",
+			formatTimestamp(cue.Start, '.'), formatTimestamp(cue.End, '.'), escaper.Replace(cue.Text))
+	}
+	return b.String()
+}
+
+// Text returns the transcript as plain text, falling back to the timestamped
+// segments when the response has no transcription
+func (r *TranscriptionResponse) Text() string {
+	if text := strings.TrimSpace(r.Transcription); text != "" {
+		return text
+	}
+
+	words := make([]timedWord, 0, len(r.Timestamps))
+	for _, seg := range r.Timestamps {
+		if text := strings.TrimSpace(seg.Text); text != "" {
+			words = append(words, timedWord{text: text})
+		}
+	}
+	return joinWords(words)
+}
+
+// TranscriptJSON renders the transcript text and its cues as JSON, with
+// times in seconds
+func (r *TranscriptionResponse) TranscriptJSON(opts *CaptionOptions) ([]byte, error) {
+	type jsonCue struct {
+		Start float64 `json:"start"`
+		End   float64 `json:"end"`
+		Text  string  `json:"text"`
+	}
+
+	cues := r.Cues(opts)
+	out := struct {
+		Text string    `json:"text"`
+		Cues []jsonCue `json:"cues"`
+	}{
+		Text: r.Text(),
+		Cues: make([]jsonCue, len(cues)),
+	}
+	for i, cue := range cues {
+		out.Cues[i] = jsonCue{Start: cue.Start.Seconds(), End: cue.End.Seconds(), Text: cue.Text}
+	}
+
+	return json.MarshalIndent(out, "", "  ")
+}
+
+// formatTimestamp formats d as HH:MM:SS followed by sep and milliseconds
+func formatTimestamp(d time.Duration, sep byte) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
@@ -0,0 +1,127 @@
+package audio
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSRT(t *testing.T) {
+	r := &TranscriptionResponse{Timestamps: []TranscriptionSegment{
+		{Text: "Hello there.", StartTime: 0, EndTime: 1.5},
+		{Text: "General Kenobi!", StartTime: 3723.25, EndTime: 3725},
+	}}
+	want := "1\n00:00:00,000 --> 00:00:01,500\nHello there.\n\n" +
+		"2\n01:02:03,250 --> 01:02:05,000\nGeneral Kenobi!\n\n"
+	if got := r.SRT(nil); got != want {
+		t.Errorf("SRT() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWebVTT(t *testing.T) {
+	r := &TranscriptionResponse{Timestamps: []TranscriptionSegment{
+		{Text: "Fish & chips <3", StartTime: 0.5, EndTime: 2},
+	}}
+	want := "WEBVTT\n\n00:00:00.500 --> 00:00:02.000\nFish &amp; chips &lt;3\n\n"
+	if got := r.WebVTT(nil); got != want {
+		t.Errorf("WebVTT() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestCues(t *testing.T) {
+	words := &TranscriptionResponse{Timestamps: []TranscriptionSegment{
+		{Text: "The", StartTime: 0, EndTime: 0.2},
+		{Text: "quick", StartTime: 0.2, EndTime: 0.5},
+		{Text: "fox", StartTime: 0.5, EndTime: 0.8},
+		{Text: "ran", StartTime: 0.8, EndTime: 1},
+		{Text: ".", StartTime: 1, EndTime: 1},
+		{Text: "It", StartTime: 1.2, EndTime: 1.4},
+		{Text: "stopped", StartTime: 1.4, EndTime: 1.8},
+		{Text: "then", StartTime: 4, EndTime: 4.3},
+		{Text: "slept", StartTime: 4.3, EndTime: 4.8},
+	}}
+
+	tests := []struct {
+		name string
+		r    *TranscriptionResponse
+		opts *CaptionOptions
+		want []Cue
+	}{
+		{
+			name: "merged sentences and pauses",
+			r:    words,
+			opts: &CaptionOptions{MergeWords: true, MaxGap: time.Second},
+			want: []Cue{
+				{Start: 0, End: time.Second, Text: "The quick fox ran."},
+				{Start: 1200 * time.Millisecond, End: 1800 * time.Millisecond, Text: "It stopped"},
+				{Start: 4 * time.Second, End: 4800 * time.Millisecond, Text: "then slept"},
+			},
+		},
+		{
+			name: "line limits",
+			r:    words,
+			opts: &CaptionOptions{MergeWords: true, MaxLineLength: 9, MaxLines: 2},
+			want: []Cue{
+				{Start: 0, End: time.Second, Text: "The quick\nfox ran."},
+				{Start: 1200 * time.Millisecond, End: 1800 * time.Millisecond, Text: "It\nstopped"},
+				{Start: 4 * time.Second, End: 4800 * time.Millisecond, Text: "then\nslept"},
+			},
+		},
+		{
+			name: "long segment split by duration",
+			r: &TranscriptionResponse{Timestamps: []TranscriptionSegment{
+				{Text: "aaaa bbbb cccc dddd", StartTime: 0, EndTime: 8},
+			}},
+			opts: &CaptionOptions{MaxDuration: 4 * time.Second},
+			want: []Cue{
+				{Start: 0, End: 4 * time.Second, Text: "aaaa bbbb"},
+				{Start: 4 * time.Second, End: 8 * time.Second, Text: "cccc dddd"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Cues(tt.opts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Cues() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTranscriptText(t *testing.T) {
+	r := &TranscriptionResponse{Timestamps: []TranscriptionSegment{
+		{Text: "Hello"}, {Text: ","}, {Text: "world"}, {Text: "!"},
+	}}
+	if got := r.Text(); got != "Hello, world!" {
+		t.Errorf("Text() = %q, want %q", got, "Hello, world!")
+	}
+
+	r.Transcription = " Hello, world. "
+	if got := r.Text(); got != "Hello, world." {
+		t.Errorf("Text() = %q, want the transcription", got)
+	}
+}
+
+func TestTranscriptJSON(t *testing.T) {
+	r := &TranscriptionResponse{
+		Transcription: "Hi.",
+		Timestamps:    []TranscriptionSegment{{Text: "Hi.", StartTime: 0.25, EndTime: 1}},
+	}
+	data, err := r.TranscriptJSON(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"text": "Hi.",
+		"cues": []interface{}{map[string]interface{}{"start": 0.25, "end": 1.0, "text": "Hi."}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TranscriptJSON() = %s", data)
+	}
+}
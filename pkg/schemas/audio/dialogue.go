@@ -0,0 +1,79 @@
+package audio
+
+import "encoding/json"
+
+// Speaker holds the voice settings of a dialogue speaker
+type Speaker struct {
+	VoiceID  string   `json:"voice_id" validate:"required"`
+	Language *string  `json:"language,omitempty"`
+	Emotion  *string  `json:"emotion,omitempty"`
+	Speed    *float64 `json:"speed,omitempty" validate:"omitempty,min=0.1,max=10"`
+}
+
+// DialogueLine is one line of a dialogue script
+type DialogueLine struct {
+	// Speaker is a key of the script's Speakers
+	Speaker string `json:"speaker" validate:"required"`
+	Text    string `json:"text" validate:"required"`
+	// PauseBefore overrides the gap before this line, in seconds
+	PauseBefore *float64 `json:"pause_before,omitempty" validate:"omitempty,min=0"`
+}
+
+// DialogueScript is a multi-speaker script. It can be loaded from JSON such as
+//
+//	{
+//	  "speakers": {"host": {"voice_id": "madison"}, "guest": {"voice_id": "jessica"}},
+//	  "lines": [{"speaker": "host", "text": "Welcome back!"}]
+//	}
+type DialogueScript struct {
+	Speakers map[string]Speaker `json:"speakers" validate:"required,min=1,dive"`
+	Lines    []DialogueLine     `json:"lines" validate:"required,min=1,dive"`
+}
+
+// DialogueManifest lists when each line of a rendered dialogue is spoken
+type DialogueManifest struct {
+	// Duration is the length of the track in seconds
+	Duration float64          `json:"duration"`
+	Lines    []DialogueTiming `json:"lines"`
+}
+
+// DialogueTiming is the position of a line in the track, in seconds
+type DialogueTiming struct {
+	Index   int     `json:"index"`
+	Speaker string  `json:"speaker"`
+	VoiceID string  `json:"voice_id"`
+	Text    string  `json:"text"`
+	Start   float64 `json:"start"`
+	End     float64 `json:"end"`
+}
+
+// JSON renders the manifest as indented JSON
+func (m *DialogueManifest) JSON() ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// Transcript returns the dialogue as a transcript with one segment per line,
+// so it can be exported with SRT, WebVTT and the other caption formats. When
+// labelSpeakers is set, each line starts with its speaker's name.
+func (m *DialogueManifest) Transcript(labelSpeakers bool) *TranscriptionResponse {
+	out := &TranscriptionResponse{Timestamps: make([]TranscriptionSegment, len(m.Lines))}
+	for i, line := range m.Lines {
+		text := line.Text
+		if labelSpeakers {
+			text = line.Speaker + ": " + text
+		}
+		out.Timestamps[i] = TranscriptionSegment{Text: text, StartTime: line.Start, EndTime: line.End}
+	}
+	out.Transcription = out.Text()
+	return out
+}
+
+// SRT renders the dialogue as SubRip subtitles labelled with the speakers
+func (m *DialogueManifest) SRT(opts *CaptionOptions) string {
+	return m.Transcript(true).SRT(opts)
+}
+
+// WebVTT renders the dialogue as WebVTT captions labelled with the speakers
+func (m *DialogueManifest) WebVTT(opts *CaptionOptions) string {
+	return m.Transcript(true).WebVTT(opts)
+}
@@ -0,0 +1,99 @@
+package audio
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testManifest is a two-line dialogue
+func testManifest() *DialogueManifest {
+	return &DialogueManifest{
+		Duration: 4.25,
+		Lines: []DialogueTiming{
+			{Index: 0, Speaker: "host", VoiceID: "madison", Text: "Welcome back!", Start: 0, End: 1.5},
+			{Index: 1, Speaker: "guest", VoiceID: "jessica", Text: "Glad to be here & ready.", Start: 2, End: 4.25},
+		},
+	}
+}
+
+func TestDialogueManifestJSON(t *testing.T) {
+	data, err := testManifest().JSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got map[string]interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("JSON() wrote invalid JSON %s: %v", data, err)
+	}
+	want := map[string]interface{}{
+		"duration": 4.25,
+		"lines": []interface{}{
+			map[string]interface{}{"index": 0.0, "speaker": "host", "voice_id": "madison", "text": "Welcome back!", "start": 0.0, "end": 1.5},
+			map[string]interface{}{"index": 1.0, "speaker": "guest", "voice_id": "jessica", "text": "Glad to be here & ready.", "start": 2.0, "end": 4.25},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("JSON() = %s, want %v", data, want)
+	}
+
+	var back DialogueManifest
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&back, testManifest()) {
+		t.Errorf("manifest round trip = %+v, want %+v", back, testManifest())
+	}
+}
+
+func TestDialogueManifestTranscript(t *testing.T) {
+	tests := []struct {
+		name     string
+		labelled bool
+		want     []string
+	}{
+		{name: "labelled", labelled: true, want: []string{"host: Welcome back!", "guest: Glad to be here & ready."}},
+		{name: "plain", want: []string{"Welcome back!", "Glad to be here & ready."}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testManifest().Transcript(tt.labelled)
+			if len(got.Timestamps) != len(tt.want) {
+				t.Fatalf("Transcript() has %d segments, want %d", len(got.Timestamps), len(tt.want))
+			}
+			for i, seg := range got.Timestamps {
+				line := testManifest().Lines[i]
+				if seg.Text != tt.want[i] || seg.StartTime != line.Start || seg.EndTime != line.End {
+					t.Errorf("segment %d = %+v, want %q from %v to %v", i, seg, tt.want[i], line.Start, line.End)
+				}
+			}
+			if want := tt.want[0] + " " + tt.want[1]; got.Transcription != want {
+				t.Errorf("Transcription = %q, want %q", got.Transcription, want)
+			}
+		})
+	}
+}
+
+func TestDialogueManifestCaptions(t *testing.T) {
+	m := testManifest()
+
+	wantSRT := "1\n00:00:00,000 --> 00:00:01,500\nhost: Welcome back!\n\n" +
+		"2\n00:00:02,000 --> 00:00:04,250\nguest: Glad to be here & ready.\n\n"
+	if got := m.SRT(nil); got != wantSRT {
+		t.Errorf("SRT() =\n%s\nwant\n%s", got, wantSRT)
+	}
+
+	wantVTT := "WEBVTT\n\n00:00:00.000 --> 00:00:01.500\nhost: Welcome back!\n\n" +
+		"00:00:02.000 --> 00:00:04.250\nguest: Glad to be here &amp; ready.\n\n"
+	if got := m.WebVTT(nil); got != wantVTT {
+		t.Errorf("WebVTT() =\n%s\nwant\n%s", got, wantVTT)
+	}
+
+	// Caption options wrap long lines
+	wrapped := m.SRT(&CaptionOptions{MaxLineLength: 20, MaxLines: 2})
+	if want := "guest: Glad to be\nhere & ready."; !strings.Contains(wrapped, want) {
+		t.Errorf("SRT() with a 20 character limit =\n%s\nwant a cue of %q", wrapped, want)
+	}
+}
@@ -0,0 +1,387 @@
+package audio
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// defaultWordDuration is how long a word is assumed to be sung when the
+// transcript matched none to measure
+const defaultWordDuration = 0.4
+
+// sectionLabel matches lyric lines that name a part of the song, such as
+// "[Chorus]" or "Verse 2:", rather than being sung
+var sectionLabel = regexp.MustCompile(`(?i)^(\[.*\]|\(\s*(intro|verse|pre-chorus|chorus|bridge|hook|refrain|outro)[^)]*\)|(intro|verse|pre-chorus|chorus|bridge|hook|refrain|outro)(\s*\d+)?\s*:?)$`)
+
+// SyncedLyrics is a song's lyrics with the time each line and word is sung,
+// in seconds
+type SyncedLyrics struct {
+	Title    string  `json:"title,omitempty"`
+	Duration float64 `json:"duration"`
+	// Coverage is the fraction of lyric words that were heard in the
+	// transcript. The times of the other words are estimated from their
+	// neighbours.
+	Coverage float64     `json:"coverage"`
+	Lines    []LyricLine `json:"lines"`
+}
+
+// LyricLine is a sung line of lyrics
+type LyricLine struct {
+	Text  string      `json:"text"`
+	Start float64     `json:"start"`
+	End   float64     `json:"end"`
+	Words []LyricWord `json:"words"`
+}
+
+// LyricWord is a sung word of a lyric line
+type LyricWord struct {
+	Text  string  `json:"text"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	// Matched reports whether the word was heard in the transcript
+	Matched bool `json:"matched"`
+}
+
+// AlignLyrics times lyrics by the word timestamps of a transcript of the
+// song, as returned by SpeechToText with TimestampLevel "word". Transcribed
+// singing is rarely exact, so the lyric words are aligned to the transcript
+// allowing for misheard, missing and extra words. Blank lines and section
+// labels such as "[Chorus]" are skipped. duration is the length of the song
+// in seconds; it bounds estimated times when it is positive.
+func AlignLyrics(lyrics string, transcript *TranscriptionResponse, duration float64) *SyncedLyrics {
+	out := &SyncedLyrics{Duration: duration}
+
+	var words []*LyricWord
+	for _, text := range strings.Split(lyrics, "\n") {
+		text = strings.TrimSpace(text)
+		if text == "" || sectionLabel.MatchString(text) {
+			continue
+		}
+		line := LyricLine{Text: text}
+		for _, f := range strings.Fields(text) {
+			line.Words = append(line.Words, LyricWord{Text: f})
+		}
+		out.Lines = append(out.Lines, line)
+	}
+
+	var lineOf []int
+	for i := range out.Lines {
+		for j := range out.Lines[i].Words {
+			words = append(words, &out.Lines[i].Words[j])
+			lineOf = append(lineOf, i)
+		}
+	}
+	if len(words) == 0 {
+		return out
+	}
+
+	var heard []timedWord
+	if transcript != nil {
+		heard = segmentWords(transcript.Timestamps)
+	}
+
+	matched := 0
+	for _, pair := range alignWords(words, heard) {
+		w, h := words[pair[0]], heard[pair[1]]
+		w.Start, w.End, w.Matched = h.start.Seconds(), h.end.Seconds(), true
+		matched++
+	}
+	out.Coverage = float64(matched) / float64(len(words))
+
+	estimateWords(words, lineOf, duration)
+
+	for i := range out.Lines {
+		line := &out.Lines[i]
+		line.Start = line.Words[0].Start
+		line.End = line.Words[len(line.Words)-1].End
+	}
+	return out
+}
+
+// alignWords aligns lyric words to heard words by global sequence alignment
+// and returns the index pairs of the words that match
+func alignWords(words []*LyricWord, heard []timedWord) [][2]int {
+	n, m := len(words), len(heard)
+	if m == 0 {
+		return nil
+	}
+
+	a := make([]string, n)
+	for i, w := range words {
+		a[i] = matchKey(w.Text)
+	}
+	b := make([]string, m)
+	for j, h := range heard {
+		b[j] = matchKey(h.text)
+	}
+
+	// score[i][j] is the best score aligning a[:i] with b[:j]. A match
+	// scores 2, a near match 1, and a substitution or skipped word -1.
+	const gap = -1
+	score := make([][]int, n+1)
+	for i := range score {
+		score[i] = make([]int, m+1)
+		score[i][0] = i * gap
+	}
+	for j := 0; j <= m; j++ {
+		score[0][j] = j * gap
+	}
+	for i := 1; i <= n; i++ {
+		for j := 1; j <= m; j++ {
+			best := score[i-1][j-1] + similarity(a[i-1], b[j-1])
+			if s := score[i-1][j] + gap; s > best {
+				best = s
+			}
+			if s := score[i][j-1] + gap; s > best {
+				best = s
+			}
+			score[i][j] = best
+		}
+	}
+
+	var pairs [][2]int
+	for i, j := n, m; i > 0 && j > 0; {
+		sim := similarity(a[i-1], b[j-1])
+		switch {
+		case score[i][j] == score[i-1][j-1]+sim:
+			if sim > 0 {
+				pairs = append(pairs, [2]int{i - 1, j - 1})
+			}
+			i, j = i-1, j-1
+		case score[i][j] == score[i-1][j]+gap:
+			i--
+		default:
+			j--
+		}
+	}
+
+	for l, r := 0, len(pairs)-1; l < r; l, r = l+1, r-1 {
+		pairs[l], pairs[r] = pairs[r], pairs[l]
+	}
+	return pairs
+}
+
+// matchKey reduces a word to its lower-case letters and digits
+func matchKey(word string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, word)
+}
+
+// similarity scores two match keys: 2 when they are equal, 1 when they are
+// spelled alike and -1 otherwise
+func similarity(a, b string) int {
+	if a == "" || b == "" {
+		return -1
+	}
+	if a == b {
+		return 2
+	}
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if 1-float64(editDistance(ra, rb))/float64(longest) >= 0.6 {
+		return 1
+	}
+	return -1
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// estimateWords times the unmatched words between the matched ones. Words on
+// the line of a matched neighbour are sung next to it at a typical pace;
+// whole lines in between share the rest of the gap.
+func estimateWords(words []*LyricWord, lineOf []int, duration float64) {
+	var durations []float64
+	for _, w := range words {
+		if w.Matched && w.End > w.Start {
+			durations = append(durations, w.End-w.Start)
+		}
+	}
+	pace := defaultWordDuration
+	if len(durations) > 0 {
+		sort.Float64s(durations)
+		pace = durations[len(durations)/2]
+	}
+
+	for i := 0; i < len(words); {
+		if words[i].Matched {
+			i++
+			continue
+		}
+		j := i
+		for j < len(words) && !words[j].Matched {
+			j++
+		}
+		k := j - i
+
+		hasPrev, hasNext := i > 0, j < len(words)
+		var lo, hi float64
+		switch {
+		case hasPrev && hasNext:
+			lo, hi = words[i-1].End, words[j].Start
+		case hasNext:
+			hi = words[j].Start
+			lo = math.Max(0, hi-float64(k)*pace)
+		case hasPrev:
+			lo = words[i-1].End
+			hi = lo + float64(k)*pace
+			if duration > 0 {
+				hi = math.Max(lo, math.Min(hi, duration))
+			}
+		default:
+			hi = duration
+			if hi <= 0 {
+				hi = float64(k) * pace
+			}
+		}
+		if hi < lo {
+			hi = lo
+		}
+		step := math.Min(pace, (hi-lo)/float64(k))
+
+		// Words on the line of the previous matched word follow it, and
+		// words on the line of the next one lead up to it
+		head := i
+		if hasPrev {
+			for head < j && lineOf[head] == lineOf[i-1] {
+				head++
+			}
+		}
+		tail := j
+		if hasNext {
+			for tail > head && lineOf[tail-1] == lineOf[j] {
+				tail--
+			}
+		}
+		if !hasPrev && !hasNext {
+			step = (hi - lo) / float64(k)
+			head, tail = i, j
+		}
+
+		for n := i; n < head; n++ {
+			words[n].Start = lo + float64(n-i)*step
+			words[n].End = words[n].Start + step
+		}
+		for n := tail; n < j; n++ {
+			words[n].End = hi - float64(j-1-n)*step
+			words[n].Start = words[n].End - step
+		}
+		if middle := tail - head; middle > 0 {
+			from, to := lo+float64(head-i)*step, hi-float64(j-tail)*step
+			slot := (to - from) / float64(middle)
+			inner := math.Min(slot, pace)
+			if !hasPrev && !hasNext {
+				inner = slot
+			}
+			// Each line gets a share of the gap by its word count and is
+			// sung in the middle of it
+			for start := head; start < tail; {
+				end := start
+				for end < tail && lineOf[end] == lineOf[start] {
+					end++
+				}
+				share := float64(end-start) * slot
+				offset := from + float64(start-head)*slot + (share-float64(end-start)*inner)/2
+				for n := start; n < end; n++ {
+					words[n].Start = offset + float64(n-start)*inner
+					words[n].End = words[n].Start + inner
+				}
+				start = end
+			}
+		}
+		i = j
+	}
+}
+
+// LRC renders the lyrics in the LRC format with one time tag per line
+func (s *SyncedLyrics) LRC() string {
+	return s.lrc(false)
+}
+
+// EnhancedLRC renders the lyrics in the enhanced LRC format used for
+// karaoke, which also tags the start of every word and the end of each line
+func (s *SyncedLyrics) EnhancedLRC() string {
+	return s.lrc(true)
+}
+
+func (s *SyncedLyrics) lrc(words bool) string {
+	var b strings.Builder
+	if s.Title != "" {
+		fmt.Fprintf(&b, "[ti:%s]\n", s.Title)
+	}
+	if s.Duration > 0 {
+		total := int(math.Round(s.Duration))
+		fmt.Fprintf(&b, "[length:%02d:%02d]\n", total/60, total%60)
+	}
+
+	for _, line := range s.Lines {
+		b.WriteString(lrcTimestamp(line.Start, '[', ']'))
+		if !words {
+			b.WriteString(line.Text)
+			b.WriteByte('\n')
+			continue
+		}
+		for i, w := range line.Words {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteString(lrcTimestamp(w.Start, '<', '>'))
+			b.WriteString(w.Text)
+		}
+		b.WriteString(lrcTimestamp(line.End, '<', '>'))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// lrcTimestamp formats seconds as mm:ss.xx between open and close
+func lrcTimestamp(s float64, open, close byte) string {
+	cs := int64(math.Round(math.Max(s, 0) * 100))
+	return fmt.Sprintf("%c%02d:%02d.%02d%c", open, cs/6000, cs/100%60, cs%100, close)
+}
+
+// JSON renders the synced lyrics as indented JSON
+func (s *SyncedLyrics) JSON() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// Transcript returns the lyrics as a transcript with one segment per line,
+// so they can be exported with SRT, WebVTT and the other caption formats
+func (s *SyncedLyrics) Transcript() *TranscriptionResponse {
+	out := &TranscriptionResponse{Timestamps: make([]TranscriptionSegment, len(s.Lines))}
+	for i, line := range s.Lines {
+		out.Timestamps[i] = TranscriptionSegment{Text: line.Text, StartTime: line.Start, EndTime: line.End}
+	}
+	out.Transcription = out.Text()
+	return out
+}
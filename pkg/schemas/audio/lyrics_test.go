@@ -0,0 +1,118 @@
+package audio
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const testLyrics = `[Verse 1]
+Walkin' down the empty road
+Counting all the lies I told
+
+Chorus:
+Hold on, hold on`
+
+// sungTranscript is a word-level transcript of testLyrics in which
+// "Walkin'" is heard as "walking", the second "the" as "uh" and "I" not at
+// all
+func sungTranscript() *TranscriptionResponse {
+	words := []TranscriptionSegment{
+		{Text: "walking", StartTime: 1.0, EndTime: 1.4},
+		{Text: "down", StartTime: 1.4, EndTime: 1.7},
+		{Text: "the", StartTime: 1.7, EndTime: 1.8},
+		{Text: "empty", StartTime: 1.8, EndTime: 2.2},
+		{Text: "road", StartTime: 2.2, EndTime: 2.8},
+		{Text: "counting", StartTime: 4.0, EndTime: 4.5},
+		{Text: "all", StartTime: 4.5, EndTime: 4.7},
+		{Text: "uh", StartTime: 4.7, EndTime: 4.9},
+		{Text: "lies", StartTime: 5.0, EndTime: 5.5},
+		{Text: "told", StartTime: 5.7, EndTime: 6.2},
+		{Text: "hold", StartTime: 8.0, EndTime: 8.5},
+		{Text: "on", StartTime: 8.5, EndTime: 9.0},
+		{Text: "hold", StartTime: 9.0, EndTime: 9.5},
+		{Text: "on", StartTime: 9.5, EndTime: 10.0},
+	}
+	return &TranscriptionResponse{Timestamps: words}
+}
+
+func TestAlignLyrics(t *testing.T) {
+	synced := AlignLyrics(testLyrics, sungTranscript(), 12)
+
+	type span struct {
+		text       string
+		start, end float64
+		matched    bool
+	}
+	want := [][]span{
+		{{"Walkin'", 1.0, 1.4, true}, {"down", 1.4, 1.7, true}, {"the", 1.7, 1.8, true}, {"empty", 1.8, 2.2, true}, {"road", 2.2, 2.8, true}},
+		// "the" was heard as "uh" and "I" was not heard; both are fitted
+		// into the gaps next to their neighbours
+		{{"Counting", 4.0, 4.5, true}, {"all", 4.5, 4.7, true}, {"the", 4.7, 5.0, false}, {"lies", 5.0, 5.5, true}, {"I", 5.5, 5.7, false}, {"told", 5.7, 6.2, true}},
+		{{"Hold", 8.0, 8.5, true}, {"on,", 8.5, 9.0, true}, {"hold", 9.0, 9.5, true}, {"on", 9.5, 10.0, true}},
+	}
+
+	if len(synced.Lines) != len(want) {
+		t.Fatalf("AlignLyrics returned %d lines, want %d", len(synced.Lines), len(want))
+	}
+	for i, line := range synced.Lines {
+		if len(line.Words) != len(want[i]) {
+			t.Fatalf("line %d has %d words, want %d", i, len(line.Words), len(want[i]))
+		}
+		for j, w := range line.Words {
+			exp := want[i][j]
+			if w.Text != exp.text || w.Matched != exp.matched ||
+				math.Abs(w.Start-exp.start) > 1e-9 || math.Abs(w.End-exp.end) > 1e-9 {
+				t.Errorf("line %d word %d = %+v, want %+v", i, j, w, exp)
+			}
+		}
+		if line.Start != line.Words[0].Start || line.End != line.Words[len(line.Words)-1].End {
+			t.Errorf("line %d spans %v-%v, not its words", i, line.Start, line.End)
+		}
+	}
+
+	if want := 13.0 / 15; math.Abs(synced.Coverage-want) > 1e-9 {
+		t.Errorf("Coverage = %v, want %v", synced.Coverage, want)
+	}
+}
+
+func TestAlignLyricsWithoutTranscript(t *testing.T) {
+	synced := AlignLyrics("one two\nthree", nil, 6)
+	if synced.Coverage != 0 {
+		t.Errorf("Coverage = %v, want 0", synced.Coverage)
+	}
+
+	// The words share the whole song evenly
+	var words []LyricWord
+	for _, line := range synced.Lines {
+		words = append(words, line.Words...)
+	}
+	for i, w := range words {
+		if math.Abs(w.Start-float64(2*i)) > 1e-9 || math.Abs(w.End-float64(2*i+2)) > 1e-9 {
+			t.Errorf("word %q spans %v-%v, want %d-%d", w.Text, w.Start, w.End, 2*i, 2*i+2)
+		}
+	}
+}
+
+func TestLRC(t *testing.T) {
+	synced := AlignLyrics(testLyrics, sungTranscript(), 72.4)
+	synced.Title = "Empty Road"
+
+	want := "[ti:Empty Road]\n[length:01:12]\n" +
+		"[00:01.00]Walkin' down the empty road\n" +
+		"[00:04.00]Counting all the lies I told\n" +
+		"[00:08.00]Hold on, hold on\n"
+	if got := synced.LRC(); got != want {
+		t.Errorf("LRC() =\n%s\nwant\n%s", got, want)
+	}
+
+	enhanced := synced.EnhancedLRC()
+	if line := "[00:08.00]<00:08.00>Hold <00:08.50>on, <00:09.00>hold <00:09.50>on<00:10.00>\n"; !strings.Contains(enhanced, line) {
+		t.Errorf("EnhancedLRC() =\n%s\nwant a line\n%s", enhanced, line)
+	}
+
+	transcript := synced.Transcript()
+	if len(transcript.Timestamps) != 3 || transcript.Timestamps[1].StartTime != 4 || transcript.Timestamps[1].EndTime != 6.2 {
+		t.Errorf("Transcript() = %+v", transcript.Timestamps)
+	}
+}
@@ -2,9 +2,14 @@
 package base
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"mime/multipart"
+	"strconv"
+
+	"github.com/modelslab/modelslab-go/pkg/utils"
 )
 
 // BaseRequest represents the base structure for all API requests
@@ -13,12 +18,24 @@ type BaseRequest struct {
 	TrackID *string `json:"track_id,omitempty"`
 }
 
-// FileInput represents different ways to provide file input
+// FileInput represents different ways to provide file input. Local files
+// given by FilePath, Reader or File are base64-encoded while the request body
+// is written, so they are never held in memory.
 type FileInput struct {
 	URL      *string               `json:"url,omitempty" validate:"omitempty,url"`
 	Base64   *string               `json:"base64,omitempty"`
 	FilePath *string               `json:"file_path,omitempty"`
 	File     *multipart.FileHeader `json:"-"`
+	// Reader supplies the file contents, read from its current position.
+	// The client rewinds seekable readers when it retries a request and
+	// copies other readers to a temporary file first, so every attempt
+	// sends the same contents. Seekable readers are left where they were
+	// once the call returns.
+	Reader io.Reader `json:"-"`
+	// MIMEType is the media type of the file, when known. Files read from
+	// FilePath or Reader are sent as data URIs when it is set; uploads in
+	// File are always sent as data URIs, detecting the type if needed.
+	MIMEType string `json:"-"`
 }
 
 // MarshalJSON implements custom JSON marshaling for FileInput
@@ -29,32 +46,83 @@ func (f *FileInput) MarshalJSON() ([]byte, error) {
 	if f.Base64 != nil {
 		return json.Marshal(*f.Base64)
 	}
-	if f.FilePath != nil {
-		return json.Marshal(*f.FilePath)
+	if f.FilePath != nil || f.Reader != nil || f.File != nil {
+		var buf bytes.Buffer
+		if err := f.WriteJSON(&buf); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
 	}
 	return json.Marshal(nil)
 }
 
-// UnmarshalJSON implements custom JSON unmarshaling for FileInput
+// WriteJSON writes the same encoding as MarshalJSON straight to w, without
+// copying large base64 payloads
+func (f *FileInput) WriteJSON(w io.Writer) error {
+	switch {
+	case f.URL != nil:
+		return utils.WriteJSONString(w, *f.URL)
+	case f.Base64 != nil:
+		return utils.WriteJSONString(w, *f.Base64)
+	case f.FilePath != nil:
+		return writeFileBase64(w, *f.FilePath, f.MIMEType)
+	case f.Reader != nil:
+		return writeBase64(w, f.Reader, f.MIMEType)
+	case f.File != nil:
+		return writeMultipartBase64(w, f.File, f.MIMEType)
+	}
+	_, err := io.WriteString(w, "null")
+	return err
+}
+
+// StreamReader returns the reader whose contents WriteJSON sends, or nil when
+// the input is sent from another source
+func (f *FileInput) StreamReader() io.Reader {
+	if f.URL != nil || f.Base64 != nil || f.FilePath != nil {
+		return nil
+	}
+	return f.Reader
+}
+
+// WriteJSONFrom writes the encoding of a Reader input with its contents read
+// from r, which the client supplies so it can resend them on a retry
+func (f *FileInput) WriteJSONFrom(w io.Writer, r io.Reader) error {
+	if f.StreamReader() == nil {
+		return f.WriteJSON(w)
+	}
+	return writeBase64(w, r, f.MIMEType)
+}
+
+// UnmarshalJSON implements custom JSON unmarshaling for FileInput. Data URIs
+// and bare base64 strings become Base64; strings with an http(s) scheme and
+// a host become URL.
 func (f *FileInput) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+
 	var str string
-	if err := json.Unmarshal(data, &str); err == nil {
-		if str != "" {
-			// Try to determine if it's a URL, base64, or file path
-			if len(str) > 7 && (str[:7] == "http://" || str[:8] == "https://") {
-				f.URL = &str
-			} else {
-				f.Base64 = &str
-			}
-		}
+	if err := json.Unmarshal(data, &str); err != nil {
+		return fmt.Errorf("invalid file input format")
+	}
+	if str == "" {
 		return nil
 	}
-	return fmt.Errorf("invalid file input format")
+
+	if mimeType, ok := dataURIType(str); ok {
+		f.Base64 = &str
+		f.MIMEType = mimeType
+	} else if isRemoteURL(str) {
+		f.URL = &str
+	} else {
+		f.Base64 = &str
+	}
+	return nil
 }
 
 // Validate checks if the FileInput has at least one valid input method
 func (f *FileInput) Validate() error {
-	if f.URL == nil && f.Base64 == nil && f.FilePath == nil && f.File == nil {
+	if f.URL == nil && f.Base64 == nil && f.FilePath == nil && f.File == nil && f.Reader == nil {
 		return fmt.Errorf("at least one file input method must be provided")
 	}
 	return nil
@@ -66,13 +134,70 @@ type FetchRequest struct {
 	Key string `json:"key" validate:"required"`
 }
 
+// Meta holds the generation parameters echoed back by the API. Numbers are
+// kept as json.Number so that seeds and other large integers stay exact.
+type Meta map[string]interface{}
+
+// UnmarshalJSON implements custom JSON unmarshaling for Meta
+func (m *Meta) UnmarshalJSON(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var values map[string]interface{}
+	if err := decoder.Decode(&values); err != nil {
+		return err
+	}
+	*m = values
+	return nil
+}
+
+// Int64 returns the integer stored under key without loss of precision
+func (m Meta) Int64(key string) (int64, bool) {
+	switch val := m[key].(type) {
+	case json.Number:
+		i, err := val.Int64()
+		return i, err == nil
+	case string:
+		i, err := strconv.ParseInt(val, 10, 64)
+		return i, err == nil
+	case float64:
+		return int64(val), val == float64(int64(val))
+	}
+	return 0, false
+}
+
+// Seed returns the seed used for the generation, ready to be passed back as
+// the Seed of a new request, or nil when the API did not report one
+func (m Meta) Seed() *int64 {
+	if seed, ok := m.Int64("seed"); ok {
+		return &seed
+	}
+	return nil
+}
+
 // Response represents a standard API response
 type Response struct {
-	Status  string      `json:"status"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
-	Error   string      `json:"error,omitempty"`
-	ID      string      `json:"id,omitempty"`
+	Status         string      `json:"status"`
+	Message        string      `json:"message,omitempty"`
+	Data           interface{} `json:"data,omitempty"`
+	Error          string      `json:"error,omitempty"`
+	ID             string      `json:"id,omitempty"`
+	Output         []string    `json:"output,omitempty"`
+	ProxyLinks     []string    `json:"proxy_links,omitempty"`
+	FutureLinks    []string    `json:"future_links,omitempty"`
+	FetchResult    string      `json:"fetch_result,omitempty"`
+	ETA            float64     `json:"eta,omitempty"`
+	GenerationTime float64     `json:"generationTime,omitempty"`
+	Meta           Meta        `json:"meta,omitempty"`
+
+	// Raw holds the complete decoded response, including fields the SDK
+	// does not model yet
+	Raw map[string]interface{} `json:"-"`
+}
+
+// SetRaw stores the complete decoded response
+func (r *Response) SetRaw(raw map[string]interface{}) {
+	r.Raw = raw
 }
 
 // EnterpriseRequest provides common enterprise functionality
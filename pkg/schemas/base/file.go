@@ -0,0 +1,313 @@
+package base
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/modelslab/modelslab-go/pkg/utils"
+)
+
+// sniffLen is how much of a file is read to detect its MIME type
+const sniffLen = 3072
+
+// FileFromPath returns a FileInput that streams a local file as a data URI.
+// The file is opened again whenever the request body is written.
+func FileFromPath(path string) (*FileInput, error) {
+	mtype, err := mimetype.DetectFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file input: %w", err)
+	}
+
+	return &FileInput{
+		FilePath: &path,
+		MIMEType: mediaType(mtype),
+	}, nil
+}
+
+// FileFromReader returns a FileInput that streams r as a data URI. The start
+// of r is read to detect its MIME type.
+func FileFromReader(r io.Reader) (*FileInput, error) {
+	seeker, canSeek := r.(io.Seeker)
+	var offset int64
+	if canSeek {
+		var err error
+		if offset, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			return nil, fmt.Errorf("failed to seek file input: %w", err)
+		}
+	}
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read file input: %w", err)
+	}
+	head = head[:n]
+
+	if canSeek {
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to seek file input: %w", err)
+		}
+	} else {
+		r = io.MultiReader(bytes.NewReader(head), r)
+	}
+
+	return &FileInput{
+		Reader:   r,
+		MIMEType: mediaType(mimetype.Detect(head)),
+	}, nil
+}
+
+// FileFromBytes returns a FileInput holding data as a data URI
+func FileFromBytes(data []byte) *FileInput {
+	return fileFromBytes(data, mediaType(mimetype.Detect(data)))
+}
+
+// FileFromImage encodes img in the given format, "png", "jpeg" or "gif", and
+// returns it as a data URI
+func FileFromImage(img image.Image, format string) (*FileInput, error) {
+	var buf bytes.Buffer
+	var mimeType string
+	var err error
+
+	switch strings.ToLower(format) {
+	case "png":
+		mimeType = "image/png"
+		err = png.Encode(&buf, img)
+	case "jpeg", "jpg":
+		mimeType = "image/jpeg"
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95})
+	case "gif":
+		mimeType = "image/gif"
+		err = gif.Encode(&buf, img, nil)
+	default:
+		return nil, fmt.Errorf("unsupported image format: %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	return fileFromBytes(buf.Bytes(), mimeType), nil
+}
+
+// FileFromURL returns a FileInput referring to a remote file
+func FileFromURL(rawURL string) *FileInput {
+	return &FileInput{URL: &rawURL}
+}
+
+// fileFromBytes returns a FileInput holding data as a data URI of mimeType
+func fileFromBytes(data []byte, mimeType string) *FileInput {
+	prefix := dataURIPrefix(mimeType)
+	buf := make([]byte, len(prefix)+base64.StdEncoding.EncodedLen(len(data)))
+	copy(buf, prefix)
+	base64.StdEncoding.Encode(buf[len(prefix):], data)
+
+	encoded := string(buf)
+	return &FileInput{
+		Base64:   &encoded,
+		MIMEType: mimeType,
+	}
+}
+
+// mediaType returns a detected MIME type without parameters such as charset,
+// which are not allowed in the base64 data URIs sent to the API
+func mediaType(mtype *mimetype.MIME) string {
+	mediaType, _, _ := strings.Cut(mtype.String(), ";")
+	return strings.TrimSpace(mediaType)
+}
+
+// dataURIPrefix returns the data URI prefix for base64 data of mimeType
+func dataURIPrefix(mimeType string) string {
+	return "data:" + mimeType + ";base64,"
+}
+
+// dataURIType reports whether s is a data URI and returns its media type
+func dataURIType(s string) (string, bool) {
+	if len(s) < len("data:") || !strings.EqualFold(s[:len("data:")], "data:") {
+		return "", false
+	}
+
+	header, _, ok := strings.Cut(s[len("data:"):], ",")
+	if !ok {
+		return "", false
+	}
+	mediaType, _, _ := strings.Cut(header, ";")
+	return mediaType, true
+}
+
+// isRemoteURL reports whether s is an absolute http(s) URL with a host
+func isRemoteURL(s string) bool {
+	u, err := url.Parse(s)
+	if err != nil {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	return (scheme == "http" || scheme == "https") && u.Host != ""
+}
+
+// writeFileBase64 writes the contents of a local file as a base64 JSON string
+func writeFileBase64(w io.Writer, path, mimeType string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file input: %w", err)
+	}
+	defer file.Close()
+
+	return writeBase64(w, file, mimeType)
+}
+
+// writeBase64 writes the contents of r as a base64 JSON string, or as a data
+// URI when mimeType is set. The base64 alphabet needs no JSON escaping, so the
+// encoder output is written as is.
+func writeBase64(w io.Writer, r io.Reader, mimeType string) error {
+	if _, err := io.WriteString(w, `"`); err != nil {
+		return err
+	}
+	if mimeType != "" {
+		if err := utils.WriteJSONEscaped(w, dataURIPrefix(mimeType)); err != nil {
+			return err
+		}
+	}
+
+	enc := base64.NewEncoder(base64.StdEncoding, w)
+	if _, err := io.Copy(enc, r); err != nil {
+		return fmt.Errorf("failed to encode file input: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, `"`)
+	return err
+}
+
+// writeMultipartBase64 writes an uploaded file as a data URI JSON string. The
+// MIME type is detected from the contents when mimeType is empty.
+func writeMultipartBase64(w io.Writer, fh *multipart.FileHeader, mimeType string) error {
+	file, err := fh.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open file input: %w", err)
+	}
+	defer file.Close()
+
+	if mimeType == "" {
+		mtype, err := mimetype.DetectReader(file)
+		if err != nil {
+			return fmt.Errorf("failed to read file input: %w", err)
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to seek file input: %w", err)
+		}
+		mimeType = mediaType(mtype)
+	}
+
+	return writeBase64(w, file, mimeType)
+}
+
+// ErrNoLocalContents is returned by Open for inputs that refer to a URL or
+// hold nothing
+var ErrNoLocalContents = errors.New("file input has no local contents")
+
+// Open returns the contents of a local file input: the file at FilePath,
+// Reader, the uploaded File or the decoded Base64 data. Inputs that refer to
+// a URL return ErrNoLocalContents.
+func (f *FileInput) Open() (io.ReadCloser, error) {
+	if f.URL == nil && f.Base64 != nil {
+		payload := *f.Base64
+		if _, ok := dataURIType(payload); ok {
+			_, payload, _ = strings.Cut(payload, ",")
+		}
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			if data, err = base64.RawStdEncoding.DecodeString(payload); err != nil {
+				return nil, fmt.Errorf("failed to decode file input: %w", err)
+			}
+		}
+		return &readSeekRestorer{ReadSeeker: bytes.NewReader(data)}, nil
+	}
+
+	r, _, _, err := f.UploadSource()
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		return nil, ErrNoLocalContents
+	}
+	return r, nil
+}
+
+// HasLocalContents reports whether the input holds its contents, as a file,
+// reader, uploaded file or inline base64, rather than referring to a URL. It
+// reads nothing.
+func (f *FileInput) HasLocalContents() bool {
+	return f.URL == nil && (f.FilePath != nil || f.Reader != nil || f.File != nil || f.Base64 != nil)
+}
+
+// UploadSource returns the contents of a local file input so that a client
+// uploader can replace it with a hosted URL. It returns a nil reader when the
+// input refers to a URL or holds inline base64, which are sent as they are.
+func (f *FileInput) UploadSource() (r io.ReadCloser, name, mimeType string, err error) {
+	if f.URL != nil || f.Base64 != nil {
+		return nil, "", "", nil
+	}
+
+	switch {
+	case f.FilePath != nil:
+		file, err := os.Open(*f.FilePath)
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to open file input: %w", err)
+		}
+		return file, filepath.Base(*f.FilePath), f.MIMEType, nil
+	case f.Reader != nil:
+		r := f.Reader
+		if named, ok := r.(interface{ Name() string }); ok {
+			name = filepath.Base(named.Name())
+		}
+		if rs, ok := r.(io.ReadSeeker); ok {
+			start, err := rs.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, "", "", fmt.Errorf("failed to seek file input: %w", err)
+			}
+			return &readSeekRestorer{ReadSeeker: rs, start: start}, name, f.MIMEType, nil
+		}
+		return io.NopCloser(r), name, f.MIMEType, nil
+	case f.File != nil:
+		file, err := f.File.Open()
+		if err != nil {
+			return nil, "", "", fmt.Errorf("failed to open file input: %w", err)
+		}
+		return file, f.File.Filename, f.MIMEType, nil
+	}
+	return nil, "", "", nil
+}
+
+// SetUploadedURL makes the input refer to the hosted copy of its contents.
+// The local source is kept but no longer sent, as URL takes precedence.
+func (f *FileInput) SetUploadedURL(url string) {
+	f.URL = &url
+}
+
+// readSeekRestorer keeps a reader seekable and seeks it back to where it
+// stood when closed, as the caller of UploadSource does not own
+// FileInput.Reader
+type readSeekRestorer struct {
+	io.ReadSeeker
+	start int64
+}
+
+func (r *readSeekRestorer) Close() error {
+	_, err := r.Seek(r.start, io.SeekStart)
+	return err
+}
@@ -0,0 +1,279 @@
+package base
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pngHeader starts every PNG file
+const pngHeader = "\x89PNG\r\n\x1a\n"
+
+// encodedJSON returns the JSON encoding WriteJSON writes for f
+func encodedJSON(t *testing.T, f *FileInput) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	if err := f.WriteJSON(&buf); err != nil {
+		t.Fatalf("WriteJSON() error = %v", err)
+	}
+	return buf.String()
+}
+
+// dataURI returns the quoted data URI JSON string of data
+func dataURI(mimeType, data string) string {
+	return `"data:` + mimeType + `;base64,` + base64.StdEncoding.EncodeToString([]byte(data)) + `"`
+}
+
+func TestFileFromPath(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		contents string
+		wantMIME string
+	}{
+		{name: "image.png", contents: pngHeader + "pixels", wantMIME: "image/png"},
+		{name: "notes.txt", contents: "plain text", wantMIME: "text/plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, []byte(tt.contents), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			f, err := FileFromPath(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.MIMEType != tt.wantMIME {
+				t.Errorf("MIMEType = %q, want %q", f.MIMEType, tt.wantMIME)
+			}
+			if got, want := encodedJSON(t, f), dataURI(tt.wantMIME, tt.contents); got != want {
+				t.Errorf("WriteJSON() = %s, want %s", got, want)
+			}
+		})
+	}
+
+	if _, err := FileFromPath(filepath.Join(dir, "missing.png")); err == nil {
+		t.Error("FileFromPath() of a missing file succeeded")
+	}
+}
+
+func TestFileFromReader(t *testing.T) {
+	contents := pngHeader + strings.Repeat("pixels", sniffLen)
+	tests := []struct {
+		name   string
+		reader func() io.Reader
+	}{
+		{name: "seekable", reader: func() io.Reader { return strings.NewReader(contents) }},
+		{name: "non-seekable", reader: func() io.Reader { return io.MultiReader(strings.NewReader(contents)) }},
+		{name: "seekable part", reader: func() io.Reader {
+			r := strings.NewReader("skipped" + contents)
+			r.Seek(int64(len("skipped")), io.SeekStart)
+			return r
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := FileFromReader(tt.reader())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.MIMEType != "image/png" {
+				t.Errorf("MIMEType = %q, want image/png", f.MIMEType)
+			}
+			// The head read to detect the type is still sent
+			if got, want := encodedJSON(t, f), dataURI("image/png", contents); got != want {
+				t.Errorf("WriteJSON() = %.80s..., want %.80s...", got, want)
+			}
+		})
+	}
+}
+
+func TestFileFromBytes(t *testing.T) {
+	tests := []struct {
+		data     string
+		wantMIME string
+	}{
+		{data: pngHeader + "pixels", wantMIME: "image/png"},
+		{data: "plain text", wantMIME: "text/plain"},
+		{data: "", wantMIME: "text/plain"},
+	}
+	for _, tt := range tests {
+		f := FileFromBytes([]byte(tt.data))
+		if f.MIMEType != tt.wantMIME {
+			t.Errorf("FileFromBytes(%q).MIMEType = %q, want %q", tt.data, f.MIMEType, tt.wantMIME)
+		}
+		if f.Base64 == nil || `"`+*f.Base64+`"` != dataURI(tt.wantMIME, tt.data) {
+			t.Errorf("FileFromBytes(%q).Base64 = %v, want %s", tt.data, f.Base64, dataURI(tt.wantMIME, tt.data))
+		}
+	}
+}
+
+func TestFileFromImage(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	img.Set(1, 1, color.RGBA{R: 255, A: 255})
+
+	tests := []struct {
+		format   string
+		wantMIME string
+		wantErr  bool
+	}{
+		{format: "png", wantMIME: "image/png"},
+		{format: "JPEG", wantMIME: "image/jpeg"},
+		{format: "jpg", wantMIME: "image/jpeg"},
+		{format: "gif", wantMIME: "image/gif"},
+		{format: "bmp", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			f, err := FileFromImage(img, tt.format)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("FileFromImage() succeeded, want an unsupported format error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.MIMEType != tt.wantMIME {
+				t.Errorf("MIMEType = %q, want %q", f.MIMEType, tt.wantMIME)
+			}
+
+			rc, err := f.Open()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rc.Close()
+			decoded, format, err := image.Decode(rc)
+			if err != nil {
+				t.Fatalf("decoding the encoded image: %v", err)
+			}
+			if "image/"+format != tt.wantMIME || decoded.Bounds() != img.Bounds() {
+				t.Errorf("decoded a %s image of %v, want %s of %v", format, decoded.Bounds(), tt.wantMIME, img.Bounds())
+			}
+		})
+	}
+}
+
+func TestFileFromURL(t *testing.T) {
+	f := FileFromURL("https://example.com/cat.png")
+	if f.URL == nil || *f.URL != "https://example.com/cat.png" {
+		t.Fatalf("URL = %v, want https://example.com/cat.png", f.URL)
+	}
+	if f.HasLocalContents() {
+		t.Error("a URL input reports local contents")
+	}
+	if got := encodedJSON(t, f); got != `"https://example.com/cat.png"` {
+		t.Errorf("WriteJSON() = %s, want the URL", got)
+	}
+}
+
+func TestFileInputJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       *FileInput
+		wantURL  bool
+		wantMIME string
+	}{
+		{name: "URL", in: FileFromURL("https://example.com/cat.png?size=large"), wantURL: true},
+		{name: "data URI", in: FileFromBytes([]byte(pngHeader + "pixels")), wantMIME: "image/png"},
+		{name: "bare base64", in: &FileInput{Base64: StringPtr(base64.StdEncoding.EncodeToString([]byte("bytes")))}},
+		{name: "base64 like a path", in: &FileInput{Base64: StringPtr("aHR0cDovL2V4YW1wbGU=")}},
+		{name: "relative URL", in: &FileInput{Base64: StringPtr("example.com/cat.png")}},
+		{name: "URL without host", in: &FileInput{Base64: StringPtr("https:cat")}},
+		{name: "reader", in: &FileInput{Reader: strings.NewReader("text"), MIMEType: "text/plain"}, wantMIME: "text/plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.in.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var out FileInput
+			if err := out.UnmarshalJSON(data); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantURL {
+				if out.URL == nil || out.Base64 != nil {
+					t.Fatalf("%s decoded as %+v, want a URL", data, out)
+				}
+			} else if out.URL != nil || out.Base64 == nil {
+				t.Fatalf("%s decoded as %+v, want base64", data, out)
+			}
+			if out.MIMEType != tt.wantMIME {
+				t.Errorf("MIMEType = %q, want %q", out.MIMEType, tt.wantMIME)
+			}
+
+			again, err := out.MarshalJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(again, data) {
+				t.Errorf("round trip wrote %s, want %s", again, data)
+			}
+		})
+	}
+}
+
+func TestFileInputUnmarshalEmpty(t *testing.T) {
+	for _, data := range []string{`null`, `""`} {
+		var f FileInput
+		if err := f.UnmarshalJSON([]byte(data)); err != nil {
+			t.Fatalf("UnmarshalJSON(%s) error = %v", data, err)
+		}
+		if f.Validate() == nil {
+			t.Errorf("UnmarshalJSON(%s) = %+v, want an empty input", data, f)
+		}
+	}
+	var f FileInput
+	if err := f.UnmarshalJSON([]byte(`42`)); err == nil {
+		t.Error("UnmarshalJSON(42) succeeded, want an error")
+	}
+}
+
+// chunkWriter records the size of every write
+type chunkWriter struct {
+	bytes.Buffer
+	largest int
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.largest = max(w.largest, len(p))
+	return w.Buffer.Write(p)
+}
+
+func TestWriteJSONStreamsBase64(t *testing.T) {
+	contents := bytes.Repeat([]byte("large file contents "), 1<<16)
+	path := filepath.Join(t.TempDir(), "large.bin")
+	if err := os.WriteFile(path, contents, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	want := dataURI("application/octet-stream", string(contents))
+	for _, f := range []*FileInput{
+		{FilePath: &path, MIMEType: "application/octet-stream"},
+		{Reader: io.MultiReader(bytes.NewReader(contents)), MIMEType: "application/octet-stream"},
+	} {
+		var w chunkWriter
+		if err := f.WriteJSON(&w); err != nil {
+			t.Fatal(err)
+		}
+		if w.String() != want {
+			t.Errorf("WriteJSON() wrote %d bytes, want the %d byte data URI", w.Len(), len(want))
+		}
+		// The encoding is written in pieces rather than built in memory
+		if w.largest >= len(contents) {
+			t.Errorf("WriteJSON() made a write of %d bytes, want it streamed", w.largest)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+var (
+	// ErrFileTooLarge is returned when an upload exceeds FileLimits.MaxSize
+	ErrFileTooLarge = errors.New("file input is too large")
+	// ErrFileType is returned when an upload is not one of
+	// FileLimits.AllowedTypes
+	ErrFileType = errors.New("file input type is not allowed")
+)
+
+// FileLimits restricts the uploads accepted by FileFromMultipart
+type FileLimits struct {
+	// MaxSize is the largest accepted file in bytes; 0 means unlimited
+	MaxSize int64
+	// AllowedTypes lists the accepted MIME types, such as "image/png", or
+	// families such as "image/*"; empty accepts any type
+	AllowedTypes []string
+}
+
+// Common upload limits
+var (
+	// ImageUploads accepts images up to 20 MB
+	ImageUploads = FileLimits{MaxSize: 20 << 20, AllowedTypes: []string{"image/*"}}
+	// AudioUploads accepts audio up to 100 MB
+	AudioUploads = FileLimits{MaxSize: 100 << 20, AllowedTypes: []string{"audio/*"}}
+	// VideoUploads accepts videos up to 500 MB
+	VideoUploads = FileLimits{MaxSize: 500 << 20, AllowedTypes: []string{"video/*"}}
+)
+
+// FileFromMultipart returns a FileInput for a file uploaded in a
+// multipart/form-data request, such as one parsed by
+// http.Request.ParseMultipartForm. The MIME type is detected from the file
+// contents rather than trusted from the client, and checked against limits
+// together with the file size. The file is streamed as a data URI when the
+// API request is sent, so fh must stay valid until then.
+func FileFromMultipart(fh *multipart.FileHeader, limits FileLimits) (*FileInput, error) {
+	if limits.MaxSize > 0 && fh.Size > limits.MaxSize {
+		return nil, fmt.Errorf("%w: %s is %d bytes, limit is %d", ErrFileTooLarge, fh.Filename, fh.Size, limits.MaxSize)
+	}
+
+	file, err := fh.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file input: %w", err)
+	}
+	defer file.Close()
+
+	mtype, err := mimetype.DetectReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file input: %w", err)
+	}
+	if !allowedType(mtype, limits.AllowedTypes) {
+		return nil, fmt.Errorf("%w: %s is %s", ErrFileType, fh.Filename, mediaType(mtype))
+	}
+
+	return &FileInput{
+		File:     fh,
+		MIMEType: mediaType(mtype),
+	}, nil
+}
+
+// allowedType reports whether mtype matches one of the allowed types
+func allowedType(mtype *mimetype.MIME, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	for m := mtype; m != nil; m = m.Parent() {
+		for _, a := range allowed {
+			if family, ok := strings.CutSuffix(a, "/*"); ok {
+				if strings.HasPrefix(mediaType(m), family+"/") {
+					return true
+				}
+			} else if m.Is(a) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package base
+
+import (
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+)
+
+// multipartFile posts contents as the file field of a multipart form,
+// declaring contentType for it, and returns the parsed file header
+func multipartFile(t *testing.T, name, contentType, contents string) *multipart.FileHeader {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="file"; filename="`+name+`"`)
+	header.Set("Content-Type", contentType)
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte(contents))
+	if err := mw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("POST", "/upload", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { req.MultipartForm.RemoveAll() })
+
+	_, fh, err := req.FormFile("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fh
+}
+
+func TestFileFromMultipart(t *testing.T) {
+	png := pngHeader + "pixels"
+	tests := []struct {
+		name        string
+		filename    string
+		contentType string
+		contents    string
+		limits      FileLimits
+		wantMIME    string
+		wantErr     error
+	}{
+		{
+			name:        "image",
+			filename:    "cat.png",
+			contentType: "image/png",
+			contents:    png,
+			limits:      ImageUploads,
+			wantMIME:    "image/png",
+		},
+		{
+			name:        "exact type",
+			filename:    "cat.png",
+			contentType: "application/octet-stream",
+			contents:    png,
+			limits:      FileLimits{AllowedTypes: []string{"image/jpeg", "image/png"}},
+			wantMIME:    "image/png",
+		},
+		{
+			name:        "no limits",
+			filename:    "notes.txt",
+			contentType: "text/plain",
+			contents:    "plain text",
+			wantMIME:    "text/plain",
+		},
+		{
+			name:        "oversize",
+			filename:    "cat.png",
+			contentType: "image/png",
+			contents:    png,
+			limits:      FileLimits{MaxSize: 4, AllowedTypes: []string{"image/*"}},
+			wantErr:     ErrFileTooLarge,
+		},
+		{
+			name:        "disallowed type",
+			filename:    "notes.txt",
+			contentType: "text/plain",
+			contents:    "plain text",
+			limits:      ImageUploads,
+			wantErr:     ErrFileType,
+		},
+		{
+			name:        "spoofed content type",
+			filename:    "cat.png",
+			contentType: "image/png",
+			contents:    "#!/bin/sh\necho not an image\n",
+			limits:      ImageUploads,
+			wantErr:     ErrFileType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fh := multipartFile(t, tt.filename, tt.contentType, tt.contents)
+			f, err := FileFromMultipart(fh, tt.limits)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("FileFromMultipart() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if f.MIMEType != tt.wantMIME {
+				t.Errorf("MIMEType = %q, want %q", f.MIMEType, tt.wantMIME)
+			}
+			if got, want := encodedJSON(t, f), dataURI(tt.wantMIME, tt.contents); got != want {
+				t.Errorf("WriteJSON() = %s, want %s", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,375 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Audio formats recognised by ProbeAudio
+const (
+	AudioFormatWAV  = "wav"
+	AudioFormatMP3  = "mp3"
+	AudioFormatFLAC = "flac"
+	AudioFormatM4A  = "m4a"
+)
+
+// ErrUnsupportedAudio is returned for audio that is not WAV, MP3, FLAC or M4A,
+// or whose headers cannot be parsed
+var ErrUnsupportedAudio = errors.New("unsupported audio format")
+
+// AudioInfo describes an audio file
+type AudioInfo struct {
+	// Format is one of the AudioFormat constants
+	Format string
+	// Duration is the playing time; it is estimated from the bitrate for
+	// constant bitrate MP3 files
+	Duration   time.Duration
+	SampleRate int
+	Channels   int
+}
+
+// MIMEType returns the media type of the audio format
+func (i AudioInfo) MIMEType() string {
+	switch i.Format {
+	case AudioFormatWAV:
+		return "audio/wav"
+	case AudioFormatMP3:
+		return "audio/mpeg"
+	case AudioFormatFLAC:
+		return "audio/flac"
+	case AudioFormatM4A:
+		return "audio/mp4"
+	}
+	return "application/octet-stream"
+}
+
+// ProbeAudio reads the headers of an audio file to find its format and
+// duration. Only the headers are read; r is left at an unspecified position.
+func ProbeAudio(r io.ReadSeeker) (AudioInfo, error) {
+	start, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return AudioInfo{}, err
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return AudioInfo{}, err
+	}
+	size := end - start
+
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return AudioInfo{}, err
+	}
+	head := make([]byte, 12)
+	n, err := io.ReadFull(r, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return AudioInfo{}, err
+	}
+	head = head[:n]
+
+	switch {
+	case len(head) >= 12 && string(head[0:4]) == "RIFF" && string(head[8:12]) == "WAVE":
+		return probeWAV(r, start+12, size-12)
+	case len(head) >= 8 && string(head[4:8]) == "ftyp":
+		return probeM4A(r, start, size)
+	}
+
+	// FLAC and MP3 files may start with an ID3v2 tag
+	offset := int64(0)
+	if len(head) >= 10 && string(head[0:3]) == "ID3" {
+		offset = 10 + int64(synchsafe(head[6:10]))
+		if head[5]&0x10 != 0 {
+			offset += 10
+		}
+	}
+	if _, err := r.Seek(start+offset, io.SeekStart); err != nil {
+		return AudioInfo{}, err
+	}
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return AudioInfo{}, ErrUnsupportedAudio
+	}
+	if string(magic) == "fLaC" {
+		return probeFLAC(r)
+	}
+	return probeMP3(r, start+offset, size-offset)
+}
+
+// probeWAV reads the fmt and data chunks of a RIFF/WAVE file. r is positioned
+// at the first chunk, remaining bytes before the end of the file.
+func probeWAV(r io.ReadSeeker, pos, remaining int64) (AudioInfo, error) {
+	info := AudioInfo{Format: AudioFormatWAV}
+	var byteRate uint32
+	header := make([]byte, 8)
+
+	for remaining >= 8 {
+		if _, err := io.ReadFull(r, header); err != nil {
+			break
+		}
+		id := string(header[0:4])
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+		pos += 8
+		remaining -= 8
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return AudioInfo{}, fmt.Errorf("%w: truncated WAV fmt chunk", ErrUnsupportedAudio)
+			}
+			fmtChunk := make([]byte, 16)
+			if _, err := io.ReadFull(r, fmtChunk); err != nil {
+				return AudioInfo{}, fmt.Errorf("%w: truncated WAV fmt chunk", ErrUnsupportedAudio)
+			}
+			info.Channels = int(binary.LittleEndian.Uint16(fmtChunk[2:4]))
+			info.SampleRate = int(binary.LittleEndian.Uint32(fmtChunk[4:8]))
+			byteRate = binary.LittleEndian.Uint32(fmtChunk[8:12])
+		case "data":
+			if byteRate == 0 {
+				return AudioInfo{}, fmt.Errorf("%w: WAV data before fmt chunk", ErrUnsupportedAudio)
+			}
+			// Streamed WAV files may leave the size unset
+			if size == 0 || size == unsetWAVSize || size > remaining {
+				size = remaining
+			}
+			info.Duration = time.Duration(float64(size) / float64(byteRate) * float64(time.Second))
+			return info, nil
+		}
+
+		// Chunks are padded to an even size
+		skip := size + size%2
+		pos += skip
+		remaining -= skip
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return AudioInfo{}, err
+		}
+	}
+	return AudioInfo{}, fmt.Errorf("%w: WAV file has no data chunk", ErrUnsupportedAudio)
+}
+
+// probeFLAC reads the STREAMINFO block that follows the "fLaC" marker
+func probeFLAC(r io.Reader) (AudioInfo, error) {
+	block := make([]byte, 4+34)
+	if _, err := io.ReadFull(r, block); err != nil || block[0]&0x7F != 0 {
+		return AudioInfo{}, fmt.Errorf("%w: missing FLAC STREAMINFO", ErrUnsupportedAudio)
+	}
+
+	info := block[4:]
+	packed := binary.BigEndian.Uint64(info[10:18])
+	sampleRate := int(packed >> 44)
+	channels := int(packed>>41&0x7) + 1
+	samples := packed & 0xFFFFFFFFF
+
+	result := AudioInfo{Format: AudioFormatFLAC, SampleRate: sampleRate, Channels: channels}
+	if sampleRate > 0 {
+		result.Duration = time.Duration(float64(samples) / float64(sampleRate) * float64(time.Second))
+	}
+	return result, nil
+}
+
+// probeM4A reads the movie header of an MPEG-4 file
+func probeM4A(r io.ReadSeeker, start, size int64) (AudioInfo, error) {
+	moov, moovSize, err := findBox(r, start, size, "moov")
+	if err != nil {
+		return AudioInfo{}, err
+	}
+	mvhd, _, err := findBox(r, moov, moovSize, "mvhd")
+	if err != nil {
+		return AudioInfo{}, err
+	}
+
+	if _, err := r.Seek(mvhd, io.SeekStart); err != nil {
+		return AudioInfo{}, err
+	}
+	header := make([]byte, 32)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return AudioInfo{}, fmt.Errorf("%w: truncated MPEG-4 mvhd box", ErrUnsupportedAudio)
+	}
+
+	var timescale, duration uint64
+	if header[0] == 1 {
+		timescale = uint64(binary.BigEndian.Uint32(header[20:24]))
+		duration = binary.BigEndian.Uint64(header[24:32])
+	} else {
+		timescale = uint64(binary.BigEndian.Uint32(header[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(header[16:20]))
+	}
+
+	info := AudioInfo{Format: AudioFormatM4A}
+	if timescale > 0 {
+		info.Duration = time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+	}
+	return info, nil
+}
+
+// findBox returns the payload offset and size of the first box of type name
+// among the boxes between start and start+size
+func findBox(r io.ReadSeeker, start, size int64, name string) (int64, int64, error) {
+	header := make([]byte, 16)
+	for pos, end := start, start+size; pos+8 <= end; {
+		if _, err := r.Seek(pos, io.SeekStart); err != nil {
+			return 0, 0, err
+		}
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			break
+		}
+
+		boxSize := int64(binary.BigEndian.Uint32(header[0:4]))
+		headerSize := int64(8)
+		switch boxSize {
+		case 0:
+			boxSize = end - pos
+		case 1:
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return 0, 0, fmt.Errorf("%w: truncated MPEG-4 box", ErrUnsupportedAudio)
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if boxSize < headerSize {
+			break
+		}
+
+		if string(header[4:8]) == name {
+			return pos + headerSize, boxSize - headerSize, nil
+		}
+		pos += boxSize
+	}
+	return 0, 0, fmt.Errorf("%w: MPEG-4 file has no %s box", ErrUnsupportedAudio, name)
+}
+
+var (
+	mp3Bitrates = [2][16]int{
+		// MPEG-1 Layer III
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+		// MPEG-2 and MPEG-2.5 Layer III
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	}
+	mp3SampleRates = [4][3]int{
+		{11025, 12000, 8000},  // MPEG-2.5
+		{0, 0, 0},             // reserved
+		{22050, 24000, 16000}, // MPEG-2
+		{44100, 48000, 32000}, // MPEG-1
+	}
+)
+
+// mp3SyncWindow is how far past the start of the audio a frame sync is
+// searched for
+const mp3SyncWindow = 64 * 1024
+
+// mp3Frame is a decoded MPEG audio frame header
+type mp3Frame struct {
+	mpeg1      bool
+	bitrate    int
+	sampleRate int
+	channels   int
+	length     int
+}
+
+// parseMP3Frame decodes a Layer III frame header
+func parseMP3Frame(h []byte) (mp3Frame, bool) {
+	if len(h) < 4 || h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return mp3Frame{}, false
+	}
+	version := int(h[1] >> 3 & 0x3)
+	layer := h[1] >> 1 & 0x3
+	bitrateIndex := h[2] >> 4
+	rateIndex := h[2] >> 2 & 0x3
+	if version == 1 || layer != 1 || bitrateIndex == 0 || bitrateIndex == 15 || rateIndex == 3 {
+		return mp3Frame{}, false
+	}
+
+	f := mp3Frame{mpeg1: version == 3, sampleRate: mp3SampleRates[version][rateIndex], channels: 2}
+	if f.mpeg1 {
+		f.bitrate = mp3Bitrates[0][bitrateIndex] * 1000
+		f.length = 144 * f.bitrate / f.sampleRate
+	} else {
+		f.bitrate = mp3Bitrates[1][bitrateIndex] * 1000
+		f.length = 72 * f.bitrate / f.sampleRate
+	}
+	f.length += int(h[2] >> 1 & 0x1)
+	if h[3]>>6 == 3 {
+		f.channels = 1
+	}
+	return f, true
+}
+
+// samplesPerFrame returns the number of samples per channel in a frame
+func (f mp3Frame) samplesPerFrame() int {
+	if f.mpeg1 {
+		return 1152
+	}
+	return 576
+}
+
+// probeMP3 finds the first MPEG audio frame and reads the duration from a
+// Xing or VBRI header, falling back to the constant bitrate estimate
+func probeMP3(r io.ReadSeeker, start, size int64) (AudioInfo, error) {
+	if _, err := r.Seek(start, io.SeekStart); err != nil {
+		return AudioInfo{}, err
+	}
+	window := make([]byte, mp3SyncWindow)
+	n, err := io.ReadFull(r, window)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return AudioInfo{}, err
+	}
+	window = window[:n]
+
+	for i := 0; i+4 <= len(window); i++ {
+		f, ok := parseMP3Frame(window[i:])
+		if !ok {
+			continue
+		}
+		// Require a second frame right after the first, unless the file
+		// ends first, to avoid false syncs in other data
+		if next := i + f.length; next+4 <= len(window) {
+			if _, ok := parseMP3Frame(window[next:]); !ok {
+				continue
+			}
+		}
+
+		info := AudioInfo{Format: AudioFormatMP3, SampleRate: f.sampleRate, Channels: f.channels}
+		if frames := mp3FrameCount(window[i:], f); frames > 0 {
+			info.Duration = time.Duration(float64(frames) * float64(f.samplesPerFrame()) / float64(f.sampleRate) * float64(time.Second))
+		} else {
+			audioBytes := size - int64(i)
+			info.Duration = time.Duration(float64(audioBytes) * 8 / float64(f.bitrate) * float64(time.Second))
+		}
+		return info, nil
+	}
+	return AudioInfo{}, ErrUnsupportedAudio
+}
+
+// mp3FrameCount returns the frame count stored in a Xing, Info or VBRI header
+// in the first frame, or 0 when there is none
+func mp3FrameCount(frame []byte, f mp3Frame) int64 {
+	sideInfo := 32
+	switch {
+	case f.mpeg1 && f.channels == 1:
+		sideInfo = 17
+	case !f.mpeg1 && f.channels == 2:
+		sideInfo = 17
+	case !f.mpeg1:
+		sideInfo = 9
+	}
+
+	if x := 4 + sideInfo; len(frame) >= x+12 {
+		tag := frame[x : x+4]
+		if bytes.Equal(tag, []byte("Xing")) || bytes.Equal(tag, []byte("Info")) {
+			if flags := binary.BigEndian.Uint32(frame[x+4 : x+8]); flags&0x1 != 0 {
+				return int64(binary.BigEndian.Uint32(frame[x+8 : x+12]))
+			}
+		}
+	}
+	if v := 4 + 32; len(frame) >= v+18 && bytes.Equal(frame[v:v+4], []byte("VBRI")) {
+		return int64(binary.BigEndian.Uint32(frame[v+14 : v+18]))
+	}
+	return 0
+}
+
+// synchsafe decodes a 28-bit ID3v2 synchsafe integer
+func synchsafe(b []byte) int {
+	return int(b[0]&0x7F)<<21 | int(b[1]&0x7F)<<14 | int(b[2]&0x7F)<<7 | int(b[3]&0x7F)
+}
@@ -0,0 +1,246 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/internal/testutil"
+)
+
+// MPEG audio frame headers used to build test streams
+var (
+	// mp3Stereo is MPEG-1 Layer III, 128 kbps, 44.1 kHz, stereo: 417 bytes
+	mp3Stereo = []byte{0xFF, 0xFB, 0x90, 0x00}
+	// mp3Mono22k is MPEG-2 Layer III, 64 kbps, 22.05 kHz, mono: 208 bytes
+	mp3Mono22k = []byte{0xFF, 0xF3, 0x80, 0xC0}
+)
+
+// mp3Stream returns frames copies of the frame with header h. When tag is
+// set it is written into the first frame at offset, followed by the frame
+// count at countOffset bytes after the tag.
+func mp3Stream(h []byte, frames int, tag string, offset, countOffset int, count uint32) []byte {
+	f, _ := parseMP3Frame(h)
+	out := make([]byte, 0, frames*f.length)
+	for i := 0; i < frames; i++ {
+		frame := make([]byte, f.length)
+		copy(frame, h)
+		if i == 0 && tag != "" {
+			copy(frame[offset:], tag)
+			if tag != "VBRI" {
+				// Xing flags: the frame count is present
+				binary.BigEndian.PutUint32(frame[offset+4:], 1)
+			}
+			binary.BigEndian.PutUint32(frame[offset+countOffset:], count)
+		}
+		out = append(out, frame...)
+	}
+	return out
+}
+
+// box returns an MPEG-4 box of type name holding payload
+func box(name string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(out, name...), body...)
+}
+
+// largeBox returns an MPEG-4 box with a 64-bit size
+func largeBox(name string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	out := binary.BigEndian.AppendUint32(nil, 1)
+	out = append(out, name...)
+	out = binary.BigEndian.AppendUint64(out, uint64(16+len(body)))
+	return append(out, body...)
+}
+
+// mvhd returns a movie header box payload of the given version
+func mvhd(version byte, timescale uint32, duration uint64) []byte {
+	p := make([]byte, 100)
+	p[0] = version
+	if version == 1 {
+		binary.BigEndian.PutUint32(p[20:], timescale)
+		binary.BigEndian.PutUint64(p[24:], duration)
+	} else {
+		binary.BigEndian.PutUint32(p[12:], timescale)
+		binary.BigEndian.PutUint32(p[16:], uint32(duration))
+	}
+	return p
+}
+
+// flacFile returns a FLAC file header with a STREAMINFO block
+func flacFile(sampleRate, channels int, samples uint64) []byte {
+	info := make([]byte, 34)
+	packed := uint64(sampleRate)<<44 | uint64(channels-1)<<41 | uint64(15)<<36 | samples
+	binary.BigEndian.PutUint64(info[10:18], packed)
+	out := append([]byte("fLaC"), 0x80, 0, 0, 34)
+	return append(out, info...)
+}
+
+func TestProbeAudio(t *testing.T) {
+	ftyp := box("ftyp", []byte("M4A \x00\x00\x00\x00"))
+	id3 := append([]byte("ID3\x04\x00\x00\x00\x00\x00\x14"), make([]byte, 20)...)
+	streamed := testutil.WAV(8000, 1, make([]float32, 8000))
+	binary.LittleEndian.PutUint32(streamed[40:44], 0xFFFFFFFF)
+
+	tests := []struct {
+		name string
+		data []byte
+		want AudioInfo
+	}{
+		{
+			name: "wav",
+			data: testutil.WAV(16000, 2, make([]float32, 2*8000)),
+			want: AudioInfo{Format: AudioFormatWAV, Duration: 500 * time.Millisecond, SampleRate: 16000, Channels: 2},
+		},
+		{
+			name: "streamed wav",
+			data: streamed,
+			want: AudioInfo{Format: AudioFormatWAV, Duration: time.Second, SampleRate: 8000, Channels: 1},
+		},
+		{
+			name: "cbr mp3",
+			data: mp3Stream(mp3Stereo, 100, "", 0, 0, 0),
+			want: AudioInfo{Format: AudioFormatMP3, Duration: 2606250 * time.Microsecond, SampleRate: 44100, Channels: 2},
+		},
+		{
+			name: "cbr mp3 after id3",
+			data: append(id3, mp3Stream(mp3Stereo, 100, "", 0, 0, 0)...),
+			want: AudioInfo{Format: AudioFormatMP3, Duration: 2606250 * time.Microsecond, SampleRate: 44100, Channels: 2},
+		},
+		{
+			name: "xing mp3",
+			data: mp3Stream(mp3Stereo, 3, "Xing", 4+32, 8, 441),
+			want: AudioInfo{Format: AudioFormatMP3, Duration: 11520 * time.Millisecond, SampleRate: 44100, Channels: 2},
+		},
+		{
+			name: "info mp3 mpeg-2 mono",
+			data: mp3Stream(mp3Mono22k, 3, "Info", 4+9, 8, 1225),
+			want: AudioInfo{Format: AudioFormatMP3, Duration: 32 * time.Second, SampleRate: 22050, Channels: 1},
+		},
+		{
+			name: "vbri mp3",
+			data: mp3Stream(mp3Stereo, 3, "VBRI", 4+32, 14, 4410),
+			want: AudioInfo{Format: AudioFormatMP3, Duration: 115200 * time.Millisecond, SampleRate: 44100, Channels: 2},
+		},
+		{
+			name: "flac",
+			data: flacFile(48000, 2, 48000*90),
+			want: AudioInfo{Format: AudioFormatFLAC, Duration: 90 * time.Second, SampleRate: 48000, Channels: 2},
+		},
+		{
+			name: "flac after id3",
+			data: append(id3, flacFile(44100, 1, 22050)...),
+			want: AudioInfo{Format: AudioFormatFLAC, Duration: 500 * time.Millisecond, SampleRate: 44100, Channels: 1},
+		},
+		{
+			name: "m4a mvhd v0",
+			data: bytes.Join([][]byte{ftyp, box("free"), box("moov", box("trak"), box("mvhd", mvhd(0, 1000, 12500)))}, nil),
+			want: AudioInfo{Format: AudioFormatM4A, Duration: 12500 * time.Millisecond},
+		},
+		{
+			name: "m4a mvhd v1",
+			data: bytes.Join([][]byte{ftyp, box("moov", box("mvhd", mvhd(1, 44100, 44100*3600)))}, nil),
+			want: AudioInfo{Format: AudioFormatM4A, Duration: time.Hour},
+		},
+		{
+			name: "m4a 64-bit boxes",
+			data: bytes.Join([][]byte{ftyp, largeBox("mdat", make([]byte, 64)), largeBox("moov", largeBox("mvhd", mvhd(0, 600, 1800)))}, nil),
+			want: AudioInfo{Format: AudioFormatM4A, Duration: 3 * time.Second},
+		},
+		{
+			name: "m4a box to end of file",
+			data: append(ftyp, append([]byte{0, 0, 0, 0, 'm', 'o', 'o', 'v'}, box("mvhd", mvhd(0, 10, 25))...)...),
+			want: AudioInfo{Format: AudioFormatM4A, Duration: 2500 * time.Millisecond},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProbeAudio(bytes.NewReader(tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("ProbeAudio() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbeAudioUnsupported(t *testing.T) {
+	ftyp := box("ftyp", []byte("M4A \x00\x00\x00\x00"))
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: nil},
+		{name: "text", data: []byte("this is not audio at all")},
+		{name: "wav without data", data: testutil.WAVFile(testutil.WAVFmt(1, 1, 8000, 16, 0), nil)[:36]},
+		{name: "m4a without moov", data: bytes.Join([][]byte{ftyp, box("mdat", make([]byte, 32))}, nil)},
+		{name: "m4a without mvhd", data: bytes.Join([][]byte{ftyp, box("moov", box("trak"))}, nil)},
+		{name: "m4a truncated large box", data: append(ftyp, 0, 0, 0, 1, 'm', 'o', 'o', 'v', 0, 0)},
+		{name: "flac without streaminfo", data: []byte("fLaC\x04\x00\x00\x22")},
+		// A lone frame sync followed by data that is not a second frame
+		{name: "false mp3 sync", data: append(mp3Stream(mp3Stereo, 1, "", 0, 0, 0), bytes.Repeat([]byte{0x55}, 600)...)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if info, err := ProbeAudio(bytes.NewReader(tt.data)); !errors.Is(err, ErrUnsupportedAudio) {
+				t.Errorf("ProbeAudio() = %+v, %v, want ErrUnsupportedAudio", info, err)
+			}
+		})
+	}
+}
+
+func TestFindBox(t *testing.T) {
+	data := bytes.Join([][]byte{box("ftyp", make([]byte, 8)), largeBox("mdat", make([]byte, 40)), box("moov", make([]byte, 12))}, nil)
+	r := bytes.NewReader(data)
+
+	pos, size, err := findBox(r, 0, int64(len(data)), "moov")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := int64(16 + 56 + 8); pos != want || size != 12 {
+		t.Errorf("findBox(moov) = %d, %d, want %d, 12", pos, size, want)
+	}
+
+	pos, size, err = findBox(r, 0, int64(len(data)), "mdat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos != 16+16 || size != 40 {
+		t.Errorf("findBox(mdat) = %d, %d, want 32, 40", pos, size)
+	}
+
+	// The search stops at the end of the range
+	if _, _, err := findBox(r, 0, 16, "moov"); !errors.Is(err, ErrUnsupportedAudio) {
+		t.Errorf("findBox() past the range = %v, want ErrUnsupportedAudio", err)
+	}
+}
+
+func TestMP3FrameCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame []byte
+		want  int64
+	}{
+		{name: "xing", frame: mp3Stream(mp3Stereo, 1, "Xing", 4+32, 8, 1234), want: 1234},
+		{name: "info mono", frame: mp3Stream(mp3Mono22k, 1, "Info", 4+9, 8, 99), want: 99},
+		{name: "vbri", frame: mp3Stream(mp3Stereo, 1, "VBRI", 4+32, 14, 5678), want: 5678},
+		{name: "xing at the wrong offset", frame: mp3Stream(mp3Stereo, 1, "Xing", 4+17, 8, 1234), want: 0},
+		{name: "none", frame: mp3Stream(mp3Stereo, 1, "", 0, 0, 0), want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, ok := parseMP3Frame(tt.frame)
+			if !ok {
+				t.Fatal("test frame header does not parse")
+			}
+			if got := mp3FrameCount(tt.frame, f); got != tt.want {
+				t.Errorf("mp3FrameCount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Seekable returns r as an io.ReadSeeker, copying it to a temporary file when
+// it cannot seek. The returned function releases the temporary file and must
+// be called once the reader is no longer needed.
+func Seekable(r io.Reader) (io.ReadSeeker, func(), error) {
+	if rs, ok := r.(io.ReadSeeker); ok {
+		return rs, func() {}, nil
+	}
+
+	tmp, err := os.CreateTemp("", "modelslab-*")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to buffer reader: %w", err)
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to buffer reader: %w", err)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to buffer reader: %w", err)
+	}
+	return tmp, cleanup, nil
+}
@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// WriteJSONString writes s to w as a quoted JSON string, escaping it the same
+// way encoding/json does. Unlike json.Marshal it never copies s, so it is
+// suited to multi-megabyte values such as base64 payloads.
+func WriteJSONString(w io.Writer, s string) error {
+	sw, ok := w.(io.StringWriter)
+	if !ok {
+		sw = stringWriter{w}
+	}
+
+	if _, err := sw.WriteString(`"`); err != nil {
+		return err
+	}
+	if err := WriteJSONEscaped(w, s); err != nil {
+		return err
+	}
+	_, err := sw.WriteString(`"`)
+	return err
+}
+
+// WriteJSONEscaped writes s escaped as the contents of a JSON string, without
+// the surrounding quotes
+func WriteJSONEscaped(w io.Writer, s string) error {
+	sw, ok := w.(io.StringWriter)
+	if !ok {
+		sw = stringWriter{w}
+	}
+
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+
+			if err := writeRun(sw, s[start:i]); err != nil {
+				return err
+			}
+
+			var escaped string
+			switch b {
+			case '"':
+				escaped = `\"`
+			case '\\':
+				escaped = `\\`
+			case '\n':
+				escaped = `\n`
+			case '\r':
+				escaped = `\r`
+			case '\t':
+				escaped = `\t`
+			default:
+				escaped = `\u00` + string(hexDigits[b>>4]) + string(hexDigits[b&0xF])
+			}
+			if _, err := sw.WriteString(escaped); err != nil {
+				return err
+			}
+
+			i++
+			start = i
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if (r == utf8.RuneError && size == 1) || r == '\u2028' || r == '\u2029' {
+			if err := writeRun(sw, s[start:i]); err != nil {
+				return err
+			}
+
+			escaped := `\ufffd`
+			if r == '\u2028' {
+				escaped = `\u2028`
+			} else if r == '\u2029' {
+				escaped = `\u2029`
+			}
+			if _, err := sw.WriteString(escaped); err != nil {
+				return err
+			}
+
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+
+	return writeRun(sw, s[start:])
+}
+
+// writeRun writes a run of characters that need no escaping
+func writeRun(w io.StringWriter, s string) error {
+	if s == "" {
+		return nil
+	}
+	_, err := w.WriteString(s)
+	return err
+}
+
+// stringWriter adapts an io.Writer to io.StringWriter
+type stringWriter struct {
+	w io.Writer
+}
+
+func (s stringWriter) WriteString(str string) (int, error) {
+	return s.w.Write([]byte(str))
+}
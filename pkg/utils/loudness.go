@@ -0,0 +1,282 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Loudness measurement constants from ITU-R BS.1770-4 and EBU R128
+const (
+	// loudnessBlock is the gating block length
+	loudnessBlock = 400 * time.Millisecond
+	// loudnessStep is the gating block step, a 75% overlap
+	loudnessStep = 100 * time.Millisecond
+	// absoluteGate drops blocks quieter than this, in LUFS
+	absoluteGate = -70.0
+	// relativeGate drops blocks this many LU below the ungated loudness
+	relativeGate = -10.0
+)
+
+// Limiter timing
+const (
+	limiterLookahead = 5 * time.Millisecond
+	limiterRelease   = 50 * time.Millisecond
+)
+
+// ErrSilentAudio is returned when normalizing audio that has no measurable
+// loudness
+var ErrSilentAudio = errors.New("audio is silent")
+
+// biquad is a second order IIR filter section
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+// process filters one sample using the transposed direct form II
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
+
+// kWeighting returns the two filter stages of the BS.1770 K-weighting curve
+// for a sample rate: a high shelf modelling the head, then a high pass
+func kWeighting(sampleRate int) (biquad, biquad) {
+	k := math.Tan(math.Pi * 1681.974450955533 / float64(sampleRate))
+	q := 0.7071752369554196
+	vh := math.Pow(10, 3.999843853973347/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	k = math.Tan(math.Pi * 38.13547087602444 / float64(sampleRate))
+	q = 0.5003270373238773
+	a0 = 1 + k/q + k*k
+	highPass := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return shelf, highPass
+}
+
+// channelWeight returns the BS.1770 weight of a channel. In 5.1 audio the
+// LFE channel is ignored and the surround channels weigh 1.41.
+func channelWeight(channel, channels int) float64 {
+	if channels == 6 {
+		switch channel {
+		case 3:
+			return 0
+		case 4, 5:
+			return 1.41
+		}
+	}
+	return 1
+}
+
+// Loudness returns the integrated loudness in LUFS, measured as specified by
+// ITU-R BS.1770-4 and EBU R128. Silent audio returns negative infinity.
+func (p *PCM) Loudness() float64 {
+	frames := p.Frames()
+	if frames == 0 || p.SampleRate == 0 {
+		return math.Inf(-1)
+	}
+
+	// Sum the K-weighted squares over every step-sized piece of audio, so
+	// each block adds up the four pieces it spans
+	step := int(loudnessStep.Seconds() * float64(p.SampleRate))
+	perBlock := int(loudnessBlock / loudnessStep)
+	pieces := make([]float64, (frames+step-1)/step)
+	for c := 0; c < p.Channels; c++ {
+		weight := channelWeight(c, p.Channels)
+		if weight == 0 {
+			continue
+		}
+		shelf, highPass := kWeighting(p.SampleRate)
+		for i := 0; i < frames; i++ {
+			y := highPass.process(shelf.process(float64(p.Samples[i*p.Channels+c])))
+			pieces[i/step] += weight * y * y
+		}
+	}
+
+	// Blocks only span whole pieces, except that audio shorter than one
+	// block is measured as a single block
+	blockLen := perBlock * step
+	full := frames / step
+	if frames < blockLen {
+		perBlock = len(pieces)
+		blockLen = frames
+		full = len(pieces)
+	}
+
+	var blocks []float64
+	for start := 0; start+perBlock <= full; start++ {
+		var sum float64
+		for _, piece := range pieces[start : start+perBlock] {
+			sum += piece
+		}
+		blocks = append(blocks, sum/float64(blockLen))
+	}
+
+	gated := gateBlocks(blocks, absoluteGate)
+	if len(gated) == 0 {
+		return math.Inf(-1)
+	}
+	gated = gateBlocks(gated, blockLoudness(mean(gated))+relativeGate)
+	if len(gated) == 0 {
+		return math.Inf(-1)
+	}
+	return blockLoudness(mean(gated))
+}
+
+// gateBlocks returns the blocks louder than threshold
+func gateBlocks(blocks []float64, threshold float64) []float64 {
+	var out []float64
+	for _, z := range blocks {
+		if blockLoudness(z) > threshold {
+			out = append(out, z)
+		}
+	}
+	return out
+}
+
+// blockLoudness converts a weighted mean square to LUFS
+func blockLoudness(z float64) float64 {
+	return -0.691 + 10*math.Log10(z)
+}
+
+// mean returns the average of values
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// Peak returns the highest sample level in dBFS. Silent audio returns
+// negative infinity.
+func (p *PCM) Peak() float64 {
+	var peak float64
+	for _, s := range p.Samples {
+		peak = math.Max(peak, math.Abs(float64(s)))
+	}
+	return decibels(peak)
+}
+
+// Gain returns the audio amplified by db decibels
+func (p *PCM) Gain(db float64) *PCM {
+	out := p.Clone()
+	factor := float32(amplitude(db))
+	for i := range out.Samples {
+		out.Samples[i] *= factor
+	}
+	return out
+}
+
+// NormalizeLoudness returns the audio amplified to an integrated loudness of
+// target LUFS. Loud targets can push peaks past full scale, so it is usually
+// followed by Limit.
+func (p *PCM) NormalizeLoudness(target float64) (*PCM, error) {
+	loudness := p.Loudness()
+	if math.IsInf(loudness, -1) {
+		return nil, ErrSilentAudio
+	}
+	return p.Gain(target - loudness), nil
+}
+
+// Limit returns the audio with peaks held below ceiling dBFS. The gain is
+// lowered ahead of each peak and recovers smoothly afterwards, which avoids
+// the distortion of clipping.
+func (p *PCM) Limit(ceiling float64) *PCM {
+	out := p.Clone()
+	frames := p.Frames()
+	if frames == 0 {
+		return out
+	}
+	limit := amplitude(ceiling)
+
+	// The gain each frame needs on its own
+	need := make([]float64, frames)
+	for i := range need {
+		need[i] = 1
+		for c := 0; c < p.Channels; c++ {
+			if level := math.Abs(float64(p.Samples[i*p.Channels+c])); level > limit {
+				need[i] = math.Min(need[i], limit/level)
+			}
+		}
+	}
+
+	lookahead := int(limiterLookahead.Seconds()*float64(p.SampleRate)) + 1
+	release := math.Exp(-1 / (limiterRelease.Seconds() * float64(p.SampleRate)))
+
+	// Holding the minimum over the lookahead window and then averaging over
+	// the same window ramps the gain down in time and never lets a frame
+	// exceed the gain it needs
+	held := slidingMin(need, lookahead)
+	smooth := 1.0
+	for i, g := range held {
+		if g < smooth {
+			smooth = g
+		} else {
+			smooth = g + (smooth-g)*release
+		}
+		held[i] = smooth
+	}
+
+	// Frames before the start take the gain of the first one, which is low
+	// enough for every frame the first window covers
+	sum := held[0] * float64(lookahead)
+	for i := 0; i < frames; i++ {
+		sum += held[i]
+		if i >= lookahead {
+			sum -= held[i-lookahead]
+		} else {
+			sum -= held[0]
+		}
+		gain := float32(sum / float64(lookahead))
+		for c := 0; c < p.Channels; c++ {
+			out.Samples[i*p.Channels+c] *= gain
+		}
+	}
+	return out
+}
+
+// slidingMin returns, for each index, the minimum of values over the window
+// starting there
+func slidingMin(values []float64, window int) []float64 {
+	out := make([]float64, len(values))
+	var deque []int
+	for i := len(values) - 1; i >= 0; i-- {
+		for len(deque) > 0 && values[deque[len(deque)-1]] >= values[i] {
+			deque = deque[:len(deque)-1]
+		}
+		deque = append(deque, i)
+		if deque[0] >= i+window {
+			deque = deque[1:]
+		}
+		out[i] = values[deque[0]]
+	}
+	return out
+}
+
+// decibels converts an amplitude to dBFS
+func decibels(amplitude float64) float64 {
+	return 20 * math.Log10(amplitude)
+}
+
+// amplitude converts dBFS to an amplitude
+func amplitude(db float64) float64 {
+	return math.Pow(10, db/20)
+}
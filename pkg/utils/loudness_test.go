@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/internal/testutil"
+)
+
+// sine wraps testutil.Sine as a PCM clip
+func sine(sampleRate, channels int, freq, level float64, d time.Duration) *PCM {
+	return &PCM{SampleRate: sampleRate, Channels: channels, Samples: testutil.Sine(sampleRate, channels, freq, level, d)}
+}
+
+// tones joins stereo 1 kHz tones at 48 kHz
+func tones(ts ...testutil.Tone) *PCM {
+	return &PCM{SampleRate: 48000, Channels: 2, Samples: testutil.Tones(48000, 2, 1000, ts...)}
+}
+
+// TestLoudnessEBU measures the EBU Tech 3341 minimum requirement signals,
+// which must read within 0.1 LU of the expected loudness
+func TestLoudnessEBU(t *testing.T) {
+	tests := []struct {
+		name  string
+		tones []testutil.Tone
+		want  float64
+	}{
+		{name: "case 1", tones: []testutil.Tone{{Level: -23, Duration: 20 * time.Second}}, want: -23},
+		{name: "case 2", tones: []testutil.Tone{{Level: -33, Duration: 20 * time.Second}}, want: -33},
+		{name: "case 3", tones: []testutil.Tone{
+			{Level: -36, Duration: 10 * time.Second}, {Level: -23, Duration: 60 * time.Second},
+			{Level: -36, Duration: 10 * time.Second},
+		}, want: -23},
+		{name: "case 4", tones: []testutil.Tone{
+			{Level: -72, Duration: 10 * time.Second}, {Level: -36, Duration: 10 * time.Second}, {Level: -23, Duration: 60 * time.Second},
+			{Level: -36, Duration: 10 * time.Second}, {Level: -72, Duration: 10 * time.Second},
+		}, want: -23},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tones(tt.tones...).Loudness(); math.Abs(got-tt.want) > 0.1 {
+				t.Errorf("Loudness() = %.2f LUFS, want %.1f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoudnessSilence(t *testing.T) {
+	if got := Silence(48000, 2, time.Second).Loudness(); !math.IsInf(got, -1) {
+		t.Errorf("Loudness() of silence = %v, want -Inf", got)
+	}
+	if _, err := Silence(48000, 2, time.Second).NormalizeLoudness(-16); !errors.Is(err, ErrSilentAudio) {
+		t.Errorf("NormalizeLoudness() of silence = %v, want ErrSilentAudio", err)
+	}
+}
+
+func TestNormalizeLoudness(t *testing.T) {
+	out, err := sine(48000, 1, 440, -30, 3*time.Second).NormalizeLoudness(-16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.Loudness(); math.Abs(got+16) > 0.1 {
+		t.Errorf("normalized loudness = %.2f LUFS, want -16", got)
+	}
+}
+
+func TestLimit(t *testing.T) {
+	in := sine(48000, 1, 440, 6, time.Second)
+	out := in.Limit(-1)
+	if got := out.Peak(); got > -1+0.01 {
+		t.Errorf("Peak() after Limit(-1) = %.2f dBFS, want at most -1", got)
+	}
+	if got := in.Peak(); math.Abs(got-6) > 0.01 {
+		t.Errorf("Limit changed its input: peak = %.2f dBFS, want 6", got)
+	}
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/hajimehoshi/go-mp3"
+)
+
+// mp3BlockSize is how much decoded audio is converted at a time
+const mp3BlockSize = 64 * 1024
+
+// DecodeMP3 decodes an MP3 file. As with DecodeAudio, readers that cannot
+// seek are buffered in memory.
+func DecodeMP3(r io.Reader) (*PCM, error) {
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read MP3: %w", err)
+		}
+		rs = bytes.NewReader(data)
+	}
+
+	start, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read MP3: %w", err)
+	}
+	channels := 2
+	if info, err := ProbeAudio(rs); err == nil && info.Format == AudioFormatMP3 {
+		channels = info.Channels
+	}
+	if _, err := rs.Seek(start, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read MP3: %w", err)
+	}
+	return decodeMP3(rs, channels)
+}
+
+// decodeMP3 decodes MP3 data. The decoder always produces 16-bit stereo, so
+// mono files are folded back to one channel. The decoded bytes are converted
+// a block at a time rather than held in memory.
+func decodeMP3(r io.Reader, channels int) (*PCM, error) {
+	// Hide any Seek method: the decoder would rewind a seeker to its
+	// absolute start and scan the whole file to learn its length
+	decoder, err := mp3.NewDecoder(struct{ io.Reader }{r})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupportedAudio, err)
+	}
+
+	stereo := &PCM{SampleRate: decoder.SampleRate(), Channels: 2}
+
+	block := make([]byte, mp3BlockSize)
+	for {
+		n, err := io.ReadFull(decoder, block)
+		for b := block[:n-n%2]; len(b) > 0; b = b[2:] {
+			stereo.Samples = append(stereo.Samples, float32(int16(binary.LittleEndian.Uint16(b)))/32768)
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode MP3: %w", err)
+		}
+	}
+
+	if channels == 1 {
+		return stereo.ConvertChannels(1)
+	}
+	return stereo, nil
+}
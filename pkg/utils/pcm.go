@@ -0,0 +1,320 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// resampleZeros is the number of sinc zero crossings on each side of a
+// resampled sample
+const resampleZeros = 16
+
+// PCM is decoded audio. Samples are interleaved by channel and scaled to
+// [-1, 1].
+type PCM struct {
+	SampleRate int
+	Channels   int
+	Samples    []float32
+}
+
+// Frames returns the number of samples per channel
+func (p *PCM) Frames() int {
+	if p.Channels == 0 {
+		return 0
+	}
+	return len(p.Samples) / p.Channels
+}
+
+// Duration returns the playing time
+func (p *PCM) Duration() time.Duration {
+	if p.SampleRate == 0 {
+		return 0
+	}
+	return time.Duration(float64(p.Frames()) / float64(p.SampleRate) * float64(time.Second))
+}
+
+// Interval is a span of audio
+type Interval struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Clone returns a copy of the audio
+func (p *PCM) Clone() *PCM {
+	return &PCM{
+		SampleRate: p.SampleRate,
+		Channels:   p.Channels,
+		Samples:    append([]float32(nil), p.Samples...),
+	}
+}
+
+// frame returns the index of the frame at d, clamped to the audio
+func (p *PCM) frame(d time.Duration) int {
+	frame := int(math.Round(d.Seconds() * float64(p.SampleRate)))
+	if frame < 0 {
+		return 0
+	}
+	if frames := p.Frames(); frame > frames {
+		return frames
+	}
+	return frame
+}
+
+// Slice returns a copy of the audio between start and end. An end of 0 or
+// past the end of the audio slices to the end.
+func (p *PCM) Slice(start, end time.Duration) *PCM {
+	from := p.frame(start)
+	to := p.Frames()
+	if end > 0 {
+		to = p.frame(end)
+	}
+	if to < from {
+		to = from
+	}
+	return &PCM{
+		SampleRate: p.SampleRate,
+		Channels:   p.Channels,
+		Samples:    append([]float32(nil), p.Samples[from*p.Channels:to*p.Channels]...),
+	}
+}
+
+// Convert returns the audio with the given sample rate and channel count
+func (p *PCM) Convert(sampleRate, channels int) (*PCM, error) {
+	out, err := p.ConvertChannels(channels)
+	if err != nil {
+		return nil, err
+	}
+	return out.Resample(sampleRate)
+}
+
+// ConvertChannels returns the audio with the given number of channels. Mono
+// is copied to every channel, and extra channels are averaged into the
+// remaining ones, so stereo becomes mono by averaging left and right.
+func (p *PCM) ConvertChannels(channels int) (*PCM, error) {
+	if channels <= 0 {
+		return nil, fmt.Errorf("invalid channel count %d", channels)
+	}
+	if channels == p.Channels {
+		return p.Clone(), nil
+	}
+
+	frames := p.Frames()
+	out := &PCM{
+		SampleRate: p.SampleRate,
+		Channels:   channels,
+		Samples:    make([]float32, frames*channels),
+	}
+
+	if p.Channels < channels {
+		for i := 0; i < frames; i++ {
+			for c := 0; c < channels; c++ {
+				out.Samples[i*channels+c] = p.Samples[i*p.Channels+c%p.Channels]
+			}
+		}
+		return out, nil
+	}
+
+	// Source channel k is folded into output channel k % channels
+	counts := make([]float32, channels)
+	for k := 0; k < p.Channels; k++ {
+		counts[k%channels]++
+	}
+	for i := 0; i < frames; i++ {
+		for k := 0; k < p.Channels; k++ {
+			out.Samples[i*channels+k%channels] += p.Samples[i*p.Channels+k]
+		}
+		for c := 0; c < channels; c++ {
+			out.Samples[i*channels+c] /= counts[c]
+		}
+	}
+	return out, nil
+}
+
+// Resample returns the audio at sampleRate, interpolated with a Hann-windowed
+// sinc filter that also removes frequencies above the new Nyquist limit
+func (p *PCM) Resample(sampleRate int) (*PCM, error) {
+	if sampleRate <= 0 {
+		return nil, fmt.Errorf("invalid sample rate %d", sampleRate)
+	}
+	if sampleRate == p.SampleRate || p.Frames() == 0 {
+		out := p.Clone()
+		out.SampleRate = sampleRate
+		return out, nil
+	}
+
+	ratio := float64(sampleRate) / float64(p.SampleRate)
+	cutoff := math.Min(1, ratio)
+	halfWidth := resampleZeros / cutoff
+	inFrames := p.Frames()
+	outFrames := int(math.Round(float64(inFrames) * ratio))
+	channels := p.Channels
+
+	out := &PCM{
+		SampleRate: sampleRate,
+		Channels:   channels,
+		Samples:    make([]float32, outFrames*channels),
+	}
+	acc := make([]float64, channels)
+	for i := 0; i < outFrames; i++ {
+		center := float64(i) / ratio
+		lo := int(math.Ceil(center - halfWidth))
+		hi := int(math.Floor(center + halfWidth))
+		if lo < 0 {
+			lo = 0
+		}
+		if hi >= inFrames {
+			hi = inFrames - 1
+		}
+
+		for c := range acc {
+			acc[c] = 0
+		}
+		var weights float64
+		for j := lo; j <= hi; j++ {
+			x := float64(j) - center
+			w := cutoff * sinc(cutoff*x) * 0.5 * (1 + math.Cos(math.Pi*x/halfWidth))
+			weights += w
+			for c := 0; c < channels; c++ {
+				acc[c] += w * float64(p.Samples[j*channels+c])
+			}
+		}
+		// Normalizing keeps the gain at 1 near the edges where the kernel is
+		// cut off
+		if weights != 0 {
+			for c := 0; c < channels; c++ {
+				out.Samples[i*channels+c] = float32(acc[c] / weights)
+			}
+		}
+	}
+	return out, nil
+}
+
+// sinc returns the normalized sinc function sin(πx)/(πx)
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+// Silence returns d of silence in the given format
+func Silence(sampleRate, channels int, d time.Duration) *PCM {
+	frames := int(math.Round(d.Seconds() * float64(sampleRate)))
+	if frames < 0 {
+		frames = 0
+	}
+	return &PCM{
+		SampleRate: sampleRate,
+		Channels:   channels,
+		Samples:    make([]float32, frames*channels),
+	}
+}
+
+// Concat joins audio clips into one. Clips that differ from the first in
+// sample rate or channel count are converted to its format.
+func Concat(parts ...*PCM) (*PCM, error) {
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("no audio to concatenate")
+	}
+
+	first := parts[0]
+	converted := make([]*PCM, len(parts))
+	total := 0
+	for i, p := range parts {
+		if p.SampleRate != first.SampleRate || p.Channels != first.Channels {
+			var err error
+			if p, err = p.Convert(first.SampleRate, first.Channels); err != nil {
+				return nil, err
+			}
+		}
+		converted[i] = p
+		total += len(p.Samples)
+	}
+
+	out := &PCM{
+		SampleRate: first.SampleRate,
+		Channels:   first.Channels,
+		Samples:    make([]float32, 0, total),
+	}
+	for _, p := range converted {
+		out.Samples = append(out.Samples, p.Samples...)
+	}
+	return out, nil
+}
+
+// DecodeAudio decodes a WAV or MP3 file, detecting the format from its
+// contents. Readers that cannot seek, which must be read again after the
+// format is detected, are buffered in memory; pass an *os.File or other
+// io.ReadSeeker to decode large files without copying them.
+func DecodeAudio(r io.Reader) (*PCM, error) {
+	rs, ok := r.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audio: %w", err)
+		}
+		rs = bytes.NewReader(data)
+	}
+
+	start, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audio: %w", err)
+	}
+	info, err := ProbeAudio(rs)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := rs.Seek(start, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	switch info.Format {
+	case AudioFormatWAV:
+		return DecodeWAV(rs)
+	case AudioFormatMP3:
+		return decodeMP3(rs, info.Channels)
+	}
+	return nil, fmt.Errorf("%w: %s files cannot be decoded", ErrUnsupportedAudio, info.Format)
+}
+
+// ReadAudioFromFile decodes a WAV or MP3 file
+func ReadAudioFromFile(audioPath string) (*PCM, error) {
+	file, err := os.Open(audioPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audio file: %w", err)
+	}
+	defer file.Close()
+
+	pcm, err := DecodeAudio(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode audio: %w", err)
+	}
+
+	return pcm, nil
+}
+
+// SaveAudioToFile saves audio to a file; only WAV output is supported
+func SaveAudioToFile(pcm *PCM, outputPath string) error {
+	ext := strings.ToLower(filepath.Ext(outputPath))
+	if ext != ".wav" {
+		return fmt.Errorf("unsupported audio format: %s", ext)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer file.Close()
+
+	if err := EncodeWAV(file, pcm); err != nil {
+		return fmt.Errorf("failed to encode audio: %w", err)
+	}
+
+	return file.Close()
+}
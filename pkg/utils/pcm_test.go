@@ -0,0 +1,258 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDecodeMP3(t *testing.T) {
+	prefix := []byte("not part of the file")
+	tests := []struct {
+		name         string
+		data         []byte
+		skip         int
+		wantRate     int
+		wantChannels int
+		wantFrames   int
+	}{
+		{name: "mpeg-1 stereo", data: mp3Stream(mp3Stereo, 10, "", 0, 0, 0), wantRate: 44100, wantChannels: 2, wantFrames: 10 * 1152},
+		{name: "mpeg-2 mono", data: mp3Stream(mp3Mono22k, 10, "", 0, 0, 0), wantRate: 22050, wantChannels: 1, wantFrames: 10 * 576},
+		{
+			name:         "reader past the start",
+			data:         append(append([]byte(nil), prefix...), mp3Stream(mp3Stereo, 4, "", 0, 0, 0)...),
+			skip:         len(prefix),
+			wantRate:     44100,
+			wantChannels: 2,
+			wantFrames:   4 * 1152,
+		},
+	}
+	decoders := []struct {
+		name   string
+		decode func(io.Reader) (*PCM, error)
+	}{
+		{name: "DecodeMP3", decode: DecodeMP3},
+		{name: "DecodeAudio", decode: DecodeAudio},
+	}
+	for _, tt := range tests {
+		for _, d := range decoders {
+			for _, seekable := range []bool{true, false} {
+				r := bytes.NewReader(tt.data)
+				r.Seek(int64(tt.skip), io.SeekStart)
+				var in io.Reader = r
+				if !seekable {
+					in = struct{ io.Reader }{r}
+				}
+
+				pcm, err := d.decode(in)
+				if err != nil {
+					t.Fatalf("%s: %s(seekable %v): %v", tt.name, d.name, seekable, err)
+				}
+				if pcm.SampleRate != tt.wantRate || pcm.Channels != tt.wantChannels || pcm.Frames() != tt.wantFrames {
+					t.Errorf("%s: %s(seekable %v) = %d Hz, %d channels, %d frames, want %d Hz, %d channels, %d frames",
+						tt.name, d.name, seekable, pcm.SampleRate, pcm.Channels, pcm.Frames(), tt.wantRate, tt.wantChannels, tt.wantFrames)
+				}
+				// The frames hold no audio data, so they decode to silence
+				if peak := pcm.Peak(); !math.IsInf(peak, -1) {
+					t.Errorf("%s: %s decoded a peak of %v dBFS, want silence", tt.name, d.name, peak)
+				}
+			}
+		}
+	}
+}
+
+func TestDecodeAudioUnsupported(t *testing.T) {
+	if _, err := DecodeAudio(bytes.NewReader(flacFile(44100, 2, 44100))); err == nil {
+		t.Error("DecodeAudio() of FLAC succeeded, want an error")
+	}
+	if _, err := DecodeMP3(bytes.NewReader([]byte("not audio"))); err == nil {
+		t.Error("DecodeMP3() of text succeeded, want an error")
+	}
+}
+
+// toneLevel returns the RMS level of p relative to a full-scale sine,
+// skipping the edges where resampling filters are cut off
+func toneLevel(p *PCM) float64 {
+	edge := p.Frames() / 10
+	var sum float64
+	n := 0
+	for _, s := range p.Samples[edge*p.Channels : len(p.Samples)-edge*p.Channels] {
+		sum += float64(s) * float64(s)
+		n++
+	}
+	return math.Sqrt(sum/float64(n)) * math.Sqrt2
+}
+
+func TestResample(t *testing.T) {
+	tests := []struct {
+		name      string
+		freq      float64
+		from, to  int
+		wantLevel float64
+	}{
+		{name: "upsample", freq: 1000, from: 16000, to: 44100, wantLevel: 0.5},
+		{name: "downsample", freq: 1000, from: 44100, to: 16000, wantLevel: 0.5},
+		// A tone above the new Nyquist limit is filtered out rather than
+		// aliased
+		{name: "anti-aliasing", freq: 10000, from: 44100, to: 16000, wantLevel: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := sine(tt.from, 2, tt.freq, 20*math.Log10(0.5), 500*time.Millisecond)
+			out, err := in.Resample(tt.to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.SampleRate != tt.to || out.Channels != 2 {
+				t.Fatalf("Resample() = %d Hz, %d channels", out.SampleRate, out.Channels)
+			}
+			if diff := out.Duration() - in.Duration(); diff < -time.Millisecond || diff > time.Millisecond {
+				t.Errorf("duration = %v, want %v", out.Duration(), in.Duration())
+			}
+			if level := toneLevel(out); math.Abs(level-tt.wantLevel) > 0.02 {
+				t.Errorf("tone level = %.3f, want %.3f", level, tt.wantLevel)
+			}
+			if tt.wantLevel > 0 {
+				// The resampled tone matches one generated at the new rate
+				want := sine(tt.to, 2, tt.freq, 20*math.Log10(0.5), 500*time.Millisecond)
+				for i := len(out.Samples) / 4; i < len(out.Samples)*3/4; i++ {
+					if math.Abs(float64(out.Samples[i]-want.Samples[i])) > 0.01 {
+						t.Fatalf("sample %d = %v, want %v", i, out.Samples[i], want.Samples[i])
+					}
+				}
+			}
+		})
+	}
+
+	if _, err := sine(16000, 1, 440, -6, time.Second).Resample(0); err == nil {
+		t.Error("Resample(0) succeeded, want an error")
+	}
+}
+
+func TestConvertChannels(t *testing.T) {
+	tests := []struct {
+		name     string
+		in       *PCM
+		channels int
+		want     []float32
+	}{
+		{
+			name:     "mono to stereo",
+			in:       &PCM{SampleRate: 8000, Channels: 1, Samples: []float32{0.5, -0.25}},
+			channels: 2,
+			want:     []float32{0.5, 0.5, -0.25, -0.25},
+		},
+		{
+			name:     "stereo to mono",
+			in:       &PCM{SampleRate: 8000, Channels: 2, Samples: []float32{0.5, 0.25, -1, 1}},
+			channels: 1,
+			want:     []float32{0.375, 0},
+		},
+		{
+			name:     "three to two",
+			in:       &PCM{SampleRate: 8000, Channels: 3, Samples: []float32{0.2, 0.4, 0.6}},
+			channels: 2,
+			want:     []float32{0.4, 0.4},
+		},
+		{
+			name:     "unchanged",
+			in:       &PCM{SampleRate: 8000, Channels: 2, Samples: []float32{0.1, 0.2}},
+			channels: 2,
+			want:     []float32{0.1, 0.2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := append([]float32(nil), tt.in.Samples...)
+			out, err := tt.in.ConvertChannels(tt.channels)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.Channels != tt.channels || out.SampleRate != tt.in.SampleRate || len(out.Samples) != len(tt.want) {
+				t.Fatalf("ConvertChannels() = %+v, want %d channels of %v", out, tt.channels, tt.want)
+			}
+			for i := range tt.want {
+				if math.Abs(float64(out.Samples[i]-tt.want[i])) > 1e-6 {
+					t.Errorf("sample %d = %v, want %v", i, out.Samples[i], tt.want[i])
+				}
+			}
+			if out.Samples[0] = 9; tt.in.Samples[0] != before[0] {
+				t.Error("ConvertChannels() shares samples with its input")
+			}
+		})
+	}
+
+	if _, err := (&PCM{SampleRate: 8000, Channels: 1}).ConvertChannels(0); err == nil {
+		t.Error("ConvertChannels(0) succeeded, want an error")
+	}
+}
+
+func TestSlice(t *testing.T) {
+	// Ten frames at 10 Hz, so each frame lasts 100 ms
+	in := &PCM{SampleRate: 10, Channels: 2, Samples: make([]float32, 20)}
+	for i := range in.Samples {
+		in.Samples[i] = float32(i / 2)
+	}
+
+	tests := []struct {
+		name       string
+		start, end time.Duration
+		wantFirst  float32
+		wantFrames int
+	}{
+		{name: "middle", start: 200 * time.Millisecond, end: 500 * time.Millisecond, wantFirst: 2, wantFrames: 3},
+		{name: "to the end", start: 700 * time.Millisecond, wantFirst: 7, wantFrames: 3},
+		{name: "past the end", start: 800 * time.Millisecond, end: time.Hour, wantFirst: 8, wantFrames: 2},
+		{name: "before the start", start: -time.Second, end: 100 * time.Millisecond, wantFirst: 0, wantFrames: 1},
+		{name: "reversed", start: 500 * time.Millisecond, end: 200 * time.Millisecond, wantFrames: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := in.Slice(tt.start, tt.end)
+			if out.Frames() != tt.wantFrames || out.Channels != 2 || out.SampleRate != 10 {
+				t.Fatalf("Slice() = %d frames, %d channels at %d Hz, want %d frames", out.Frames(), out.Channels, out.SampleRate, tt.wantFrames)
+			}
+			if tt.wantFrames > 0 && (out.Samples[0] != tt.wantFirst || out.Samples[1] != tt.wantFirst) {
+				t.Errorf("Slice() starts at frame %v, want %v", out.Samples[0], tt.wantFirst)
+			}
+		})
+	}
+
+	out := in.Slice(0, 0)
+	if out.Samples[0] = -1; in.Samples[0] != 0 {
+		t.Error("Slice() shares samples with its input")
+	}
+}
+
+func TestConcat(t *testing.T) {
+	first := sine(16000, 1, 440, -6, 250*time.Millisecond)
+	second := sine(8000, 2, 440, -6, 500*time.Millisecond)
+	out, err := Concat(first, Silence(16000, 1, 100*time.Millisecond), second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out.SampleRate != 16000 || out.Channels != 1 {
+		t.Errorf("Concat() = %d Hz, %d channels, want the format of the first clip", out.SampleRate, out.Channels)
+	}
+	if want := 850 * time.Millisecond; out.Duration() != want {
+		t.Errorf("duration = %v, want %v", out.Duration(), want)
+	}
+	for i, s := range first.Samples {
+		if out.Samples[i] != s {
+			t.Fatalf("sample %d = %v, want the first clip unchanged", i, out.Samples[i])
+		}
+	}
+	if peak := out.Slice(250*time.Millisecond, 350*time.Millisecond).Peak(); !math.IsInf(peak, -1) {
+		t.Errorf("the silence peaks at %v dBFS", peak)
+	}
+	if peak := out.Slice(400*time.Millisecond, 0).Peak(); math.Abs(peak+6) > 0.5 {
+		t.Errorf("the converted clip peaks at %v dBFS, want -6", peak)
+	}
+
+	if _, err := Concat(); err == nil {
+		t.Error("Concat() of nothing succeeded, want an error")
+	}
+}
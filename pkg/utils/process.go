@@ -0,0 +1,216 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// silenceWindow is the length of the windows whose level silence detection
+// compares to the threshold
+const silenceWindow = 10 * time.Millisecond
+
+// DefaultSilenceThreshold is the level in dBFS below which audio counts as
+// silence when no threshold is given
+const DefaultSilenceThreshold = -50
+
+// AudioProcessing lists post-processing steps for generated audio. They are
+// applied in field order: trimming, loudness normalization, limiting and
+// fades.
+type AudioProcessing struct {
+	// TrimSilence removes leading and trailing audio quieter than
+	// SilenceThreshold
+	TrimSilence bool
+	// SilenceThreshold is the level in dBFS below which audio counts as
+	// silence; 0 means DefaultSilenceThreshold
+	SilenceThreshold float64
+	// SilencePadding is the silence kept before and after the trimmed audio
+	SilencePadding time.Duration
+	// TargetLoudness is the integrated loudness to normalize to, in LUFS;
+	// 0 leaves the loudness unchanged. Silent audio, which has no loudness,
+	// is left as it is.
+	TargetLoudness float64
+	// PeakCeiling is the level in dBFS that the limiter holds peaks below;
+	// nil disables the limiter
+	PeakCeiling *float64
+	// FadeIn and FadeOut are the lengths of the fades at the start and end
+	FadeIn  time.Duration
+	FadeOut time.Duration
+}
+
+// DefaultAudioProcessing returns processing suitable for speech: trimmed,
+// normalized to -16 LUFS with peaks below -1 dBFS, with short fades to avoid
+// clicks
+func DefaultAudioProcessing() *AudioProcessing {
+	ceiling := -1.0
+	return &AudioProcessing{
+		TrimSilence:      true,
+		SilenceThreshold: DefaultSilenceThreshold,
+		SilencePadding:   50 * time.Millisecond,
+		TargetLoudness:   -16,
+		PeakCeiling:      &ceiling,
+		FadeIn:           10 * time.Millisecond,
+		FadeOut:          10 * time.Millisecond,
+	}
+}
+
+// Process returns the audio with the processing steps applied. Audio that
+// is silent, or empty once trimmed, skips loudness normalization rather than
+// failing, so a quiet clip does not abort a longer job.
+func (p *PCM) Process(opts *AudioProcessing) (*PCM, error) {
+	out := p
+	if opts.TrimSilence {
+		out = out.TrimSilence(opts.SilenceThreshold, opts.SilencePadding)
+	}
+	if opts.TargetLoudness != 0 {
+		normalized, err := out.NormalizeLoudness(opts.TargetLoudness)
+		switch {
+		case err == nil:
+			out = normalized
+		case !errors.Is(err, ErrSilentAudio):
+			return nil, fmt.Errorf("failed to normalize loudness: %w", err)
+		}
+	}
+	if opts.PeakCeiling != nil {
+		out = out.Limit(*opts.PeakCeiling)
+	}
+	if opts.FadeIn > 0 {
+		out = out.FadeIn(opts.FadeIn)
+	}
+	if opts.FadeOut > 0 {
+		out = out.FadeOut(opts.FadeOut)
+	}
+	if out == p {
+		out = p.Clone()
+	}
+	return out, nil
+}
+
+// windowLevels returns the peak level of each silence window as an amplitude
+func (p *PCM) windowLevels() ([]float64, int) {
+	size := int(silenceWindow.Seconds() * float64(p.SampleRate))
+	if size < 1 {
+		size = 1
+	}
+
+	frames := p.Frames()
+	levels := make([]float64, (frames+size-1)/size)
+	for i := 0; i < frames; i++ {
+		for c := 0; c < p.Channels; c++ {
+			if level := math.Abs(float64(p.Samples[i*p.Channels+c])); level > levels[i/size] {
+				levels[i/size] = level
+			}
+		}
+	}
+	return levels, size
+}
+
+// silenceLimit returns the amplitude of a silence threshold in dBFS, where 0
+// means DefaultSilenceThreshold
+func silenceLimit(threshold float64) float64 {
+	if threshold == 0 {
+		threshold = DefaultSilenceThreshold
+	}
+	return amplitude(threshold)
+}
+
+// DetectSilence returns the stretches of at least minDuration where the
+// audio stays below threshold dBFS. A threshold of 0 means
+// DefaultSilenceThreshold.
+func (p *PCM) DetectSilence(threshold float64, minDuration time.Duration) []Interval {
+	levels, size := p.windowLevels()
+	limit := silenceLimit(threshold)
+	frames := p.Frames()
+
+	var silences []Interval
+	start := -1
+	for i := 0; i <= len(levels); i++ {
+		if i < len(levels) && levels[i] < limit {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			end := i * size
+			if end > frames {
+				end = frames
+			}
+			interval := Interval{Start: p.frameTime(start * size), End: p.frameTime(end)}
+			if interval.End-interval.Start >= minDuration {
+				silences = append(silences, interval)
+			}
+			start = -1
+		}
+	}
+	return silences
+}
+
+// TrimSilence returns the audio without the leading and trailing silence
+// below threshold dBFS, keeping padding on each side. A threshold of 0 means
+// DefaultSilenceThreshold. Audio that is silent throughout is trimmed to
+// nothing.
+func (p *PCM) TrimSilence(threshold float64, padding time.Duration) *PCM {
+	levels, size := p.windowLevels()
+	limit := silenceLimit(threshold)
+
+	first, last := -1, -1
+	for i, level := range levels {
+		if level >= limit {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return &PCM{SampleRate: p.SampleRate, Channels: p.Channels}
+	}
+
+	start := p.frameTime(first*size) - padding
+	end := p.frameTime((last+1)*size) + padding
+	if end >= p.Duration() {
+		end = 0
+	}
+	return p.Slice(start, end)
+}
+
+// frameTime returns the time of a frame
+func (p *PCM) frameTime(frame int) time.Duration {
+	return time.Duration(float64(frame) / float64(p.SampleRate) * float64(time.Second))
+}
+
+// FadeIn returns the audio fading in from silence over d
+func (p *PCM) FadeIn(d time.Duration) *PCM {
+	out := p.Clone()
+	n := p.frame(d)
+	for i := 0; i < n; i++ {
+		gain := fadeCurve(float64(i) / float64(n))
+		for c := 0; c < p.Channels; c++ {
+			out.Samples[i*p.Channels+c] *= gain
+		}
+	}
+	return out
+}
+
+// FadeOut returns the audio fading out to silence over its last d
+func (p *PCM) FadeOut(d time.Duration) *PCM {
+	out := p.Clone()
+	frames := p.Frames()
+	n := p.frame(d)
+	for i := 0; i < n; i++ {
+		gain := fadeCurve(float64(i) / float64(n))
+		frame := frames - 1 - i
+		for c := 0; c < p.Channels; c++ {
+			out.Samples[frame*p.Channels+c] *= gain
+		}
+	}
+	return out
+}
+
+// fadeCurve returns the gain at position x of a fade from 0 to 1, following
+// a raised cosine that starts and ends smoothly
+func fadeCurve(x float64) float32 {
+	return float32(0.5 - 0.5*math.Cos(math.Pi*x))
+}
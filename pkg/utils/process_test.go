@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// paddedTone returns a second of tone between half seconds of silence
+func paddedTone(t *testing.T) *PCM {
+	t.Helper()
+	silence := Silence(16000, 1, 500*time.Millisecond)
+	p, err := Concat(silence, sine(16000, 1, 440, -20, time.Second), silence)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestTrimSilence(t *testing.T) {
+	tests := []struct {
+		name      string
+		threshold float64
+		padding   time.Duration
+		want      time.Duration
+	}{
+		{name: "default threshold", threshold: 0, want: time.Second},
+		{name: "threshold", threshold: -40, want: time.Second},
+		{name: "padding", threshold: -40, padding: 100 * time.Millisecond, want: 1200 * time.Millisecond},
+		{name: "above the tone", threshold: -10, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := paddedTone(t).TrimSilence(tt.threshold, tt.padding).Duration()
+			if diff := got - tt.want; diff < -silenceWindow || diff > silenceWindow {
+				t.Errorf("TrimSilence(%v, %v) duration = %v, want %v", tt.threshold, tt.padding, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessDefaultSilenceThreshold(t *testing.T) {
+	out, err := paddedTone(t).Process(&AudioProcessing{TrimSilence: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.Duration(); got < time.Second-silenceWindow || got > time.Second+silenceWindow {
+		t.Errorf("Process duration = %v, want %v", got, time.Second)
+	}
+}
+
+func TestDetectSilence(t *testing.T) {
+	silences := paddedTone(t).DetectSilence(0, 100*time.Millisecond)
+	if len(silences) != 2 {
+		t.Fatalf("DetectSilence found %d silences, want 2: %v", len(silences), silences)
+	}
+	if silences[0].Start != 0 || silences[1].End != 2*time.Second {
+		t.Errorf("DetectSilence = %v, want silence at both ends", silences)
+	}
+}
+
+func TestProcessSilentAudio(t *testing.T) {
+	tests := []struct {
+		name string
+		in   *PCM
+	}{
+		{name: "silence", in: Silence(16000, 1, time.Second)},
+		{name: "empty", in: &PCM{SampleRate: 16000, Channels: 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			untrimmed := DefaultAudioProcessing()
+			untrimmed.TrimSilence = false
+			for _, opts := range []*AudioProcessing{DefaultAudioProcessing(), untrimmed} {
+				out, err := tt.in.Process(opts)
+				if err != nil {
+					t.Fatalf("Process(trim %v) = %v, want the audio left silent", opts.TrimSilence, err)
+				}
+				if peak := out.Peak(); !math.IsInf(peak, -1) {
+					t.Errorf("Process(trim %v) peaks at %v dBFS, want silence", opts.TrimSilence, peak)
+				}
+				if !opts.TrimSilence && out.Duration() != tt.in.Duration() {
+					t.Errorf("Process() duration = %v, want %v", out.Duration(), tt.in.Duration())
+				}
+			}
+		})
+	}
+}
+
+func TestProcessPeakCeiling(t *testing.T) {
+	// A tone normalized to -1 LUFS peaks above full scale
+	in := sine(48000, 1, 1000, -3, time.Second)
+	zero, low := 0.0, -6.0
+
+	tests := []struct {
+		name     string
+		ceiling  *float64
+		wantPeak float64
+	}{
+		{name: "disabled", wantPeak: 1},
+		{name: "full scale", ceiling: &zero, wantPeak: 0},
+		{name: "below full scale", ceiling: &low, wantPeak: -6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := in.Process(&AudioProcessing{TargetLoudness: -1, PeakCeiling: tt.ceiling})
+			if err != nil {
+				t.Fatal(err)
+			}
+			peak := out.Peak()
+			if tt.ceiling == nil {
+				if peak < tt.wantPeak {
+					t.Errorf("peak = %.2f dBFS, want the unlimited peak above %v", peak, tt.wantPeak)
+				}
+				return
+			}
+			if peak > tt.wantPeak+0.01 || peak < tt.wantPeak-1 {
+				t.Errorf("peak = %.2f dBFS, want it held just below %v", peak, tt.wantPeak)
+			}
+		})
+	}
+}
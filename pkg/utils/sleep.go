@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"context"
+	"time"
+)
+
+// SleepContext waits for d or until ctx is done, returning ctx's error in the
+// latter case
+func SleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,144 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Time stretching parameters
+const (
+	// stretchFrame is the length of the frames that are overlapped
+	stretchFrame = 40 * time.Millisecond
+	// stretchTolerance is how far from its nominal position a frame may be
+	// taken to line up with the previous one
+	stretchTolerance = 10 * time.Millisecond
+	// stretchSearchRate is the sample rate the coarse alignment search runs
+	// at
+	stretchSearchRate = 8000
+)
+
+// TimeStretch returns the audio played rate times as fast without changing
+// its pitch; a rate of 1.25 makes it 20% shorter. It uses waveform similarity
+// overlap-add (WSOLA), which suits speech well at rates between about 0.5
+// and 2.
+func (p *PCM) TimeStretch(rate float64) (*PCM, error) {
+	if rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return nil, fmt.Errorf("invalid stretch rate %v", rate)
+	}
+	frames := p.Frames()
+	if rate == 1 || frames == 0 {
+		return p.Clone(), nil
+	}
+
+	channels := p.Channels
+	n := int(stretchFrame.Seconds()*float64(p.SampleRate)) &^ 1
+	if n < 4 {
+		n = 4
+	}
+	hop := n / 2
+	tolerance := int(stretchTolerance.Seconds() * float64(p.SampleRate))
+	step := p.SampleRate / stretchSearchRate
+	if step < 1 {
+		step = 1
+	}
+
+	mono := make([]float64, frames)
+	for i := range mono {
+		for c := 0; c < channels; c++ {
+			mono[i] += float64(p.Samples[i*channels+c])
+		}
+	}
+
+	window := make([]float64, n)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+	}
+
+	outFrames := int(math.Round(float64(frames) / rate))
+	acc := make([]float64, (outFrames+n)*channels)
+	weights := make([]float64, outFrames+n)
+
+	prev := 0
+	for k := 0; k*hop < outFrames; k++ {
+		pos := 0
+		if k > 0 {
+			nominal := int(math.Round(float64(k*hop) * rate))
+			pos = alignFrame(mono, prev+hop, nominal, tolerance, n, step)
+		}
+
+		start := k * hop
+		for i := 0; i < n && pos+i < frames; i++ {
+			w := window[i]
+			for c := 0; c < channels; c++ {
+				acc[(start+i)*channels+c] += w * float64(p.Samples[(pos+i)*channels+c])
+			}
+			weights[start+i] += w
+		}
+		prev = pos
+	}
+
+	out := &PCM{
+		SampleRate: p.SampleRate,
+		Channels:   channels,
+		Samples:    make([]float32, outFrames*channels),
+	}
+	for i := 0; i < outFrames; i++ {
+		if weights[i] < 1e-6 {
+			continue
+		}
+		for c := 0; c < channels; c++ {
+			out.Samples[i*channels+c] = float32(acc[i*channels+c] / weights[i])
+		}
+	}
+	return out, nil
+}
+
+// alignFrame returns the position within tolerance of nominal whose frame
+// best matches the frame at target, the natural continuation of the
+// previous frame. A coarse search every step samples is refined around the
+// best match.
+func alignFrame(mono []float64, target, nominal, tolerance, n, step int) int {
+	last := len(mono) - n
+	if last < 0 {
+		last = 0
+	}
+	clamp := func(pos int) int {
+		if pos < 0 {
+			return 0
+		}
+		if pos > last {
+			return last
+		}
+		return pos
+	}
+	target = clamp(target)
+
+	best, bestScore := clamp(nominal), math.Inf(-1)
+	search := func(from, to, stride, sampleStride int) {
+		for pos := from; pos <= to; pos += stride {
+			candidate := clamp(pos)
+			var dot, energy float64
+			for i := 0; i < n && candidate+i < len(mono) && target+i < len(mono); i += sampleStride {
+				x := mono[candidate+i]
+				dot += x * mono[target+i]
+				energy += x * x
+			}
+			score := dot
+			if energy > 0 {
+				score = dot / math.Sqrt(energy)
+			}
+			if score > bestScore {
+				best, bestScore = candidate, score
+			}
+		}
+	}
+
+	search(nominal-tolerance, nominal+tolerance, step, step)
+	if step > 1 {
+		center := best
+		bestScore = math.Inf(-1)
+		search(center-step, center+step, 1, 1)
+	}
+	return best
+}
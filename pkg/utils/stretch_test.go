@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// pitch estimates the frequency of a tone in the first channel of p from its
+// rising zero crossings, skipping the edges
+func pitch(p *PCM) float64 {
+	edge := p.Frames() / 10
+	first, last, crossings := -1, 0, 0
+	for i := edge + 1; i < p.Frames()-edge; i++ {
+		if p.Samples[(i-1)*p.Channels] < 0 && p.Samples[i*p.Channels] >= 0 {
+			if first < 0 {
+				first = i
+			} else {
+				crossings++
+			}
+			last = i
+		}
+	}
+	if crossings == 0 {
+		return 0
+	}
+	return float64(crossings) * float64(p.SampleRate) / float64(last-first)
+}
+
+func TestTimeStretch(t *testing.T) {
+	tests := []struct {
+		name       string
+		sampleRate int
+		channels   int
+		freq       float64
+		rate       float64
+	}{
+		{name: "faster", sampleRate: 16000, channels: 1, freq: 220, rate: 1.25},
+		{name: "twice as fast", sampleRate: 44100, channels: 2, freq: 440, rate: 2},
+		{name: "slower", sampleRate: 24000, channels: 1, freq: 300, rate: 0.8},
+		{name: "half speed", sampleRate: 16000, channels: 2, freq: 180, rate: 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := sine(tt.sampleRate, tt.channels, tt.freq, 20*math.Log10(0.5), time.Second)
+			out, err := in.TimeStretch(tt.rate)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.SampleRate != tt.sampleRate || out.Channels != tt.channels {
+				t.Fatalf("TimeStretch() = %d Hz, %d channels, want %d Hz, %d channels", out.SampleRate, out.Channels, tt.sampleRate, tt.channels)
+			}
+
+			want := time.Duration(float64(time.Second) / tt.rate)
+			if diff := out.Duration() - want; diff < -time.Millisecond || diff > time.Millisecond {
+				t.Errorf("duration = %v, want %v", out.Duration(), want)
+			}
+			// The pitch and level are kept
+			if got := pitch(out); math.Abs(got-tt.freq) > tt.freq*0.02 {
+				t.Errorf("pitch = %.1f Hz, want %.1f Hz", got, tt.freq)
+			}
+			if level := toneLevel(out); math.Abs(level-0.5) > 0.05 {
+				t.Errorf("tone level = %.3f, want 0.5", level)
+			}
+		})
+	}
+}
+
+func TestTimeStretchUnchanged(t *testing.T) {
+	in := sine(16000, 1, 440, -6, 100*time.Millisecond)
+	out, err := in.TimeStretch(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Frames() != in.Frames() {
+		t.Fatalf("TimeStretch(1) = %d frames, want %d", out.Frames(), in.Frames())
+	}
+	for i := range in.Samples {
+		if out.Samples[i] != in.Samples[i] {
+			t.Fatalf("sample %d = %v, want %v", i, out.Samples[i], in.Samples[i])
+		}
+	}
+	if out.Samples[0] = 9; in.Samples[0] == 9 {
+		t.Error("TimeStretch(1) shares samples with its input")
+	}
+
+	empty, err := (&PCM{SampleRate: 16000, Channels: 1}).TimeStretch(1.5)
+	if err != nil || empty.Frames() != 0 {
+		t.Errorf("TimeStretch() of no audio = %v, %v, want no audio", empty, err)
+	}
+}
+
+func TestTimeStretchInvalidRate(t *testing.T) {
+	in := sine(16000, 1, 440, -6, 100*time.Millisecond)
+	for _, rate := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, err := in.TimeStretch(rate); err == nil {
+			t.Errorf("TimeStretch(%v) succeeded, want an error", rate)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// WAV format codes
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+// unsetWAVSize is the chunk size streamed WAV files write when the length is
+// not known in advance
+const unsetWAVSize = 0xFFFFFFFF
+
+// wavBlockSize is how much sample data is converted at a time
+const wavBlockSize = 64 * 1024
+
+// maxWAVFmtSize bounds the fmt chunk, which is 40 bytes at most for the
+// formats DecodeWAV reads, so a corrupt size cannot make it allocate
+const maxWAVFmtSize = 64
+
+// DecodeWAV decodes a WAV file holding 8, 16, 24 or 32-bit integer PCM or
+// 32 or 64-bit float samples
+func DecodeWAV(r io.Reader) (*PCM, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("failed to read WAV header: %w", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("%w: not a WAV file", ErrUnsupportedAudio)
+	}
+
+	// remaining counts the bytes left in the RIFF chunk, or is -1 when a
+	// streamed file leaves its size unset
+	remaining := int64(-1)
+	if riffSize := binary.LittleEndian.Uint32(header[4:8]); riffSize != 0 && riffSize != unsetWAVSize {
+		remaining = int64(riffSize) - 4
+	}
+
+	var format, channels, bits int
+	var sampleRate int
+	chunk := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(br, chunk); err != nil {
+			return nil, fmt.Errorf("%w: WAV file has no data chunk", ErrUnsupportedAudio)
+		}
+		id := string(chunk[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunk[4:8]))
+		if remaining >= 0 {
+			remaining = max(remaining-8, 0)
+		}
+
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("%w: truncated WAV fmt chunk", ErrUnsupportedAudio)
+			}
+			if size > maxWAVFmtSize {
+				return nil, fmt.Errorf("%w: WAV fmt chunk of %d bytes", ErrUnsupportedAudio, size)
+			}
+			fmtChunk := make([]byte, size+size%2)
+			if _, err := io.ReadFull(br, fmtChunk); err != nil {
+				return nil, fmt.Errorf("%w: truncated WAV fmt chunk", ErrUnsupportedAudio)
+			}
+			format = int(binary.LittleEndian.Uint16(fmtChunk[0:2]))
+			channels = int(binary.LittleEndian.Uint16(fmtChunk[2:4]))
+			sampleRate = int(binary.LittleEndian.Uint32(fmtChunk[4:8]))
+			bits = int(binary.LittleEndian.Uint16(fmtChunk[14:16]))
+			if format == wavFormatExtensible && size >= 26 {
+				// The sub-format GUID starts with the actual format code
+				format = int(binary.LittleEndian.Uint16(fmtChunk[24:26]))
+			}
+		case "data":
+			if channels == 0 {
+				return nil, fmt.Errorf("%w: WAV data before fmt chunk", ErrUnsupportedAudio)
+			}
+			// Streamed files may leave the data size unset; the data then
+			// runs to the end of the RIFF chunk, or of the file
+			if size == 0 || size == unsetWAVSize {
+				size = remaining
+			} else if remaining >= 0 && size > remaining {
+				size = remaining
+			}
+			samples, err := decodeWAVSamples(br, size, format, bits)
+			if err != nil {
+				return nil, err
+			}
+			return &PCM{SampleRate: sampleRate, Channels: channels, Samples: samples}, nil
+		default:
+			if _, err := br.Discard(int(size + size%2)); err != nil {
+				return nil, fmt.Errorf("%w: truncated WAV chunk %q", ErrUnsupportedAudio, id)
+			}
+		}
+		if remaining >= 0 {
+			remaining = max(remaining-size-size%2, 0)
+		}
+	}
+}
+
+// decodeWAVSamples reads the contents of a data chunk of size bytes, or up to
+// the end of r when size is negative. The data is converted a block at a
+// time, so only the decoded samples are held in memory.
+func decodeWAVSamples(r io.Reader, size int64, format, bits int) ([]float32, error) {
+	width := bits / 8
+	switch {
+	case format == wavFormatPCM && (bits == 8 || bits == 16 || bits == 24 || bits == 32):
+	case format == wavFormatFloat && (bits == 32 || bits == 64):
+	default:
+		return nil, fmt.Errorf("%w: WAV format %d with %d-bit samples", ErrUnsupportedAudio, format, bits)
+	}
+
+	var samples []float32
+	if size >= 0 {
+		r = io.LimitReader(r, size)
+		samples = make([]float32, 0, size/int64(width))
+	}
+
+	block := make([]byte, wavBlockSize-wavBlockSize%width)
+	for {
+		n, err := io.ReadFull(r, block)
+		for b := block[:n-n%width]; len(b) > 0; b = b[width:] {
+			samples = append(samples, wavSample(b, format, bits))
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return samples, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read WAV data: %w", err)
+		}
+	}
+}
+
+// wavSample decodes the sample at the start of b
+func wavSample(b []byte, format, bits int) float32 {
+	switch {
+	case format == wavFormatFloat && bits == 32:
+		return math.Float32frombits(binary.LittleEndian.Uint32(b))
+	case format == wavFormatFloat:
+		return float32(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+	case bits == 8:
+		return (float32(b[0]) - 128) / 128
+	case bits == 16:
+		return float32(int16(binary.LittleEndian.Uint16(b))) / 32768
+	case bits == 24:
+		v := int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+		return float32(v) / 8388608
+	}
+	return float32(float64(int32(binary.LittleEndian.Uint32(b))) / 2147483648)
+}
+
+// EncodeWAV writes p as a 16-bit PCM WAV file. Samples are rounded to 16
+// bits and clipped to [-1, 1], so float or 24-bit input loses resolution and
+// any headroom above full scale.
+func EncodeWAV(w io.Writer, p *PCM) error {
+	const bits = 16
+	dataSize := len(p.Samples) * bits / 8
+	blockAlign := p.Channels * bits / 8
+
+	bw := bufio.NewWriter(w)
+	header := make([]byte, 44)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(36+dataSize))
+	copy(header[8:16], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16)
+	binary.LittleEndian.PutUint16(header[20:22], wavFormatPCM)
+	binary.LittleEndian.PutUint16(header[22:24], uint16(p.Channels))
+	binary.LittleEndian.PutUint32(header[24:28], uint32(p.SampleRate))
+	binary.LittleEndian.PutUint32(header[28:32], uint32(p.SampleRate*blockAlign))
+	binary.LittleEndian.PutUint16(header[32:34], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(header[34:36], bits)
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], uint32(dataSize))
+	if _, err := bw.Write(header); err != nil {
+		return err
+	}
+
+	sample := make([]byte, 2)
+	for _, s := range p.Samples {
+		binary.LittleEndian.PutUint16(sample, uint16(toInt16(s)))
+		if _, err := bw.Write(sample); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// toInt16 converts a sample in [-1, 1] to 16 bits, clipping it
+func toInt16(s float32) int16 {
+	v := math.Round(float64(s) * 32767)
+	if v > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if v < math.MinInt16 {
+		return math.MinInt16
+	}
+	return int16(v)
+}
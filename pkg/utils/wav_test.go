@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelslab/modelslab-go/pkg/internal/testutil"
+)
+
+func TestWAVRoundTrip(t *testing.T) {
+	for _, channels := range []int{1, 2} {
+		in := sine(22050, channels, 440, -6, 100*time.Millisecond)
+		in.Samples[0], in.Samples[1] = 1, -1
+
+		var buf bytes.Buffer
+		if err := EncodeWAV(&buf, in); err != nil {
+			t.Fatal(err)
+		}
+		out, err := DecodeWAV(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if out.SampleRate != in.SampleRate || out.Channels != in.Channels || len(out.Samples) != len(in.Samples) {
+			t.Fatalf("%d channels: decoded %d Hz, %d channels, %d samples, want %d Hz, %d channels, %d samples",
+				channels, out.SampleRate, out.Channels, len(out.Samples), in.SampleRate, in.Channels, len(in.Samples))
+		}
+		for i := range in.Samples {
+			if diff := math.Abs(float64(out.Samples[i] - in.Samples[i])); diff > 1.0/32767 {
+				t.Fatalf("%d channels: sample %d = %v, want %v", channels, i, out.Samples[i], in.Samples[i])
+			}
+		}
+	}
+}
+
+func TestDecodeWAVFormats(t *testing.T) {
+	float32Data := make([]byte, 8)
+	binary.LittleEndian.PutUint32(float32Data[0:], math.Float32bits(0.5))
+	binary.LittleEndian.PutUint32(float32Data[4:], math.Float32bits(-0.25))
+
+	extensible := testutil.WAVFmt(wavFormatExtensible, 1, 8000, 32, 24)
+	binary.LittleEndian.PutUint16(extensible[16:18], 22)
+	binary.LittleEndian.PutUint16(extensible[24:26], wavFormatFloat)
+
+	tests := []struct {
+		name string
+		file []byte
+		want []float32
+	}{
+		{name: "8-bit", file: testutil.WAVFile(testutil.WAVFmt(wavFormatPCM, 1, 8000, 8, 0), []byte{192, 64}), want: []float32{0.5, -0.5}},
+		{name: "24-bit", file: testutil.WAVFile(testutil.WAVFmt(wavFormatPCM, 1, 8000, 24, 0), []byte{0, 0, 0x40, 0, 0, 0xC0}), want: []float32{0.5, -0.5}},
+		{name: "32-bit float", file: testutil.WAVFile(testutil.WAVFmt(wavFormatFloat, 1, 8000, 32, 0), float32Data), want: []float32{0.5, -0.25}},
+		{name: "extensible", file: testutil.WAVFile(extensible, float32Data), want: []float32{0.5, -0.25}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := DecodeWAV(bytes.NewReader(tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(out.Samples) != len(tt.want) {
+				t.Fatalf("decoded %v, want %v", out.Samples, tt.want)
+			}
+			for i := range tt.want {
+				if math.Abs(float64(out.Samples[i]-tt.want[i])) > 1e-6 {
+					t.Fatalf("decoded %v, want %v", out.Samples, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeWAVRejectsCorruptFiles(t *testing.T) {
+	huge := testutil.WAVFile(testutil.WAVFmt(wavFormatPCM, 1, 8000, 16, 0), []byte{0, 0})
+	binary.LittleEndian.PutUint32(huge[16:20], math.MaxUint32)
+
+	tests := []struct {
+		name    string
+		file    []byte
+		wantErr string
+	}{
+		{name: "oversized fmt chunk", file: huge, wantErr: "fmt chunk of 4294967295 bytes"},
+		{name: "not a WAV file", file: []byte("RIFF\x00\x00\x00\x00AVI LIST"), wantErr: "not a WAV file"},
+		{name: "unsupported bits", file: testutil.WAVFile(testutil.WAVFmt(wavFormatPCM, 1, 8000, 12, 0), []byte{0, 0}), wantErr: "12-bit"},
+		{name: "no data chunk", file: testutil.WAVFile(testutil.WAVFmt(wavFormatPCM, 1, 8000, 16, 0), nil)[:36], wantErr: "no data chunk"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeWAV(bytes.NewReader(tt.file))
+			if !errors.Is(err, ErrUnsupportedAudio) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("DecodeWAV error = %v, want ErrUnsupportedAudio with %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDecodeWAVUnsetDataSize(t *testing.T) {
+	// Two samples, followed by bytes outside the RIFF chunk such as an
+	// appended ID3 tag
+	file := testutil.WAVFile(testutil.WAVFmt(wavFormatPCM, 1, 8000, 16, 0), []byte{0, 0x40, 0, 0xC0})
+	trailer := []byte("ID3\x04\x00\x00\x00\x00\x00\x00")
+	withSizes := func(riff, data uint32, tail []byte) []byte {
+		f := append(append([]byte(nil), file...), tail...)
+		binary.LittleEndian.PutUint32(f[4:8], riff)
+		binary.LittleEndian.PutUint32(f[40:44], data)
+		return f
+	}
+	riff := uint32(len(file) - 8)
+
+	tests := []struct {
+		name string
+		file []byte
+		want int
+	}{
+		{name: "data size 0", file: withSizes(riff, 0, trailer), want: 2},
+		{name: "data size unset", file: withSizes(riff, math.MaxUint32, trailer), want: 2},
+		{name: "data past the RIFF chunk", file: withSizes(riff, 100, trailer), want: 2},
+		{name: "both sizes unset", file: withSizes(math.MaxUint32, math.MaxUint32, nil), want: 2},
+		{name: "both sizes 0", file: withSizes(0, 0, nil), want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := DecodeWAV(bytes.NewReader(tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(out.Samples) != tt.want || out.Samples[0] != 0.5 || out.Samples[1] != -0.5 {
+				t.Errorf("decoded %v, want [0.5 -0.5]", out.Samples)
+			}
+		})
+	}
+}
+
+func TestDecodeWAVLargeData(t *testing.T) {
+	// More data than one conversion block
+	in := sine(44100, 2, 440, -6, 2*time.Second)
+	out, err := DecodeWAV(bytes.NewReader(testutil.WAV(in.SampleRate, in.Channels, in.Samples)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Samples) != len(in.Samples) {
+		t.Fatalf("decoded %d samples, want %d", len(out.Samples), len(in.Samples))
+	}
+	for i := range in.Samples {
+		if math.Abs(float64(out.Samples[i]-in.Samples[i])) > 1.0/16384 {
+			t.Fatalf("sample %d = %v, want %v", i, out.Samples[i], in.Samples[i])
+		}
+	}
+}